@@ -0,0 +1,26 @@
+package main
+
+import (
+	"TemplatestPGSQL/internal/config"
+	"fmt"
+	"os"
+)
+
+// runConfig - подкоманда config print: выводит действующую конфигурацию со скрытыми секретами и ошибки её
+// проверки, возвращает код выхода. Выполняется до подключения к базе
+func runConfig(cfg config.AppConfig, loadErr error, args []string) int {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: tasks [flags] config print")
+		return 2
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const importActor = "cli"
+
+// runImport - подкоманда import: загружает задачи из файла или stdin, возвращает код выхода
+func runImport(ctx context.Context, repository repo.Repository, logger *zap.SugaredLogger, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "-", "path to CSV, NDJSON or iCalendar file, - for stdin")
+	format := flags.String("format", "", "csv, ndjson or ics, detected by file extension when empty")
+	mapping := flags.String("mapping", "", "CSV column mapping, e.g. title=Name,user_id=Owner")
+	dryRun := flags.Bool("dry-run", false, "validate only, report errors per line without writing")
+	userID := flags.String("user", "", "owner of tasks imported from ics")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	columns, err := service.ParseImportMapping(*mapping)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var src io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		src = f
+	}
+	if *format == "" {
+		*format = service.ImportFormatNDJSON
+		switch ext := filepath.Ext(*file); {
+		case strings.EqualFold(ext, ".csv"):
+			*format = service.ImportFormatCSV
+		case strings.EqualFold(ext, ".ics"):
+			*format = service.ImportFormatICS
+		}
+	}
+
+	report, err := service.NewImporter(repository, logger).Import(ctx, importActor, "", src, service.ImportOptions{
+		Format:  *format,
+		Mapping: columns,
+		DryRun:  *dryRun,
+		UserID:  *userID,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import failed:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -2,30 +2,47 @@ package main
 
 import (
 	"TemplatestPGSQL/internal/api"
+	"TemplatestPGSQL/internal/api/middleware"
+	"TemplatestPGSQL/internal/auth"
 	"TemplatestPGSQL/internal/config"
+	"TemplatestPGSQL/internal/gql"
+	"TemplatestPGSQL/internal/grpcapi"
+	"TemplatestPGSQL/internal/health"
+	"TemplatestPGSQL/internal/lifecycle"
 	customLogger "TemplatestPGSQL/internal/logger"
+	"TemplatestPGSQL/internal/metrics"
+	"TemplatestPGSQL/internal/ratelimit"
 	"TemplatestPGSQL/internal/repo"
 	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	"TemplatestPGSQL/internal/tracing"
+	"TemplatestPGSQL/internal/webhook"
+	"TemplatestPGSQL/migrations"
+	"TemplatestPGSQL/pkg/i18n"
 	"context"
-	"github.com/joho/godotenv"
+	"errors"
+	"flag"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-
-	"github.com/kelseyhightower/envconfig"
+	"time"
 )
 
 func main() {
 
-	// Config
-	if err := godotenv.Load(config.EnvPath); err != nil {
-		log.Fatalf("failed to load config file %s: %v", config.EnvPath, err)
+	// Config: defaults, config file, environment, command-line flags
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
 	}
-
-	var cfg config.AppConfig
-	if err := envconfig.Process("", &cfg); err != nil {
-		log.Fatalf("failed to process config file %s: %v", config.EnvPath, err)
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfig(cfg, err, args[1:]))
+	}
+	if err != nil {
+		log.Fatal("failed to load config: ", err)
 	}
 
 	// Logger
@@ -34,6 +51,11 @@ func main() {
 		log.Fatal("failed to initialize logger: ", err)
 	}
 
+	// Messages
+	if err = i18n.SetDefault(cfg.DefaultLanguage); err != nil {
+		log.Fatal("failed to set default language: ", err)
+	}
+
 	// Repository
 	repository, err := repo.NewRepository(context.Background(), cfg.Memory)
 	if err != nil {
@@ -44,11 +66,71 @@ func main() {
 		log.Fatal("failed to set up repo: ", err)
 	}
 
+	// Subcommands
+	if len(args) > 0 && args[0] == "import" {
+		os.Exit(runImport(context.Background(), repository, logger, args[1:]))
+	}
+
+	// Tracing; the import report goes to stdout, so spans are written for the server only
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("failed to initialize tracing: ", err)
+	}
+
 	// Service initialization
-	serviceInstance := service.NewService(repository, logger)
+	token := cfg.Auth.Token
+	signer := auth.NewSigner(cfg.Auth.SigningKey, cfg.Auth.TokenTTL)
+	serviceInstance := service.NewService(repository, logger, signer)
+
+	// Lifecycle: background workers and shutdown steps
+	lifecycleManager := lifecycle.New(cfg.ShutdownTimeout, logger)
+
+	// Webhooks dispatching
+	lifecycleManager.Go(webhook.NewDispatcher(repository, cfg.Webhook, logger).Run)
+
+	// Task events streaming; the hub stops separately, before HTTP draining, to close open streams
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	hub := stream.NewHub(repository, logger)
+	go hub.Run(hubCtx)
+
+	// Idempotency keys expiration
+	idempotency := middleware.NewIdempotency(repository, cfg.Rest.IdempotencyTTL, logger)
+	lifecycleManager.Go(idempotency.Run)
+
+	// Rate limiting; the postgres backend shares buckets between replicas
+	rateLimits := cfg.RateLimit.Limits()
+	var limiter ratelimit.Limiter
+	if cfg.RateLimit.Backend == ratelimit.BackendPostgres {
+		sharedLimiter := ratelimit.NewPostgres(repository, rateLimits.MaxPeriod(), logger)
+		lifecycleManager.Go(sharedLimiter.Run)
+		limiter = sharedLimiter
+	} else {
+		memoryLimiter := ratelimit.NewMemory()
+		lifecycleManager.Go(memoryLimiter.Run)
+		limiter = memoryLimiter
+	}
+	rateLimit := ratelimit.NewPolicy(limiter, rateLimits)
+
+	// Metrics
+	appMetrics := metrics.New()
+	if err = appMetrics.Register(metrics.NewStoreCollector(repository, cfg.Admin.MetricsTimeout, logger)); err != nil {
+		log.Fatal("failed to register metrics: ", err)
+	}
 
 	// Routers initialization
-	app := api.NewRouters(&api.Routers{Service: serviceInstance}, "token")
+	app := api.NewRouters(&api.Routers{
+		Service:     serviceInstance,
+		Signer:      signer,
+		Stream:      hub,
+		GraphQL:     gql.NewServer(serviceInstance, repository, logger),
+		Idempotency: idempotency,
+		Metrics:     appMetrics,
+		RateLimit:   rateLimit,
+		Logger:      logger,
+
+		RequestTimeout: cfg.Rest.RequestTimeout,
+		ExportTimeout:  cfg.Rest.ExportTimeout,
+	}, token)
 
 	// Listening and serving
 	go func() {
@@ -58,10 +140,105 @@ func main() {
 		}
 	}()
 
+	// gRPC API on a separate port
+	grpcServer := grpcapi.NewServer(&grpcapi.Server{
+		Service:   serviceInstance,
+		Signer:    signer,
+		Stream:    hub,
+		Logger:    logger,
+		Timeout:   cfg.Rest.RequestTimeout,
+		RateLimit: rateLimit,
+	}, token)
+	go func() {
+		listener, err := net.Listen("tcp", cfg.Grpc.Port)
+		if err != nil {
+			log.Fatalf("failed to listen gRPC port: %v", err)
+		}
+		logger.Infof("Starting gRPC server on %s", cfg.Grpc.Port)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("failed to start gRPC server: %v", err)
+		}
+	}()
+
+	// Readiness checks
+	readiness := health.NewRegistry(cfg.Admin.HealthTimeout)
+	readiness.Register("postgres", health.Postgres(repository))
+	readiness.Register("migrations", health.Migrations(repository, migrations.Version()))
+
+	// Admin listener with metrics and probes, separate from the public API
+	adminMux := http.NewServeMux()
+	adminMux.Handle(metrics.Path, appMetrics.Handler())
+	adminMux.Handle(health.LivenessPath, health.Liveness())
+	adminMux.Handle(health.ReadinessPath, readiness.Readiness())
+	adminServer := &http.Server{Addr: cfg.Admin.Port, Handler: adminMux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		logger.Infof("Starting admin server on %s", cfg.Admin.Port)
+		if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to start admin server: %v", err)
+		}
+	}()
+
 	// Fold operations
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	<-signalChan
 
 	logger.Info("Shutting down gracefully...")
+	lifecycleManager.OnShutdown("readiness", func(context.Context) error {
+		readiness.Shutdown()
+		return nil
+	})
+	// порты ещё открыты: запросы, направленные до того, как балансировщик заметил отказ /readyz, выполняются
+	lifecycleManager.OnShutdown("delay", func(ctx context.Context) error {
+		select {
+		case <-time.After(cfg.ShutdownDelay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	// остановка хаба закрывает потоки SSE, WebSocket и WatchTasks, иначе сервер ждёт их до конца срока
+	lifecycleManager.OnShutdown("streams", func(ctx context.Context) error {
+		stopHub()
+		select {
+		case <-hub.Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	lifecycleManager.OnShutdown("http", app.ShutdownWithContext)
+	lifecycleManager.OnShutdown("grpc", func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+	lifecycleManager.OnShutdown("workers", lifecycleManager.StopWorkers)
+	lifecycleManager.OnShutdown("admin", adminServer.Shutdown)
+	// накопленные спаны дописываются в экспортёр
+	lifecycleManager.OnShutdown("tracing", shutdownTracing)
+	lifecycleManager.OnShutdown("postgres", func(context.Context) error {
+		repository.Close()
+		return nil
+	})
+
+	err = lifecycleManager.Shutdown()
+	if err != nil {
+		logger.Error("Shutdown finished with errors: ", err)
+	} else {
+		logger.Info("Shutdown completed")
+	}
+	_ = logger.Sync()
+	if errors.Is(err, lifecycle.ErrDeadlineExceeded) {
+		os.Exit(1)
+	}
 }
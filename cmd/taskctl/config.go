@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configEnv      = "TASKCTL_CONFIG"
+	profileEnv     = "TASKCTL_PROFILE"
+	defaultProfile = "default"
+	defaultServer  = "http://localhost:8080"
+)
+
+// Config - файл профилей; в нём токены, поэтому он пишется с правами 0600
+type Config struct {
+	Current  string              `yaml:"current"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile - сервер и токен, с которыми выполняются команды
+type Profile struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token,omitempty"`
+	// UserID - пользователь токена, пустой для сервисного токена
+	UserID string `yaml:"user_id,omitempty"`
+}
+
+// defaultConfigPath - $TASKCTL_CONFIG или taskctl/config.yaml в каталоге настроек пользователя
+func defaultConfigPath() string {
+	if path := os.Getenv(configEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "taskctl", "config.yaml")
+}
+
+// loadConfig читает файл профилей, отсутствующий файл - пустой набор профилей
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{Profiles: map[string]*Profile{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config")
+	}
+	if err = yaml.Unmarshal(raw, cfg); err != nil {
+		return nil, errors.Wrapf(err, "invalid config %s", path)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+	return cfg, nil
+}
+
+// save записывает файл целиком через временный файл, чтобы не оставить его наполовину записанным
+func (c *Config) save(path string) error {
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal config")
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create config dir")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return errors.Wrap(err, "failed to write config")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(raw); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write config")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write config")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "failed to write config")
+}
+
+// profileNames - имена профилей по алфавиту
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"TemplatestPGSQL/pkg/client"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// taskctl - клиент командной строки для API задач:
+//
+//	taskctl [-config file] [-profile name] <command> [flags]
+
+// errUsage - неверные аргументы, сообщение уже выведено; код выхода 2
+var errUsage = errors.New("usage")
+
+// app - окружение команды: профили, выбранный профиль и потоки ввода-вывода
+type app struct {
+	cfg         *Config
+	cfgPath     string
+	profileName string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"login":   {"login [-server url] [-name name] [-password password]", runLogin},
+	"profile": {"profile list | use <name> | set <name> [-server url] [-token token] | delete <name>", runProfile},
+	"tasks":   {"tasks list | get <id> | create | status <id> <status> | delete <id>", runTasks},
+	"users":   {"users list | create [-name name] [-password password] | delete <id>", runUsers},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run выполняет команду и возвращает код выхода: 0 - успех, 1 - ошибка, 2 - неверные аргументы
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("taskctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	cfgPath := flags.String("config", defaultConfigPath(), "profiles file, $"+configEnv+" by default")
+	profile := flags.String("profile", os.Getenv(profileEnv), "profile to use instead of the current one")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: taskctl [-config file] [-profile name] <command> [flags]")
+		flags.PrintDefaults()
+		fmt.Fprintln(stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(stderr, "  "+commands[name].usage)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := loadConfig(*cfgPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "taskctl:", err)
+		return 1
+	}
+	a := &app{cfg: cfg, cfgPath: *cfgPath, profileName: *profile, stdin: stdin, stdout: stdout, stderr: stderr}
+	if a.profileName == "" {
+		a.profileName = cfg.Current
+	}
+	if a.profileName == "" {
+		a.profileName = defaultProfile
+	}
+
+	switch err = cmd.run(ctx, a, flags.Args()[1:]); {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.Is(err, client.ErrUnauthorized):
+		fmt.Fprintf(stderr, "taskctl: %v\ntoken of profile %q is missing or expired, run taskctl login\n", err, a.profileName)
+	default:
+		fmt.Fprintln(stderr, "taskctl:", err)
+	}
+	return 1
+}
+
+// profile - выбранный профиль, ошибка если его нет
+func (a *app) profile() (*Profile, error) {
+	p, ok := a.cfg.Profiles[a.profileName]
+	if !ok {
+		return nil, errors.Errorf("profile %q not found, run taskctl login", a.profileName)
+	}
+	return p, nil
+}
+
+// client - клиент API для выбранного профиля
+func (a *app) client() (*client.Client, error) {
+	p, err := a.profile()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(p.Server, client.WithToken(p.Token))
+}
+
+// flagSet - набор флагов подкоманды; ошибки разбора печатаются в stderr
+func (a *app) flagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	return flags
+}
+
+// parse разбирает флаги вперемешку с позиционными аргументами и проверяет число последних
+func (a *app) parse(flags *flag.FlagSet, args []string, positional ...string) ([]string, error) {
+	var values []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		values, args = append(values, flags.Arg(0)), flags.Args()[1:]
+	}
+	if output := flags.Lookup("o"); output != nil && !validOutput(output.Value.String()) {
+		fmt.Fprintf(a.stderr, "unknown output format %q, want table, json or yaml\n", output.Value.String())
+		return nil, errUsage
+	}
+	if len(values) != len(positional) {
+		fmt.Fprintf(a.stderr, "usage: taskctl %s [flags]", flags.Name())
+		for _, name := range positional {
+			fmt.Fprintf(a.stderr, " <%s>", name)
+		}
+		fmt.Fprintln(a.stderr)
+		flags.PrintDefaults()
+		return nil, errUsage
+	}
+	return values, nil
+}
+
+// outputFlag добавляет флаг -o, его значение проверяет parse
+func outputFlag(flags *flag.FlagSet) *string {
+	return flags.String("o", outputTable, "output format: table, json or yaml")
+}
+
+func (a *app) output(format string, v any, t table) error {
+	return writeOutput(a.stdout, format, v, t)
+}
+
+// subcommand выбирает подкоманду по первому аргументу
+func (a *app) subcommand(ctx context.Context, name string, args []string, subs map[string]func(ctx context.Context, a *app, args []string) error) error {
+	if len(args) == 0 || subs[args[0]] == nil {
+		names := make([]string, 0, len(subs))
+		for sub := range subs {
+			names = append(names, sub)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(a.stderr, "usage: taskctl %s %s\n", name, strings.Join(names, " | "))
+		return errUsage
+	}
+	return subs[args[0]](ctx, a, args[1:])
+}
+
+// secret берёт значение из флага, переменной окружения или первой строки stdin
+func (a *app) secret(value, env, prompt string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if value = os.Getenv(env); value != "" {
+		return value, nil
+	}
+	fmt.Fprint(a.stderr, prompt+": ")
+	var line strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := a.stdin.Read(buf)
+		if n == 1 && buf[0] != '\n' {
+			line.WriteByte(buf[0])
+			continue
+		}
+		if n == 1 || errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read "+prompt)
+		}
+	}
+	return strings.TrimSuffix(line.String(), "\r"), nil
+}
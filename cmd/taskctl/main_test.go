@@ -0,0 +1,144 @@
+package main
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/pkg/client"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, status int, response dto.Response) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(response)
+	}
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer user-token" {
+				reply(w, http.StatusUnauthorized, dto.Response{Status: "error", Error: &dto.Error{Code: dto.Unauthorized}})
+				return
+			}
+			next(w, r)
+		}
+	}
+
+	mux.HandleFunc("POST /v1/login", func(w http.ResponseWriter, r *http.Request) {
+		var req client.UserRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "secret", req.Password)
+		reply(w, http.StatusOK, dto.Response{Status: "success", Data: client.LoginResult{Token: "user-token", UserID: "3"}})
+	})
+	mux.HandleFunc("GET /v1/tasks/all", authorized(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "done", r.URL.Query().Get("status"))
+		task := client.Task{UserID: "3", Tags: []string{"#work"}}
+		task.ID, task.Title, task.Status = "7", "Report", "done"
+		reply(w, http.StatusOK, dto.Response{Status: "success", Data: []client.Task{task}})
+	}))
+	mux.HandleFunc("POST /v1/tasks", authorized(func(w http.ResponseWriter, r *http.Request) {
+		var req client.CreateTaskRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "3", req.UserID, "owner defaults to the profile user")
+		reply(w, http.StatusOK, dto.Response{Status: "success", Data: map[string]string{"task_id": "8"}})
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runTaskctl(t *testing.T, cfgPath, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-config", cfgPath}, args...), strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestTaskctl(t *testing.T) {
+	server := newTestServer(t)
+	cfgPath := filepath.Join(t.TempDir(), "taskctl", "config.yaml")
+
+	code, _, stderr := runTaskctl(t, cfgPath, "", "tasks", "list")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "run taskctl login")
+
+	// пароль читается из stdin
+	code, _, stderr = runTaskctl(t, cfgPath, "secret\n", "login", "-server", server.URL, "-name", "ops")
+	require.Equal(t, 0, code, stderr)
+
+	info, err := os.Stat(cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	cfg, err := loadConfig(cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, defaultProfile, cfg.Current)
+	assert.Equal(t, Profile{Server: server.URL, Token: "user-token", UserID: "3"}, *cfg.Profiles[defaultProfile])
+
+	t.Run("table", func(t *testing.T) {
+		code, stdout, stderr := runTaskctl(t, cfgPath, "", "tasks", "list", "-status", "done")
+		require.Equal(t, 0, code, stderr)
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, taskHeader, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"7", "3", "done", "Report", "#work"}, strings.Fields(lines[1])[:5])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		code, stdout, stderr := runTaskctl(t, cfgPath, "", "tasks", "list", "-status", "done", "-o", "json")
+		require.Equal(t, 0, code, stderr)
+		var tasks []client.Task
+		require.NoError(t, json.Unmarshal([]byte(stdout), &tasks))
+		assert.Equal(t, "Report", tasks[0].Title)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		code, stdout, stderr := runTaskctl(t, cfgPath, "", "tasks", "list", "-status", "done", "-o", "yaml")
+		require.Equal(t, 0, code, stderr)
+		assert.Contains(t, stdout, "- created_at:")
+		assert.Contains(t, stdout, "  title: Report\n")
+	})
+
+	t.Run("create", func(t *testing.T) {
+		code, stdout, stderr := runTaskctl(t, cfgPath, "", "tasks", "create", "-title", "Call")
+		require.Equal(t, 0, code, stderr)
+		assert.Equal(t, "8\n", stdout)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		code, _, stderr := runTaskctl(t, cfgPath, "", "tasks", "status", "7")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "<id> <status>")
+
+		code, _, _ = runTaskctl(t, cfgPath, "", "tasks", "list", "-o", "xml")
+		assert.Equal(t, 2, code)
+	})
+
+	t.Run("profiles", func(t *testing.T) {
+		code, _, stderr := runTaskctl(t, cfgPath, "", "profile", "set", "admin", "-server", server.URL, "-token", "service")
+		require.Equal(t, 0, code, stderr)
+		code, _, stderr = runTaskctl(t, cfgPath, "", "profile", "use", "admin")
+		require.Equal(t, 0, code, stderr)
+
+		code, stdout, _ := runTaskctl(t, cfgPath, "", "profile", "list")
+		require.Equal(t, 0, code)
+		assert.NotContains(t, stdout, "user-token")
+		assert.Regexp(t, `\*\s+admin`, stdout)
+
+		// сервисный токен тестовый сервер не принимает
+		code, _, _ = runTaskctl(t, cfgPath, "", "tasks", "list")
+		assert.Equal(t, 1, code)
+		code, _, stderr = runTaskctl(t, cfgPath, "", "-profile", "default", "tasks", "list", "-status", "done")
+		assert.Equal(t, 0, code, stderr)
+	})
+}
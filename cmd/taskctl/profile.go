@@ -0,0 +1,151 @@
+package main
+
+import (
+	"TemplatestPGSQL/pkg/client"
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const passwordEnv = "TASKCTL_PASSWORD"
+
+// runLogin получает токен пользователя и сохраняет его в профиль, профиль становится текущим
+func runLogin(ctx context.Context, a *app, args []string) error {
+	server := defaultServer
+	if p, err := a.profile(); err == nil {
+		server = p.Server
+	}
+
+	flags := a.flagSet("login")
+	flags.StringVar(&server, "server", server, "API base URL")
+	name := flags.String("name", "", "user name")
+	password := flags.String("password", "", "password, $"+passwordEnv+" or stdin when empty")
+	if _, err := a.parse(flags, args); err != nil {
+		return err
+	}
+	if *name == "" {
+		fmt.Fprintln(a.stderr, "login: -name is required")
+		return errUsage
+	}
+	secret, err := a.secret(*password, passwordEnv, "password")
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(server)
+	if err != nil {
+		return err
+	}
+	result, err := c.Login(ctx, *name, secret)
+	if err != nil {
+		return err
+	}
+
+	a.cfg.Profiles[a.profileName] = &Profile{Server: server, Token: result.Token, UserID: result.UserID}
+	a.cfg.Current = a.profileName
+	if err = a.cfg.save(a.cfgPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "logged in as %s (user %s), profile %q\n", *name, result.UserID, a.profileName)
+	return nil
+}
+
+func runProfile(ctx context.Context, a *app, args []string) error {
+	return a.subcommand(ctx, "profile", args, map[string]func(context.Context, *app, []string) error{
+		"list":   profileList,
+		"use":    profileUse,
+		"set":    profileSet,
+		"delete": profileDelete,
+	})
+}
+
+func profileList(_ context.Context, a *app, args []string) error {
+	flags := a.flagSet("profile list")
+	output := outputFlag(flags)
+	if _, err := a.parse(flags, args); err != nil {
+		return err
+	}
+
+	// токены не выводятся
+	type profileView struct {
+		Name    string `json:"name"`
+		Server  string `json:"server"`
+		UserID  string `json:"user_id,omitempty"`
+		Current bool   `json:"current"`
+	}
+	views := make([]profileView, 0, len(a.cfg.Profiles))
+	t := table{header: []string{"CURRENT", "NAME", "SERVER", "USER"}}
+	for _, name := range a.cfg.profileNames() {
+		p := a.cfg.Profiles[name]
+		view := profileView{Name: name, Server: p.Server, UserID: p.UserID, Current: name == a.cfg.Current}
+		views = append(views, view)
+
+		mark := ""
+		if view.Current {
+			mark = "*"
+		}
+		t.rows = append(t.rows, []string{mark, name, p.Server, p.UserID})
+	}
+	return a.output(*output, views, t)
+}
+
+func profileUse(_ context.Context, a *app, args []string) error {
+	positional, err := a.parse(a.flagSet("profile use"), args, "name")
+	if err != nil {
+		return err
+	}
+	if _, ok := a.cfg.Profiles[positional[0]]; !ok {
+		return errors.Errorf("profile %q not found", positional[0])
+	}
+	a.cfg.Current = positional[0]
+	return a.cfg.save(a.cfgPath)
+}
+
+// profileSet создаёт или меняет профиль без входа, например для сервисного токена
+func profileSet(_ context.Context, a *app, args []string) error {
+	flags := a.flagSet("profile set")
+	server := flags.String("server", "", "API base URL")
+	token := flags.String("token", "", "API token, e.g. the service token")
+	userID := flags.String("user", "", "user of the token, empty for the service token")
+	positional, err := a.parse(flags, args, "name")
+	if err != nil {
+		return err
+	}
+
+	p, ok := a.cfg.Profiles[positional[0]]
+	if !ok {
+		p = &Profile{Server: defaultServer}
+		a.cfg.Profiles[positional[0]] = p
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			p.Server = *server
+		case "token":
+			p.Token = *token
+		case "user":
+			p.UserID = *userID
+		}
+	})
+	if a.cfg.Current == "" {
+		a.cfg.Current = positional[0]
+	}
+	return a.cfg.save(a.cfgPath)
+}
+
+func profileDelete(_ context.Context, a *app, args []string) error {
+	positional, err := a.parse(a.flagSet("profile delete"), args, "name")
+	if err != nil {
+		return err
+	}
+	if _, ok := a.cfg.Profiles[positional[0]]; !ok {
+		return errors.Errorf("profile %q not found", positional[0])
+	}
+	delete(a.cfg.Profiles, positional[0])
+	if a.cfg.Current == positional[0] {
+		a.cfg.Current = ""
+	}
+	return a.cfg.save(a.cfgPath)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"TemplatestPGSQL/pkg/client"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var taskHeader = []string{"ID", "USER", "STATUS", "TITLE", "TAGS", "DUE", "CREATED"}
+
+func runTasks(ctx context.Context, a *app, args []string) error {
+	return a.subcommand(ctx, "tasks", args, map[string]func(context.Context, *app, []string) error{
+		"list":   tasksList,
+		"get":    tasksGet,
+		"create": tasksCreate,
+		"status": tasksStatus,
+		"delete": tasksDelete,
+	})
+}
+
+func tasksList(ctx context.Context, a *app, args []string) error {
+	var filter client.TaskListRequest
+	flags := a.flagSet("tasks list")
+	flags.StringVar(&filter.Status, "status", "", "new, in_progress or done")
+	flags.StringVar(&filter.UserID, "user", "", "owner id")
+	flags.StringVar(&filter.Tag, "tag", "", "tag, e.g. #work")
+	flags.StringVar(&filter.CreatedFrom, "created-from", "", "created at or after, RFC3339")
+	flags.StringVar(&filter.CreatedTo, "created-to", "", "created before, RFC3339")
+	flags.StringVar(&filter.DueFrom, "due-from", "", "due at or after, RFC3339")
+	flags.StringVar(&filter.DueTo, "due-to", "", "due before, RFC3339")
+	limit := flags.Int("limit", 0, "max tasks to print, 0 - all")
+	output := outputFlag(flags)
+	if _, err := a.parse(flags, args); err != nil {
+		return err
+	}
+	if *limit > 0 && *limit < client.DefaultPageSize {
+		filter.Limit = *limit
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	tasks := make([]client.Task, 0)
+	for task, err := range c.Tasks(ctx, filter) {
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, task)
+		if len(tasks) == *limit {
+			break
+		}
+	}
+	return a.output(*output, tasks, tasksTable(tasks...))
+}
+
+func tasksGet(ctx context.Context, a *app, args []string) error {
+	flags := a.flagSet("tasks get")
+	output := outputFlag(flags)
+	positional, err := a.parse(flags, args, "id")
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	task, err := c.GetTaskByID(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return a.output(*output, task, tasksTable(*task))
+}
+
+func tasksCreate(ctx context.Context, a *app, args []string) error {
+	var req client.CreateTaskRequest
+	flags := a.flagSet("tasks create")
+	flags.StringVar(&req.Title, "title", "", "title")
+	flags.StringVar(&req.Data, "data", "", "description")
+	flags.StringVar(&req.UserID, "user", "", "owner id, the profile user by default")
+	due := flags.String("due", "", "due date, RFC3339")
+	if _, err := a.parse(flags, args); err != nil {
+		return err
+	}
+	if *due != "" {
+		dueAt, err := time.Parse(time.RFC3339, *due)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "tasks create: invalid -due %q, want RFC3339\n", *due)
+			return errUsage
+		}
+		req.DueAt = &dueAt
+	}
+
+	p, err := a.profile()
+	if err != nil {
+		return err
+	}
+	if req.UserID == "" {
+		req.UserID = p.UserID
+	}
+	if req.Title == "" || req.UserID == "" {
+		fmt.Fprintln(a.stderr, "tasks create: -title and -user are required")
+		return errUsage
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	id, err := c.CreateTask(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.stdout, id)
+	return nil
+}
+
+func tasksStatus(ctx context.Context, a *app, args []string) error {
+	flags := a.flagSet("tasks status")
+	version := versionFlag(flags)
+	positional, err := a.parse(flags, args, "id", "status")
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	return c.UpdateStatusByID(ctx, positional[0], positional[1], *version)
+}
+
+func tasksDelete(ctx context.Context, a *app, args []string) error {
+	flags := a.flagSet("tasks delete")
+	version := versionFlag(flags)
+	positional, err := a.parse(flags, args, "id")
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(c.DeleteTaskByID(ctx, positional[0], *version), "task %s", positional[0])
+}
+
+func versionFlag(flags *flag.FlagSet) *int {
+	return flags.Int("version", 0, "expected task version, 0 - overwrite any version")
+}
+
+func tasksTable(tasks ...client.Task) table {
+	t := table{header: taskHeader}
+	for _, task := range tasks {
+		due := ""
+		if task.DueAt != nil {
+			due = task.DueAt.Format(time.RFC3339)
+		}
+		t.rows = append(t.rows, []string{
+			task.ID, task.UserID, task.Status, task.Title, strings.Join(task.Tags, ","), due,
+			task.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return t
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"TemplatestPGSQL/pkg/client"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func runUsers(ctx context.Context, a *app, args []string) error {
+	return a.subcommand(ctx, "users", args, map[string]func(context.Context, *app, []string) error{
+		"list":   usersList,
+		"create": usersCreate,
+		"delete": usersDelete,
+	})
+}
+
+func usersList(ctx context.Context, a *app, args []string) error {
+	flags := a.flagSet("users list")
+	output := outputFlag(flags)
+	if _, err := a.parse(flags, args); err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	users, err := c.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "NAME", "CREATED"}}
+	for _, user := range users {
+		t.rows = append(t.rows, []string{user.ID, user.Name, user.CreatedAt.Format(time.RFC3339)})
+	}
+	return a.output(*output, users, t)
+}
+
+func usersCreate(ctx context.Context, a *app, args []string) error {
+	var req client.UserRequest
+	flags := a.flagSet("users create")
+	flags.StringVar(&req.Name, "name", "", "user name")
+	flags.StringVar(&req.Password, "password", "", "password, $"+passwordEnv+" or stdin when empty")
+	if _, err := a.parse(flags, args); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		fmt.Fprintln(a.stderr, "users create: -name is required")
+		return errUsage
+	}
+	password, err := a.secret(req.Password, passwordEnv, "password")
+	if err != nil {
+		return err
+	}
+	req.Password = password
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	id, err := c.CreateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.stdout, id)
+	return nil
+}
+
+func usersDelete(ctx context.Context, a *app, args []string) error {
+	positional, err := a.parse(a.flagSet("users delete"), args, "id")
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(c.DeleteUserByID(ctx, positional[0]), "user %s", positional[0])
+}
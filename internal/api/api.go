@@ -2,35 +2,119 @@ package api
 
 import (
 	"TemplatestPGSQL/internal/api/middleware"
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/gql"
+	"TemplatestPGSQL/internal/metrics"
+	"TemplatestPGSQL/internal/ratelimit"
 	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	"TemplatestPGSQL/pkg/i18n"
+	"TemplatestPGSQL/pkg/openapi"
+	"encoding/json"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"go.uber.org/zap"
 )
 
 type Routers struct {
-	Service service.Service
+	Service     service.Service
+	Signer      *auth.Signer
+	Stream      *stream.Hub
+	GraphQL     *gql.Server
+	Idempotency *middleware.Idempotency
+	// Metrics - необязательные метрики запросов
+	Metrics *metrics.Metrics
+	// RateLimit - ограничения частоты запросов по группам маршрутов, nil - без ограничений
+	RateLimit *ratelimit.Policy
+	Logger    *zap.SugaredLogger
+	// RequestTimeout - срок обработки запроса, нулевой - без срока
+	RequestTimeout time.Duration
+	// ExportTimeout - срок записи файла выгрузки и ленты календаря после ответа обработчика, нулевой - без срока
+	ExportTimeout time.Duration
 }
 
 func NewRouters(r *Routers, token string) *fiber.App {
-	app := fiber.New()
+	// тело запроса читается потоком, чтобы импорт не держал файл целиком в памяти
+	app := fiber.New(fiber.Config{StreamRequestBody: true, ErrorHandler: errorHandler})
 
 	app.Use(cors.New(cors.Config{
 		AllowMethods:  "GET, POST, PUT, DELETE",
-		AllowHeaders:  "Accept, Authorization, Content-Type, X-CSRF-Token, X-REQUEST-ID",
-		ExposeHeaders: "Link",
+		AllowHeaders:  "Accept, Authorization, Content-Type, X-CSRF-Token, X-Request-ID, Idempotency-Key, If-Match, If-None-Match, traceparent, tracestate",
+		ExposeHeaders: "Link, Idempotent-Replayed, ETag, X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy",
 		MaxAge:        300,
 	}))
 
-	apiGroup := app.Group("/v1", middleware.Authorization(token))
-
-	apiGroup.Post("/tasks", r.Service.CreateTask)
-	apiGroup.Post("/users", r.Service.CreateUser)
-	apiGroup.Get("/tasks/all", r.Service.GetAllTasks)
-	apiGroup.Get("/tasks/users/:id", r.Service.GetAllTasksByUserID)
-	apiGroup.Delete("/tasks/:id", r.Service.DeleteTaskByID)
-	apiGroup.Put("/tasks/:id", r.Service.UpdateStatusByID)
-	apiGroup.Get("tasks/users/:id/last", r.Service.GetLastTaskByUserID)
-	apiGroup.Get("tasks/:id", r.Service.GetTaskByID)
-	apiGroup.Get("tasks/users/:username", r.Service.GetTasksByUserName)
+	// идентификатор запроса попадает в журнал аудита и в логи через контекст сервиса
+	app.Use(middleware.RequestID(r.Logger), middleware.Tracing(r.Logger))
+	if r.Metrics != nil {
+		app.Use(middleware.Metrics(r.Metrics))
+	}
+	app.Use(middleware.AccessLog(r.Logger))
+
+	// язык сообщений об ошибках выбирается по Accept-Language
+	app.Use(func(ctx *fiber.Ctx) error {
+		lang := i18n.Match(ctx.Get(fiber.HeaderAcceptLanguage))
+		ctx.Set(fiber.HeaderContentLanguage, lang)
+		ctx.SetUserContext(i18n.WithLanguage(ctx.UserContext(), lang))
+		return ctx.Next()
+	})
+
+	handlers := NewHandlers(r.Service, r.Logger, r.ExportTimeout)
+
+	// документ собирается после регистрации всех маршрутов
+	var spec []byte
+	app.Get(specPath, func(ctx *fiber.Ctx) error {
+		ctx.Type("json")
+		return ctx.Send(spec)
+	})
+	app.Get(docsPath, openapi.UI(apiSpec.Info.Title, specPath))
+	app.Get(dto.ProblemTypeBase, handlers.GetErrorCatalog)
+
+	// без авторизации запросы считаются по IP клиента
+	apiLimit := middleware.RateLimit(r.RateLimit, ratelimit.GroupAPI, r.Logger)
+	heavyLimit := middleware.RateLimit(r.RateLimit, ratelimit.GroupHeavy, r.Logger)
+	// срок ставится каждому маршруту, кроме импорта: он читает файл потоком и может идти дольше обычного запроса
+	timeout := middleware.Timeout(r.RequestTimeout)
+
+	app.Post("/v1/login", middleware.RateLimit(r.RateLimit, ratelimit.GroupLogin, r.Logger), timeout, handlers.Login)
+	// календари не передают Authorization, лента проверяет собственный токен из query
+	app.Get("/calendar/:user_id/tasks.ics", apiLimit, timeout, handlers.CalendarFeed)
+
+	authorization := middleware.Authorization(token, r.Signer)
+	app.Post("/graphql", authorization, apiLimit, timeout, r.GraphQL.Handler())
+
+	apiGroup := app.Group("/v1", authorization, apiLimit)
+	idempotent := r.Idempotency.Handler()
+
+	apiGroup.Post("/tasks", timeout, idempotent, handlers.CreateTask)
+	apiGroup.Post("/tasks/bulk", heavyLimit, timeout, idempotent, handlers.BulkTasks)
+	apiGroup.Post("/tasks/import", heavyLimit, handlers.ImportTasks)
+	apiGroup.Post("/users", timeout, idempotent, handlers.CreateUser)
+	apiGroup.Get("/users", middleware.AdminOnly(), timeout, handlers.GetUsers)
+	apiGroup.Delete("/users/:id", middleware.AdminOnly(), timeout, handlers.DeleteUserByID)
+	apiGroup.Get("/tasks/all", timeout, handlers.GetAllTasks)
+	apiGroup.Get("/tasks/stream", r.Stream.SSE())
+	apiGroup.Get("/tasks/ws", r.Stream.WebSocket())
+	apiGroup.Get("/tasks/export", heavyLimit, timeout, handlers.ExportTasks)
+	apiGroup.Get("/calendar", timeout, handlers.GetCalendarLink)
+	apiGroup.Get("/tasks/users/:id", timeout, handlers.GetAllTasksByUserID)
+	apiGroup.Delete("/tasks/:id", timeout, handlers.DeleteTaskByID)
+	apiGroup.Put("/tasks/:id", timeout, handlers.UpdateStatusByID)
+	apiGroup.Get("tasks/users/:id/last", timeout, handlers.GetLastTaskByUserID)
+	apiGroup.Get("tasks/:id", timeout, handlers.GetTaskByID)
+	apiGroup.Get("tasks/users/name/:username", timeout, handlers.GetTasksByUserName)
+	apiGroup.Get("/tasks/:id/activity", timeout, handlers.GetTaskActivity)
+	apiGroup.Get("/audit", middleware.AdminOnly(), timeout, handlers.GetAuditRecords)
+
+	webhooks := apiGroup.Group("/webhooks", middleware.AdminOnly(), timeout)
+	webhooks.Post("/", handlers.CreateWebhook)
+	webhooks.Get("/", handlers.GetWebhooks)
+	webhooks.Delete("/:id", handlers.DeleteWebhookByID)
+	webhooks.Get("/:id/deliveries", handlers.GetWebhookDeliveries)
+
+	spec, _ = json.Marshal(apiSpec.Build(app.GetRoutes(true)))
 	return app
 }
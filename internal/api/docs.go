@@ -0,0 +1,278 @@
+package api
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/gql"
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/pkg/openapi"
+	"TemplatestPGSQL/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Описание API: таблица маршрутов берётся из приложения, здесь только то, чего в ней нет.
+// Маршрут без описания попадает в документ с x-undocumented и роняет TestOpenAPICoversRoutes.
+
+const (
+	specPath = "/openapi.json"
+	docsPath = "/docs"
+
+	tagTasks    = "tasks"
+	tagUsers    = "users"
+	tagAudit    = "audit"
+	tagWebhooks = "webhooks"
+	tagCalendar = "calendar"
+	tagGraphQL  = "graphql"
+	tagDocs     = "docs"
+)
+
+type createdTask struct {
+	TaskID string `json:"task_id"`
+}
+
+type createdUser struct {
+	UserID string `json:"user_id"`
+}
+
+// idempotencyHeader - повтор с тем же ключом и телом получает сохранённый ответ с заголовком Idempotent-Replayed
+type idempotencyHeader struct {
+	Key string `header:"Idempotency-Key" validate:"omitempty,max=255"`
+}
+
+// idempotencyErrors - ключ повторён с другим запросом (422) или первый запрос ещё выполняется (409)
+var idempotencyErrors = []int{fiber.StatusConflict, fiber.StatusUnprocessableEntity}
+
+// ifNoneMatchHeader - ETag задачи совпал с одним из тегов: 304 без тела
+type ifNoneMatchHeader struct {
+	ETag string `header:"If-None-Match"`
+}
+
+// ifMatchHeader - ETag из GET или "*"; устаревшая версия - 412 с текущим состоянием задачи в data
+type ifMatchHeader struct {
+	ETag string `header:"If-Match" validate:"required"`
+}
+
+// preconditionErrors - версия задачи устарела (412) или не передан If-Match (428)
+var preconditionErrors = []int{fiber.StatusPreconditionFailed, fiber.StatusPreconditionRequired}
+
+const apiDescription = "Сервис задач и пользователей. Ответы JSON обёрнуты в конверт {status, data, error}; " +
+	"с Accept: application/problem+json ошибки приходят по RFC 9457, коды ошибок - GET /errors."
+
+var apiSpec = openapi.Spec{
+	Info: openapi.Info{
+		Title:       "TemplatestPGSQL",
+		Description: apiDescription,
+		Version:     "1.0.0",
+	},
+	Envelope: dto.Response{},
+	Security: map[string]openapi.SecurityScheme{
+		"bearerAuth":  {Type: "http", Scheme: "bearer"},
+		"accessToken": {Type: "apiKey", In: "query", Name: "access_token"},
+	},
+	Rules: map[string]openapi.Schema{
+		"intString": {Pattern: validator.IntStringPattern},
+		"tag":       {Pattern: validator.TagPattern},
+	},
+	Routes: map[string]openapi.Route{
+		"POST /v1/login": {
+			Summary:  "Выдаёт токен пользователя по имени и паролю",
+			Tags:     []string{tagUsers},
+			Public:   true,
+			Body:     service.PostUserRequest{},
+			Response: service.LoginResult{},
+		},
+		"GET /calendar/:user_id/tasks.ics": {
+			Summary:     "Лента iCalendar задач пользователя со сроком",
+			Description: "Защищена токеном ленты из GET /v1/calendar, заголовок Authorization не нужен.",
+			Tags:        []string{tagCalendar},
+			Public:      true,
+			Query:       service.CalendarFeedRequest{},
+			Produces:    []string{"text/calendar"},
+		},
+		"POST /graphql": {
+			OperationID: "GraphQL",
+			Summary:     "GraphQL API задач и пользователей",
+			Description: "Схема - internal/gql/schema.graphql. Ответ в формате GraphQL {data, errors}, " +
+				"код ошибки сервиса - в errors[].extensions.code.",
+			Tags:     []string{tagGraphQL},
+			Body:     gql.GraphQLRequest{},
+			Produces: []string{"application/json"},
+		},
+		"POST /v1/tasks": {
+			Summary:  "Создаёт задачу",
+			Tags:     []string{tagTasks},
+			Body:     service.PostRequest{},
+			Response: createdTask{},
+			Header:   idempotencyHeader{},
+			Errors:   idempotencyErrors,
+		},
+		"POST /v1/tasks/bulk": {
+			Summary:  "Пакетные операции над задачами",
+			Tags:     []string{tagTasks},
+			Body:     service.BulkRequest{},
+			Response: []service.BulkResult{},
+			Header:   idempotencyHeader{},
+			Errors:   idempotencyErrors,
+		},
+		"POST /v1/tasks/import": {
+			Summary:  "Импорт задач из CSV, NDJSON или iCalendar",
+			Tags:     []string{tagTasks},
+			Query:    service.ImportRequest{},
+			Consumes: []string{"text/csv", "application/x-ndjson", "text/calendar"},
+			Response: service.ImportReport{},
+		},
+		"POST /v1/users": {
+			Summary:  "Создаёт пользователя",
+			Tags:     []string{tagUsers},
+			Body:     service.PostUserRequest{},
+			Response: createdUser{},
+			Header:   idempotencyHeader{},
+			Errors:   idempotencyErrors,
+		},
+		"GET /v1/users": {
+			Summary:  "Список пользователей",
+			Tags:     []string{tagUsers},
+			Admin:    true,
+			Response: []service.UserInfo{},
+		},
+		"DELETE /v1/users/:id": {
+			Summary: "Удаляет пользователя вместе с его задачами",
+			Tags:    []string{tagUsers},
+			Admin:   true,
+			Path:    service.RequestWithId{},
+		},
+		"GET /v1/tasks/all": {
+			Summary:  "Список задач с фильтрами",
+			Tags:     []string{tagTasks},
+			Query:    service.TaskListRequest{},
+			Response: []repo.Task{},
+		},
+		"GET /v1/tasks/stream": {
+			Summary:  "Поток изменений задач (Server-Sent Events)",
+			Tags:     []string{tagTasks},
+			Produces: []string{"text/event-stream"},
+		},
+		"GET /v1/tasks/ws": {
+			Summary: "Поток изменений задач (WebSocket)",
+			Tags:    []string{tagTasks},
+			Status:  101,
+		},
+		"GET /v1/tasks/export": {
+			Summary: "Выгрузка задач",
+			Tags:    []string{tagTasks},
+			Query:   service.ExportRequest{},
+			Produces: []string{
+				"text/csv",
+				"application/x-ndjson",
+				"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			},
+		},
+		"GET /v1/calendar": {
+			Summary:  "Адрес ленты iCalendar",
+			Tags:     []string{tagCalendar},
+			Query:    service.CalendarLinkRequest{},
+			Response: service.CalendarLink{},
+		},
+		"GET /v1/tasks/users/:id": {
+			Summary:  "Задачи пользователя",
+			Tags:     []string{tagTasks},
+			Path:     service.RequestWithId{},
+			Response: []repo.Task{},
+		},
+		"DELETE /v1/tasks/:id": {
+			Summary: "Удаляет задачу",
+			Tags:    []string{tagTasks},
+			Path:    service.DeleteTaskRequest{},
+			Header:  ifMatchHeader{},
+			Errors:  preconditionErrors,
+		},
+		"PUT /v1/tasks/:id": {
+			Summary: "Меняет статус задачи",
+			Tags:    []string{tagTasks},
+			Path:    service.UpdateRequest{},
+			Body:    service.UpdateRequest{},
+			Header:  ifMatchHeader{},
+			Errors:  preconditionErrors,
+		},
+		"GET /v1/tasks/users/:id/last": {
+			Summary:  "Последняя задача пользователя",
+			Tags:     []string{tagTasks},
+			Path:     service.RequestWithId{},
+			Header:   ifNoneMatchHeader{},
+			Response: repo.Task{},
+		},
+		"GET /v1/tasks/:id": {
+			Summary:  "Задача по id",
+			Tags:     []string{tagTasks},
+			Path:     service.RequestWithId{},
+			Header:   ifNoneMatchHeader{},
+			Response: repo.Task{},
+		},
+		"GET /v1/tasks/users/name/:username": {
+			Summary:  "Задачи пользователя по имени",
+			Tags:     []string{tagTasks},
+			Path:     service.RequestWithUserName{},
+			Response: []repo.Task{},
+		},
+		"GET /v1/tasks/:id/activity": {
+			Summary:  "История изменений задачи",
+			Tags:     []string{tagAudit},
+			Path:     service.RequestWithId{},
+			Response: []repo.AuditRecord{},
+		},
+		"GET /v1/audit": {
+			Summary:  "Журнал аудита",
+			Tags:     []string{tagAudit},
+			Admin:    true,
+			Query:    service.AuditRequest{},
+			Response: []repo.AuditRecord{},
+		},
+		"POST /v1/webhooks": {
+			Summary:  "Создаёт подписку на события",
+			Tags:     []string{tagWebhooks},
+			Admin:    true,
+			Body:     service.PostWebhookRequest{},
+			Response: repo.Webhook{},
+		},
+		"GET /v1/webhooks": {
+			Summary:  "Список подписок",
+			Tags:     []string{tagWebhooks},
+			Admin:    true,
+			Response: []repo.Webhook{},
+		},
+		"DELETE /v1/webhooks/:id": {
+			Summary: "Удаляет подписку",
+			Tags:    []string{tagWebhooks},
+			Admin:   true,
+			Path:    service.RequestWithId{},
+		},
+		"GET /v1/webhooks/:id/deliveries": {
+			Summary:  "Последние доставки подписки",
+			Tags:     []string{tagWebhooks},
+			Admin:    true,
+			Path:     service.RequestWithId{},
+			Response: []repo.WebhookDelivery{},
+		},
+		"GET " + specPath: {
+			OperationID: "GetOpenAPI",
+			Summary:     "Этот документ",
+			Tags:        []string{tagDocs},
+			Public:      true,
+			Produces:    []string{"application/json"},
+		},
+		"GET " + dto.ProblemTypeBase: {
+			Summary:  "Каталог кодов ошибок с HTTP-статусами",
+			Tags:     []string{tagDocs},
+			Public:   true,
+			Response: []dto.CodeInfo{},
+		},
+		"GET " + docsPath: {
+			OperationID: "GetDocs",
+			Summary:     "Документация API (Redoc)",
+			Tags:        []string{tagDocs},
+			Public:      true,
+			Produces:    []string{"text/html"},
+		},
+	},
+}
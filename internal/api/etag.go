@@ -0,0 +1,56 @@
+package api
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etag - сильный ETag задачи по её версии
+func etag(task *repo2.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// writeTask отдаёт задачу с ETag; совпадение с If-None-Match - 304 без тела
+func writeTask(ctx *fiber.Ctx, task *repo2.Task) error {
+	tag := etag(task)
+	ctx.Set(fiber.HeaderETag, tag)
+	if noneMatch := ctx.Get(fiber.HeaderIfNoneMatch); noneMatch != "" && matchETag(noneMatch, tag) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	return writeData(ctx, task)
+}
+
+// matchETag - слабое сравнение со списком тегов из If-None-Match
+func matchETag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch - ожидаемая версия задачи из If-Match. "*" - 0, изменение без проверки версии.
+// Тег, который не является версией задачи, не совпадёт ни с одной версией (-1).
+func ifMatch(ctx *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	switch header {
+	case "":
+		return 0, &service.Error{Code: dto.PreconditionRequired, Desc: dto.MsgIfMatchRequired}
+	case "*":
+		return 0, nil
+	}
+
+	tag := strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 || `"`+tag+`"` != header {
+		return -1, nil
+	}
+	return version, nil
+}
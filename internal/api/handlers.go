@@ -0,0 +1,394 @@
+package api
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	customLogger "TemplatestPGSQL/internal/logger"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/pkg/i18n"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Handlers - HTTP-слой над service.Service: разбирает запрос, вызывает сервис и переводит результат в dto.
+// Имена методов становятся operationId в документе OpenAPI.
+type Handlers struct {
+	service service.Service
+	log     *zap.SugaredLogger
+	// exportTimeout ограничивает запись файла, которая идёт уже после выхода из обработчика; нулевой - без срока
+	exportTimeout time.Duration
+}
+
+func NewHandlers(s service.Service, logger *zap.SugaredLogger, exportTimeout time.Duration) *Handlers {
+	return &Handlers{service: s, log: logger, exportTimeout: exportTimeout}
+}
+
+func (h *Handlers) Login(ctx *fiber.Ctx) error {
+	var req service.PostUserRequest
+	if err := h.parseBody(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	result, err := h.service.Login(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, result)
+}
+
+func (h *Handlers) CreateUser(ctx *fiber.Ctx) error {
+	var req service.PostUserRequest
+	if err := h.parseBody(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	user, err := h.service.CreateUser(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, map[string]string{"user_id": user.ID})
+}
+
+func (h *Handlers) GetUsers(ctx *fiber.Ctx) error {
+	users, err := h.service.GetUsers(ctx.UserContext())
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, users)
+}
+
+func (h *Handlers) DeleteUserByID(ctx *fiber.Ctx) error {
+	err := h.service.DeleteUserByID(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, nil)
+}
+
+func (h *Handlers) CreateTask(ctx *fiber.Ctx) error {
+	var req service.PostRequest
+	if err := h.parseBody(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	task, err := h.service.CreateTask(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, map[string]string{"task_id": task.ID})
+}
+
+func (h *Handlers) GetAllTasks(ctx *fiber.Ctx) error {
+	var req service.TaskListRequest
+	if err := h.parseQuery(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	tasks, err := h.service.GetAllTasks(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, tasks)
+}
+
+func (h *Handlers) GetTaskByID(ctx *fiber.Ctx) error {
+	task, err := h.service.GetTaskByID(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeTask(ctx, task)
+}
+
+func (h *Handlers) GetLastTaskByUserID(ctx *fiber.Ctx) error {
+	task, err := h.service.GetLastTaskByUserID(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeTask(ctx, task)
+}
+
+func (h *Handlers) GetAllTasksByUserID(ctx *fiber.Ctx) error {
+	tasks, err := h.service.GetAllTasksByUserID(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, tasks)
+}
+
+func (h *Handlers) GetTasksByUserName(ctx *fiber.Ctx) error {
+	tasks, err := h.service.GetTasksByUserName(ctx.UserContext(), service.RequestWithUserName{Name: ctx.Params("username")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, tasks)
+}
+
+func (h *Handlers) UpdateStatusByID(ctx *fiber.Ctx) error {
+	var req service.UpdateRequest
+	if err := h.parseBody(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+	req.ID = ctx.Params("id")
+	version, err := ifMatch(ctx)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	req.Version = version
+
+	if err := h.service.UpdateStatusByID(ctx.UserContext(), req); err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, nil)
+}
+
+func (h *Handlers) DeleteTaskByID(ctx *fiber.Ctx) error {
+	version, err := ifMatch(ctx)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+
+	if err := h.service.DeleteTaskByID(ctx.UserContext(), service.DeleteTaskRequest{ID: ctx.Params("id"), Version: version}); err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, nil)
+}
+
+func (h *Handlers) BulkTasks(ctx *fiber.Ctx) error {
+	var req service.BulkRequest
+	if err := h.parseBody(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	results, err := h.service.BulkTasks(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, results)
+}
+
+func (h *Handlers) ImportTasks(ctx *fiber.Ctx) error {
+	var req service.ImportRequest
+	if err := h.parseQuery(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+	if req.Format == "" {
+		switch contentType := ctx.Get(fiber.HeaderContentType); {
+		case strings.HasPrefix(contentType, "text/csv"):
+			req.Format = service.ImportFormatCSV
+		case strings.HasPrefix(contentType, "text/calendar"):
+			req.Format = service.ImportFormatICS
+		}
+	}
+
+	// reads body as a stream when the server allows it
+	src := ctx.Context().RequestBodyStream()
+	if src == nil {
+		src = bytes.NewReader(ctx.Body())
+	}
+
+	report, err := h.service.ImportTasks(ctx.UserContext(), req, src)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, report)
+}
+
+func (h *Handlers) ExportTasks(ctx *fiber.Ctx) error {
+	var req service.ExportRequest
+	if err := h.parseQuery(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	download, err := h.service.ExportTasks(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return h.writeDownload(ctx, download)
+}
+
+func (h *Handlers) CalendarFeed(ctx *fiber.Ctx) error {
+	var req service.CalendarFeedRequest
+	if err := h.parseQuery(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	download, err := h.service.CalendarFeed(ctx.UserContext(), ctx.Params("user_id"), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return h.writeDownload(ctx, download)
+}
+
+func (h *Handlers) GetCalendarLink(ctx *fiber.Ctx) error {
+	var req service.CalendarLinkRequest
+	if err := h.parseQuery(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	link, err := h.service.GetCalendarLink(ctx.UserContext(), req, ctx.BaseURL())
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, link)
+}
+
+func (h *Handlers) GetTaskActivity(ctx *fiber.Ctx) error {
+	records, err := h.service.GetTaskActivity(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, records)
+}
+
+func (h *Handlers) GetAuditRecords(ctx *fiber.Ctx) error {
+	var req service.AuditRequest
+	if err := h.parseQuery(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	records, err := h.service.GetAuditRecords(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, records)
+}
+
+func (h *Handlers) CreateWebhook(ctx *fiber.Ctx) error {
+	var req service.PostWebhookRequest
+	if err := h.parseBody(ctx, &req); err != nil {
+		return writeError(ctx, err)
+	}
+
+	webhook, err := h.service.CreateWebhook(ctx.UserContext(), req)
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, webhook)
+}
+
+func (h *Handlers) GetWebhooks(ctx *fiber.Ctx) error {
+	webhooks, err := h.service.GetWebhooks(ctx.UserContext())
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, webhooks)
+}
+
+func (h *Handlers) DeleteWebhookByID(ctx *fiber.Ctx) error {
+	if err := h.service.DeleteWebhookByID(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")}); err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, nil)
+}
+
+func (h *Handlers) GetWebhookDeliveries(ctx *fiber.Ctx) error {
+	deliveries, err := h.service.GetWebhookDeliveries(ctx.UserContext(), service.RequestWithId{ID: ctx.Params("id")})
+	if err != nil {
+		return writeError(ctx, err)
+	}
+	return writeData(ctx, deliveries)
+}
+
+// logger - логгер запроса с его идентификатором
+func (h *Handlers) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return customLogger.FromContext(ctx.UserContext(), h.log)
+}
+
+// parseBody раскладывает JSON тела запроса в req
+func (h *Handlers) parseBody(ctx *fiber.Ctx, req any) error {
+	if err := json.Unmarshal(ctx.Body(), req); err != nil {
+		h.logger(ctx).Error("Invalid request body", zap.Error(err))
+		return &service.Error{Code: dto.FieldBadFormat, Desc: dto.MsgInvalidBody}
+	}
+	return nil
+}
+
+// parseQuery раскладывает query-параметры в req по тегам query
+func (h *Handlers) parseQuery(ctx *fiber.Ctx, req any) error {
+	if err := ctx.QueryParser(req); err != nil {
+		h.logger(ctx).Error("Invalid query", zap.Error(err))
+		return &service.Error{Code: dto.FieldBadFormat, Desc: dto.MsgInvalidQuery}
+	}
+	return nil
+}
+
+// writeData отвечает конвертом success с data
+func writeData(ctx *fiber.Ctx, data any) error {
+	return ctx.Status(fiber.StatusOK).JSON(dto.Response{
+		Status: "success",
+		Data:   data,
+	})
+}
+
+// writeError переводит ошибку сервиса в ответ dto; всё, что не *service.Error, клиент видит как 500
+func writeError(ctx *fiber.Ctx, err error) error {
+	svcErr, ok := service.AsError(ctx.UserContext(), err)
+	if !ok {
+		return dto.InternalServerError(ctx)
+	}
+
+	if task, ok := svcErr.Data.(*repo2.Task); ok && svcErr.Code == dto.PreconditionFailed {
+		ctx.Set(fiber.HeaderETag, etag(task))
+	}
+	return dto.Fail(ctx, dto.Error{Code: svcErr.Code, Desc: svcErr.Desc, Fields: svcErr.Fields}, svcErr.Data)
+}
+
+// errorHandler отвечает в формате API на ошибки самого fiber: нет маршрута, не тот метод, большое тело
+func errorHandler(ctx *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if !errors.As(err, &fiberErr) {
+		return dto.InternalServerError(ctx)
+	}
+
+	switch code := fiberErr.Code; {
+	case code == fiber.StatusNotFound:
+		return dto.NotFoundError(ctx, dto.NotFound, dto.MsgRouteNotFound)
+	case code == fiber.StatusMethodNotAllowed:
+		return dto.Fail(ctx, dto.Error{Code: dto.MethodNotAllowed, Desc: dto.MsgMethodNotAllowed}, nil)
+	case code == fiber.StatusRequestEntityTooLarge:
+		return dto.Fail(ctx, dto.Error{Code: dto.RequestTooLarge, Desc: dto.MsgRequestTooLarge}, nil)
+	case code < fiber.StatusInternalServerError:
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, dto.MsgBadRequest)
+	}
+	return dto.InternalServerError(ctx)
+}
+
+func (h *Handlers) GetErrorCatalog(ctx *fiber.Ctx) error {
+	return writeData(ctx, dto.LocalizedCatalog(i18n.FromContext(ctx.UserContext())))
+}
+
+// writeDownload отдаёт файл потоком после выхода из обработчика
+func (h *Handlers) writeDownload(ctx *fiber.Ctx, download *service.Download) error {
+	disposition := "attachment"
+	if download.Inline {
+		disposition = "inline"
+	}
+	ctx.Set(fiber.HeaderContentType, download.ContentType)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`%s; filename="%s"`, disposition, download.Filename))
+
+	// fiber.Ctx возвращается в пул после выхода из обработчика, поэтому в поток передаётся только контекст запроса.
+	// Срок запроса истекает с выходом из обработчика, а файл пишется после него, поэтому поток отвязан
+	// от срока запроса и получает свой - exportTimeout, отсчитываемый с начала записи
+	reqCtx := context.WithoutCancel(ctx.UserContext())
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		streamCtx, cancel := context.WithCancel(reqCtx)
+		if h.exportTimeout > 0 {
+			streamCtx, cancel = context.WithTimeout(reqCtx, h.exportTimeout)
+		}
+		defer cancel()
+		// статус уже отправлен, при ошибке клиент получит обрезанный файл; сервис её залогировал
+		if err := download.Write(streamCtx, w); err == nil {
+			_ = w.Flush()
+		}
+	})
+	return nil
+}
@@ -0,0 +1,603 @@
+package api
+
+import (
+	"TemplatestPGSQL/internal/api/middleware"
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/gql"
+	"TemplatestPGSQL/internal/metrics"
+	"TemplatestPGSQL/internal/ratelimit"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testToken = "token"
+
+var testSigner = auth.NewSigner("secret", auth.DefaultTokenTTL)
+
+func newHandlersApp(repository repo2.Repository) *fiber.App {
+	return newLoggedApp(repository, zap.NewNop().Sugar())
+}
+
+// newLoggedApp собирает приложение с заданным логгером, чтобы проверять журнал запросов
+func newLoggedApp(repository repo2.Repository, logger *zap.SugaredLogger) *fiber.App {
+	return newRoutersApp(repository, logger, func(*Routers) {})
+}
+
+// newRoutersApp собирает приложение, configure дополняет необязательные параметры
+func newRoutersApp(repository repo2.Repository, logger *zap.SugaredLogger, configure func(r *Routers)) *fiber.App {
+	serviceInstance := service.NewService(repository, logger, testSigner)
+	routers := &Routers{
+		Service:     serviceInstance,
+		Signer:      testSigner,
+		Stream:      stream.NewHub(nil, logger),
+		GraphQL:     gql.NewServer(serviceInstance, repository, logger),
+		Idempotency: middleware.NewIdempotency(repository, time.Hour, logger),
+		Logger:      logger,
+	}
+	configure(routers)
+	return NewRouters(routers, testToken)
+}
+
+func call(t *testing.T, app *fiber.App, method, target, body string) (*testResponse, dto.Response) {
+	t.Helper()
+	return send(t, app, httptest.NewRequest(method, target, strings.NewReader(body)))
+}
+
+// send выполняет подготовленный запрос с сервисным токеном
+func send(t *testing.T, app *fiber.App, req *http.Request) (*testResponse, dto.Response) {
+	t.Helper()
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+testToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var payload dto.Response
+	if strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		require.NoError(t, json.Unmarshal(raw, &payload))
+	}
+	return &testResponse{status: resp.StatusCode, header: resp.Header.Get, body: string(raw)}, payload
+}
+
+type testResponse struct {
+	status int
+	header func(string) string
+	body   string
+}
+
+func TestHandlersErrors(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, "7").Return(nil, dto.ErrNotFound)
+	app := newHandlersApp(repository)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"Bad body", fiber.MethodPost, "/v1/tasks", "{", fiber.StatusBadRequest, dto.FieldBadFormat},
+		{"Invalid field", fiber.MethodPost, "/v1/tasks", `{"title":"t"}`, fiber.StatusBadRequest, dto.FieldIncorrect},
+		{"Not found", fiber.MethodGet, "/v1/tasks/7", "", fiber.StatusNotFound, dto.NotFound},
+		{"Unknown route", fiber.MethodGet, "/v1/nothing", "", fiber.StatusNotFound, dto.NotFound},
+		{"Bad query", fiber.MethodGet, "/v1/tasks/all?limit=x", "", fiber.StatusBadRequest, dto.FieldBadFormat},
+		{"Bad GraphQL body", fiber.MethodPost, "/graphql", "{", fiber.StatusBadRequest, dto.FieldBadFormat},
+		{"Bad feed token", fiber.MethodGet, "/calendar/3/tasks.ics?token=x", "", fiber.StatusUnauthorized, dto.Unauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, payload := call(t, app, tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.wantStatus, resp.status)
+			require.NotNil(t, payload.Error)
+			assert.Equal(t, tt.wantCode, payload.Error.Code)
+		})
+	}
+}
+
+func TestHandlersProblemJSON(t *testing.T) {
+	app := newHandlersApp(mocks.NewRepository(t))
+	req := httptest.NewRequest(fiber.MethodPost, "/v1/tasks", strings.NewReader(`{"data":"d"}`))
+	req.Header.Set(fiber.HeaderAccept, dto.MIMEProblemJSON)
+
+	resp, _ := send(t, app, req)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.status)
+	assert.Equal(t, dto.MIMEProblemJSON, resp.header(fiber.HeaderContentType))
+	var problem dto.Problem
+	require.NoError(t, json.Unmarshal([]byte(resp.body), &problem))
+	assert.Equal(t, dto.ProblemType(dto.FieldIncorrect), problem.Type)
+	assert.Equal(t, fiber.StatusBadRequest, problem.Status)
+	assert.Equal(t, "/v1/tasks", problem.Instance)
+	// перечислены все неверные поля, а не только первое
+	fields := make(map[string]string)
+	for _, fieldErr := range problem.Errors {
+		fields[fieldErr.Field] = fieldErr.Rule
+	}
+	assert.Equal(t, map[string]string{"title": "required", "user_id": "required"}, fields)
+}
+
+func TestHandlersLocalizedErrors(t *testing.T) {
+	app := newHandlersApp(mocks.NewRepository(t))
+	req := httptest.NewRequest(fiber.MethodPost, "/v1/tasks", strings.NewReader(`{"data":"d"}`))
+	req.Header.Set(fiber.HeaderAcceptLanguage, "ru-RU,ru;q=0.9,en;q=0.8")
+
+	resp, payload := send(t, app, req)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.status)
+	assert.Equal(t, "ru", resp.header(fiber.HeaderContentLanguage))
+	// код не зависит от языка
+	assert.Equal(t, dto.FieldIncorrect, payload.Error.Code)
+	assert.Equal(t, "Обязательное поле: PostRequest.Title; Обязательное поле: PostRequest.UserID", payload.Error.Desc)
+	assert.Equal(t, "Обязательное поле", payload.Error.Fields[0].Message)
+
+	req = httptest.NewRequest(fiber.MethodGet, "/v1/tasks/all", nil)
+	req.Header.Set(fiber.HeaderAcceptLanguage, "ru")
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer wrong")
+	raw, err := app.Test(req)
+	require.NoError(t, err)
+	var unauthorized dto.Response
+	require.NoError(t, json.NewDecoder(raw.Body).Decode(&unauthorized))
+	assert.Equal(t, dto.Unauthorized, unauthorized.Error.Code)
+	assert.Equal(t, "Токен авторизации отсутствует или недействителен", unauthorized.Error.Desc)
+}
+
+func TestHandlersRequestID(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, "7").Return(nil, dto.ErrNotFound)
+	core, logs := observer.New(zap.InfoLevel)
+	app := newLoggedApp(repository, zap.New(core).Sugar())
+
+	tests := []struct {
+		name     string
+		provided string
+		keep     bool
+	}{
+		{"Generated", "", false},
+		{"Provided", "req-42", true},
+		{"Invalid", "bad id", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+			req := httptest.NewRequest(fiber.MethodGet, "/v1/tasks/7", nil)
+			if tt.provided != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.provided)
+			}
+
+			resp, _ := send(t, app, req)
+
+			id := resp.header(middleware.RequestIDHeader)
+			require.NotEmpty(t, id)
+			if tt.keep {
+				assert.Equal(t, tt.provided, id)
+			} else {
+				assert.NotEqual(t, tt.provided, id)
+			}
+
+			// одна строка журнала на запрос с шаблоном маршрута и статусом
+			entries := logs.FilterMessage("request").All()
+			require.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+			assert.Equal(t, id, fields["request_id"])
+			assert.Equal(t, fiber.MethodGet, fields["method"])
+			assert.Equal(t, "/v1/tasks/:id", fields["route"])
+			assert.EqualValues(t, fiber.StatusNotFound, fields["status"])
+			assert.Equal(t, "admin", fields["user"])
+		})
+	}
+}
+
+func TestHandlersMetrics(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, mock.Anything).Return(nil, dto.ErrNotFound)
+	appMetrics := metrics.New()
+	app := newRoutersApp(repository, zap.NewNop().Sugar(), func(r *Routers) { r.Metrics = appMetrics })
+
+	call(t, app, fiber.MethodGet, "/v1/tasks/7", "")
+	call(t, app, fiber.MethodGet, "/v1/tasks/8", "")
+	call(t, app, fiber.MethodGet, "/v1/nothing", "")
+
+	recorder := httptest.NewRecorder()
+	appMetrics.Handler().ServeHTTP(recorder, httptest.NewRequest(fiber.MethodGet, metrics.Path, nil))
+	body := recorder.Body.String()
+	// запросы к разным задачам попадают в один ряд шаблона маршрута
+	assert.Contains(t, body, `tasks_http_requests_total{method="GET",route="/v1/tasks/:id",status="404"} 2`)
+	assert.Contains(t, body, `tasks_http_request_duration_seconds_count{method="GET",route="/v1/tasks/:id"} 2`)
+	assert.NotContains(t, body, "/v1/nothing")
+}
+
+func TestHandlersTimeout(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	// PostgreSQL отвечает на отмену своей ошибкой, а не ошибкой контекста
+	repository.On("GetTaskByID", mock.Anything, "7").
+		Return(func(ctx context.Context, _ string) (*repo2.Task, error) {
+			<-ctx.Done()
+			return nil, errors.New("ERROR: canceling statement due to user request (SQLSTATE 57014)")
+		})
+	repository.On("GetAllTasks", mock.Anything, mock.Anything).Return([]repo2.Task{}, nil)
+	app := newRoutersApp(repository, zap.NewNop().Sugar(), func(r *Routers) { r.RequestTimeout = 20 * time.Millisecond })
+
+	resp, payload := call(t, app, fiber.MethodGet, "/v1/tasks/7", "")
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.status)
+	require.NotNil(t, payload.Error)
+	assert.Equal(t, dto.RequestTimeout, payload.Error.Code)
+
+	// запрос, уложившийся в срок, не затронут
+	resp, _ = call(t, app, fiber.MethodGet, "/v1/tasks/all", "")
+	assert.Equal(t, fiber.StatusOK, resp.status)
+}
+
+func TestHandlersTimeoutSkipsImportAndBoundsExport(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	// импорт идёт без срока при любом написании пути
+	repository.On("GetExistingUserIDs", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ []string) ([]string, error) {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return []string{"1"}, nil
+		})
+	// выгрузка дописывается после ответа обработчика со своим сроком
+	repository.On("ForEachTask", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ repo2.TaskFilter, fn func(repo2.Task) error) error {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.Greater(t, time.Until(deadline), time.Minute)
+			return fn(repo2.Task{DataObject: repo2.DataObject{ID: "7", Title: "Report"}, UserID: "3"})
+		})
+	app := newRoutersApp(repository, zap.NewNop().Sugar(), func(r *Routers) {
+		r.RequestTimeout = 20 * time.Millisecond
+		r.ExportTimeout = time.Hour
+	})
+
+	for _, target := range []string{"/v1/tasks/import?format=ndjson&dry_run=true", "/v1/Tasks/Import/?format=ndjson&dry_run=true"} {
+		resp, _ := call(t, app, fiber.MethodPost, target, `{"title":"t","user_id":"1"}`)
+		assert.Equal(t, fiber.StatusOK, resp.status, target)
+	}
+
+	resp, _ := call(t, app, fiber.MethodGet, "/v1/tasks/export?format=ndjson", "")
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Contains(t, resp.body, `"title":"Report"`)
+}
+
+func TestHandlersRateLimit(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetAllTasks", mock.Anything, mock.Anything).Return([]repo2.Task{}, nil)
+	policy := ratelimit.NewPolicy(ratelimit.NewMemory(), ratelimit.Limits{
+		ratelimit.GroupAPI: {Requests: 2, Period: time.Minute},
+	})
+	app := newRoutersApp(repository, zap.NewNop().Sugar(), func(r *Routers) { r.RateLimit = policy })
+
+	resp, _ := call(t, app, fiber.MethodGet, "/v1/tasks/all", "")
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Equal(t, "2", resp.header(middleware.RateLimitLimitHeader))
+	assert.Equal(t, "1", resp.header(middleware.RateLimitRemainingHeader))
+	assert.Equal(t, "2;w=60", resp.header(middleware.RateLimitPolicyHeader))
+
+	call(t, app, fiber.MethodGet, "/v1/tasks/all", "")
+	resp, payload := call(t, app, fiber.MethodGet, "/v1/tasks/all", "")
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.status)
+	require.NotNil(t, payload.Error)
+	assert.Equal(t, dto.RateLimited, payload.Error.Code)
+	assert.Equal(t, "0", resp.header(middleware.RateLimitRemainingHeader))
+	assert.Equal(t, "30", resp.header(fiber.HeaderRetryAfter))
+
+	// у другого автора своя корзина
+	req := httptest.NewRequest(fiber.MethodGet, "/v1/tasks/users/3", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+testSigner.Issue("3"))
+	repository.On("GetAllTasksByUserID", mock.Anything, "3").Return([]repo2.Task{}, nil)
+	userResp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, userResp.StatusCode)
+	assert.Equal(t, "1", userResp.Header.Get(middleware.RateLimitRemainingHeader))
+}
+
+// recordSpans подменяет глобальный провайдер трассировки на время теста
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+func TestHandlersTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, "7").Return(nil, dto.ErrNotFound)
+	app := newHandlersApp(repository)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/v1/tasks/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	send(t, app, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /v1/tasks/:id", span.Name())
+	// спан продолжает трассировку вызывающего сервиса
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Contains(t, span.Attributes(), semconv.HTTPRoute("/v1/tasks/:id"))
+	assert.Contains(t, span.Attributes(), semconv.HTTPResponseStatusCode(fiber.StatusNotFound))
+}
+
+func TestHandlersBulkAborted(t *testing.T) {
+	app := newHandlersApp(mocks.NewRepository(t))
+
+	resp, payload := call(t, app, fiber.MethodPost, "/v1/tasks/bulk", `{"operations":[{"op":"delete","id":"x"}]}`)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.status)
+	assert.Equal(t, dto.BulkAborted, payload.Error.Code)
+	assert.Len(t, payload.Data, 1)
+}
+
+func TestHandlersTasksByUserName(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetTasksByUserName", mock.Anything, "ivan").
+		Return([]repo2.Task{{DataObject: repo2.DataObject{ID: "7"}, UserID: "3"}}, nil)
+	app := newHandlersApp(repository)
+
+	resp, payload := call(t, app, fiber.MethodGet, "/v1/tasks/users/name/ivan", "")
+
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Len(t, payload.Data, 1)
+}
+
+func TestHandlersTasksByUserID(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetAllTasksByUserID", mock.Anything, "3").
+		Return([]repo2.Task{{DataObject: repo2.DataObject{ID: "7"}, UserID: "3"}}, nil)
+	repository.On("GetAllTasksByUserID", mock.Anything, "4").Return(nil, dto.ErrNotFound)
+	app := newHandlersApp(repository)
+
+	// задачи отдаются массивом, а не JSON-документом в base64
+	resp, payload := call(t, app, fiber.MethodGet, "/v1/tasks/users/3", "")
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Len(t, payload.Data, 1)
+
+	resp, payload = call(t, app, fiber.MethodGet, "/v1/tasks/users/4", "")
+	assert.Equal(t, fiber.StatusNotFound, resp.status)
+	require.NotNil(t, payload.Error)
+	assert.Equal(t, dto.NotFound, payload.Error.Code)
+}
+
+func TestHandlersExportStreams(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("ForEachTask", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ repo2.TaskFilter, fn func(repo2.Task) error) error {
+			// автор запроса доходит до сервиса через контекст
+			assert.True(t, auth.FromContext(ctx).Admin)
+			return fn(repo2.Task{DataObject: repo2.DataObject{ID: "7", Title: "Report"}, UserID: "3"})
+		})
+	app := newHandlersApp(repository)
+
+	resp, _ := call(t, app, fiber.MethodGet, "/v1/tasks/export?format=ndjson", "")
+
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Equal(t, "application/x-ndjson", resp.header(fiber.HeaderContentType))
+	assert.Regexp(t, `^attachment; filename="tasks-.+\.ndjson"$`, resp.header(fiber.HeaderContentDisposition))
+	assert.Contains(t, resp.body, `"title":"Report"`)
+}
+
+// blockingListener держит канал задач открытым, пока не отменён контекст хаба
+type blockingListener struct{}
+
+func (blockingListener) Listen(ctx context.Context, _ string, _ func(string)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestHandlersStreamsThroughMiddleware проверяет потоковые ответы на настоящем соединении: заголовки должны
+// дойти до клиента до конца потока, т.е. ни один middleware не дочитывает тело ответа
+func TestHandlersStreamsThroughMiddleware(t *testing.T) {
+	headersSent := make(chan struct{})
+	repository := mocks.NewRepository(t)
+	repository.On("ForEachTask", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ repo2.TaskFilter, fn func(repo2.Task) error) error {
+			// первые строки больше буфера записи и уходят клиенту, остальные ждут, пока он получит заголовки:
+			// если тело читается целиком до отправки, заголовки не придут никогда
+			for i := 0; i < 1000; i++ {
+				if err := fn(repo2.Task{DataObject: repo2.DataObject{ID: "1", Title: strings.Repeat("x", 100)}, UserID: "3"}); err != nil {
+					return err
+				}
+			}
+			select {
+			case <-headersSent:
+			case <-time.After(5 * time.Second):
+				return errors.New("headers were not sent before the body was read")
+			}
+			return fn(repo2.Task{DataObject: repo2.DataObject{ID: "7", Title: "Report"}, UserID: "3"})
+		})
+
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	hub := stream.NewHub(blockingListener{}, zap.NewNop().Sugar())
+	go hub.Run(hubCtx)
+	app := newRoutersApp(repository, zap.NewNop().Sugar(), func(r *Routers) { r.Stream = hub })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(listener) }()
+	t.Cleanup(func() {
+		stopHub()
+		_ = app.ShutdownWithTimeout(time.Second)
+	})
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	get := func(path string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String()+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+testToken)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	// поток SSE не заканчивается, но заголовки приходят сразу
+	sse := get("/v1/tasks/stream")
+	assert.Equal(t, fiber.StatusOK, sse.StatusCode)
+	assert.Equal(t, "text/event-stream", sse.Header.Get(fiber.HeaderContentType))
+
+	export := get("/v1/tasks/export?format=ndjson")
+	close(headersSent)
+	assert.Equal(t, fiber.StatusOK, export.StatusCode)
+	body, err := io.ReadAll(export.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"title":"Report"`)
+}
+
+func TestHandlersIdempotency(t *testing.T) {
+	const body = `{"title":"Report","user_id":"3"}`
+	repository := mocks.NewRepository(t)
+	app := newHandlersApp(repository)
+	post := func(key, body string) (*testResponse, dto.Response) {
+		req := httptest.NewRequest(fiber.MethodPost, "/v1/tasks", strings.NewReader(body))
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		return send(t, app, req)
+	}
+
+	// первый запрос выполняется и сохраняет ответ
+	var hash, storedType string
+	var stored []byte
+	repository.On("ReserveIdempotencyKey", mock.Anything, auth.AdminActor, "k1", mock.Anything, time.Hour).
+		Run(func(args mock.Arguments) { hash = args.String(3) }).Return(nil, nil).Once()
+	repository.On("InTx", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, fn func(repo2.Repository) error) error { return fn(repository) }).Once()
+	repository.On("CreateTask", mock.Anything, mock.Anything).
+		Return(&repo2.Task{DataObject: repo2.DataObject{ID: "7", Title: "Report"}, UserID: "3"}, nil).Once()
+	repository.On("CreateAuditRecords", mock.Anything, mock.Anything).Return(nil).Once()
+	repository.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(nil).Once()
+	repository.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	repository.On("CompleteIdempotencyKey", mock.Anything, auth.AdminActor, "k1", fiber.StatusOK, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { storedType, stored = args.String(4), args.Get(5).([]byte) }).Return(nil).Once()
+
+	resp, payload := post("k1", body)
+	require.Equal(t, fiber.StatusOK, resp.status)
+	assert.Equal(t, map[string]any{"task_id": "7"}, payload.Data)
+	assert.JSONEq(t, resp.body, string(stored))
+	assert.Equal(t, resp.header(fiber.HeaderContentType), storedType)
+
+	// повтор получает сохранённый ответ, задача второй раз не создаётся
+	record := &repo2.IdempotencyRecord{RequestHash: hash, StatusCode: fiber.StatusOK, Response: stored, ContentType: storedType}
+	repository.On("ReserveIdempotencyKey", mock.Anything, auth.AdminActor, "k1", hash, time.Hour).Return(record, nil)
+	resp, _ = post("k1", body)
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Equal(t, "true", resp.header(middleware.IdempotentReplayedHeader))
+	assert.JSONEq(t, string(stored), resp.body)
+
+	// тот же ключ с другим телом
+	repository.On("ReserveIdempotencyKey", mock.Anything, auth.AdminActor, "k1", mock.Anything, time.Hour).Return(record, nil)
+	resp, payload = post("k1", `{"title":"Other","user_id":"3"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.status)
+	assert.Equal(t, dto.IdempotencyKeyReused, payload.Error.Code)
+
+	// первый запрос с ключом ещё выполняется
+	repository.On("ReserveIdempotencyKey", mock.Anything, auth.AdminActor, "k2", mock.Anything, time.Hour).
+		Return(&repo2.IdempotencyRecord{RequestHash: hash}, nil)
+	resp, payload = post("k2", body)
+	assert.Equal(t, fiber.StatusConflict, resp.status)
+	assert.Equal(t, dto.IdempotencyKeyInProgress, payload.Error.Code)
+
+	// ответ повтора сохраняет тип первого ответа, а не всегда application/json
+	req := httptest.NewRequest(fiber.MethodPost, "/v1/tasks", strings.NewReader("{"))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "k3")
+	req.Header.Set(fiber.HeaderAccept, dto.MIMEProblemJSON)
+	repository.On("ReserveIdempotencyKey", mock.Anything, auth.AdminActor, "k3", mock.Anything, time.Hour).
+		Run(func(args mock.Arguments) { hash = args.String(3) }).Return(nil, nil).Once()
+	repository.On("CompleteIdempotencyKey", mock.Anything, auth.AdminActor, "k3", fiber.StatusBadRequest, dto.MIMEProblemJSON, mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(5).([]byte) }).Return(nil).Once()
+	resp, _ = send(t, app, req)
+	require.Equal(t, fiber.StatusBadRequest, resp.status)
+
+	repository.On("ReserveIdempotencyKey", mock.Anything, auth.AdminActor, "k3", mock.Anything, time.Hour).
+		Return(&repo2.IdempotencyRecord{RequestHash: hash, StatusCode: fiber.StatusBadRequest, Response: stored,
+			ContentType: dto.MIMEProblemJSON}, nil).Once()
+	req = httptest.NewRequest(fiber.MethodPost, "/v1/tasks", strings.NewReader("{"))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "k3")
+	resp, _ = send(t, app, req)
+	assert.Equal(t, fiber.StatusBadRequest, resp.status)
+	assert.Equal(t, "true", resp.header(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, dto.MIMEProblemJSON, resp.header(fiber.HeaderContentType))
+	assert.Equal(t, string(stored), resp.body)
+}
+
+func TestHandlersConditionalRequests(t *testing.T) {
+	task := &repo2.Task{DataObject: repo2.DataObject{ID: "7", Status: "new", Version: 3}, UserID: "3"}
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, "7").Return(task, nil)
+	app := newHandlersApp(repository)
+	request := func(method, ifMatch, ifNoneMatch string) (*testResponse, dto.Response) {
+		req := httptest.NewRequest(method, "/v1/tasks/7", strings.NewReader(`{"status":"done"}`))
+		if ifMatch != "" {
+			req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set(fiber.HeaderIfNoneMatch, ifNoneMatch)
+		}
+		return send(t, app, req)
+	}
+
+	// GET отдаёт ETag, совпадение с If-None-Match - 304 без тела
+	resp, _ := request(fiber.MethodGet, "", "")
+	assert.Equal(t, fiber.StatusOK, resp.status)
+	assert.Equal(t, `"3"`, resp.header(fiber.HeaderETag))
+	resp, _ = request(fiber.MethodGet, "", `"2", W/"3"`)
+	assert.Equal(t, fiber.StatusNotModified, resp.status)
+	assert.Empty(t, resp.body)
+
+	// без If-Match изменение не выполняется
+	resp, payload := request(fiber.MethodPut, "", "")
+	assert.Equal(t, fiber.StatusPreconditionRequired, resp.status)
+	assert.Equal(t, dto.PreconditionRequired, payload.Error.Code)
+	resp, _ = request(fiber.MethodDelete, "", "")
+	assert.Equal(t, fiber.StatusPreconditionRequired, resp.status)
+
+	// устаревшая версия - 412 с текущим состоянием задачи
+	repository.On("InTx", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, fn func(repo2.Repository) error) error { return fn(repository) })
+	resp, payload = request(fiber.MethodPut, `"2"`, "")
+	assert.Equal(t, fiber.StatusPreconditionFailed, resp.status)
+	assert.Equal(t, dto.PreconditionFailed, payload.Error.Code)
+	assert.Equal(t, `"3"`, resp.header(fiber.HeaderETag))
+	assert.Equal(t, 3.0, payload.Data.(map[string]any)["version"])
+
+	// актуальная версия доходит до репозитория
+	repository.On("UpdateStatusByID", mock.Anything, "7", "done", 3).Return(nil).Once()
+	repository.On("CreateAuditRecords", mock.Anything, mock.Anything).Return(nil).Once()
+	repository.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(nil).Once()
+	repository.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	resp, _ = request(fiber.MethodPut, `"3"`, "")
+	assert.Equal(t, fiber.StatusOK, resp.status)
+}
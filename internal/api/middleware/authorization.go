@@ -1,12 +1,50 @@
 package middleware
 
 import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-func Authorization(token string) fiber.Handler {
+const (
+	bearerPrefix = "Bearer "
+
+	// EventSource и WebSocket в браузере не умеют передавать заголовки, для них токен передаётся в query
+	tokenQueryParam = "access_token"
+)
+
+// Authorization пропускает запросы с сервисным токеном (администратор)
+// или с пользовательским токеном, выданным через /v1/login
+func Authorization(token string, signer *auth.Signer) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// проверка токена вторизации
+		raw := c.Get(fiber.HeaderAuthorization)
+		switch {
+		case strings.HasPrefix(raw, bearerPrefix):
+			raw = strings.TrimPrefix(raw, bearerPrefix)
+		case raw == "" && c.Query(tokenQueryParam) != "":
+			raw = c.Query(tokenQueryParam)
+		default:
+			return dto.UnauthorizedError(c)
+		}
+
+		principal, err := auth.Authenticate(raw, token, signer)
+		if err != nil {
+			return dto.UnauthorizedError(c)
+		}
+		auth.SetPrincipal(c, principal)
+		return c.Next()
+	}
+}
+
+// AdminOnly ограничивает доступ к маршруту сервисным токеном
+func AdminOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !auth.FromFiber(c).Admin {
+			return dto.ForbiddenError(c)
+		}
 		return c.Next()
 	}
 }
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	customLogger "TemplatestPGSQL/internal/logger"
+	"TemplatestPGSQL/internal/repo"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	idempotencyPurgeEvery   = 10 * time.Minute
+)
+
+// IdempotencyStore - хранилище ключей идемпотентности, реализуется репозиторием
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, actor, key, requestHash string, ttl time.Duration) (*repo.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, actor, key string, statusCode int, contentType string, response []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, actor, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+// Idempotency сохраняет первый ответ на запрос с заголовком Idempotency-Key и отдаёт его на повторы
+// того же запроса тем же автором, пока ключ не истёк
+type Idempotency struct {
+	store IdempotencyStore
+	ttl   time.Duration
+	log   *zap.SugaredLogger
+}
+
+func NewIdempotency(store IdempotencyStore, ttl time.Duration, logger *zap.SugaredLogger) *Idempotency {
+	return &Idempotency{store: store, ttl: ttl, log: logger}
+}
+
+// Handler ставится после Authorization: ключи разных авторов не пересекаются.
+// Ключ с другим запросом отклоняется с 422, ключ выполняющегося запроса - с 409.
+// Ответы 5xx не сохраняются, после сбоя запрос можно повторить с тем же ключом.
+func (i *Idempotency) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return dto.BadResponseError(c, dto.FieldBadFormat, dto.MsgIdempotencyKeyTooLong)
+		}
+
+		ctx := c.UserContext()
+		log := customLogger.FromContext(ctx, i.log)
+		actor := auth.FromFiber(c).Actor()
+		hash := requestHash(c)
+		record, err := i.store.ReserveIdempotencyKey(ctx, actor, key, hash, i.ttl)
+		if err != nil {
+			log.Error("Failed to reserve idempotency key", zap.Error(err))
+			return dto.InternalServerError(c)
+		}
+
+		if record != nil {
+			switch {
+			case record.RequestHash != hash:
+				return dto.UnprocessableEntityError(c, dto.IdempotencyKeyReused,
+					dto.MsgIdempotencyKeyReused)
+			case record.StatusCode == 0:
+				return dto.ConflictError(c, dto.IdempotencyKeyInProgress,
+					dto.MsgIdempotencyKeyInProgress)
+			}
+			c.Set(IdempotentReplayedHeader, "true")
+			// у записей, сохранённых до колонки content_type, тип не известен, ответы API тогда были JSON
+			contentType := record.ContentType
+			if contentType == "" {
+				contentType = fiber.MIMEApplicationJSON
+			}
+			c.Set(fiber.HeaderContentType, contentType)
+			return c.Status(record.StatusCode).Send(record.Response)
+		}
+
+		err = c.Next()
+		// ответ сохраняется и после истечения срока запроса, иначе ключ остался бы занятым до конца ttl
+		ctx = context.WithoutCancel(ctx)
+		if status := c.Response().StatusCode(); err != nil || status >= fiber.StatusInternalServerError {
+			if releaseErr := i.store.ReleaseIdempotencyKey(ctx, actor, key); releaseErr != nil {
+				log.Error("Failed to release idempotency key", zap.Error(releaseErr))
+			}
+			return err
+		}
+
+		// тело ответа принадлежит fasthttp и переиспользуется, поэтому сохраняется копия
+		response := bytes.Clone(c.Response().Body())
+		contentType := string(c.Response().Header.ContentType())
+		if err = i.store.CompleteIdempotencyKey(ctx, actor, key, c.Response().StatusCode(), contentType, response); err != nil {
+			log.Error("Failed to save idempotent response", zap.Error(err))
+			// иначе повторы получали бы 409 до истечения ключа
+			if err = i.store.ReleaseIdempotencyKey(ctx, actor, key); err != nil {
+				log.Error("Failed to release idempotency key", zap.Error(err))
+			}
+		}
+		return nil
+	}
+}
+
+// Run удаляет истёкшие ключи, пока ctx не отменён
+func (i *Idempotency) Run(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyPurgeEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := i.store.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+				i.log.Error("Failed to delete expired idempotency keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// requestHash - отпечаток запроса: тот же ключ на другом маршруте или с другим телом считается другим запросом
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method() + " " + c.OriginalURL() + "\n"))
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"TemplatestPGSQL/internal/metrics"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics учитывает запрос в метриках по шаблону маршрута. Стоит перед AccessLog,
+// который уже превратил ошибку обработчика в ответ, поэтому статус окончательный
+func Metrics(m *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		m.ObserveRequest(c.Method(), c.Route().Path, c.Response().StatusCode(), time.Since(start))
+		return err
+	}
+}
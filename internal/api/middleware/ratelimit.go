@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	customLogger "TemplatestPGSQL/internal/logger"
+	"TemplatestPGSQL/internal/ratelimit"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Заголовки ограничения частоты запросов (draft-ietf-httpapi-ratelimit-headers)
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// RateLimit ограничивает частоту запросов группы маршрутов group. Ставится после Authorization, чтобы
+// запросы считались по автору, без авторизации - по IP клиента. Превышение - 429 с Retry-After.
+// Ошибка хранилища корзин не блокирует API: запрос пропускается, ошибка пишется в журнал
+func RateLimit(policy *ratelimit.Policy, group string, logger *zap.SugaredLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if policy == nil {
+			return c.Next()
+		}
+
+		subject := ratelimit.Subject(auth.FromFiber(c), c.IP())
+		result, err := policy.Take(c.UserContext(), group, subject)
+		if err != nil {
+			customLogger.FromContext(c.UserContext(), logger).Errorw("Failed to check rate limit", "group", group, "error", err)
+			return c.Next()
+		}
+		if result.Limit.Unlimited() {
+			return c.Next()
+		}
+
+		c.Set(RateLimitLimitHeader, strconv.Itoa(result.Limit.Requests))
+		c.Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		c.Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Set(RateLimitPolicyHeader, strconv.Itoa(result.Limit.Requests)+";w="+strconv.Itoa(ceilSeconds(result.Limit.Period)))
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			return dto.TooManyRequestsError(c)
+		}
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	customLogger "TemplatestPGSQL/internal/logger"
+	"TemplatestPGSQL/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength - более длинный идентификатор клиента заменяется своим
+	maxRequestIDLength = 128
+	requestIDLocalsKey = "requestID"
+)
+
+// RequestID берёт идентификатор запроса из X-Request-ID или создаёт новый, возвращает его в ответе
+// и кладёт в контекст сервиса вместе с логгером запроса
+func RequestID(logger *zap.SugaredLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(RequestIDHeader, id)
+		c.Locals(requestIDLocalsKey, id)
+
+		ctx := service.WithRequestID(c.UserContext(), id)
+		c.SetUserContext(customLogger.WithContext(ctx, logger.With("request_id", id)))
+		return c.Next()
+	}
+}
+
+// GetRequestID - идентификатор текущего запроса
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestIDLocalsKey).(string)
+	return id
+}
+
+// validRequestID пропускает только печатные ASCII-символы, чтобы идентификатор не ломал журнал
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog пишет одну строку журнала на запрос: метод, шаблон маршрута, статус, время, размер ответа
+// (кроме потоковых) и автора
+func AccessLog(logger *zap.SugaredLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			// статус ответа известен только после обработчика ошибок
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		fields := []any{
+			"method", c.Method(),
+			"route", c.Route().Path,
+			"status", status,
+			"latency", time.Since(start),
+			"ip", c.IP(),
+		}
+		// тело потока (SSE, выгрузка) пишется после обработчика: Body() прочитал бы его целиком в память,
+		// а бесконечный поток SSE - никогда, поэтому размер потока не пишется
+		if !c.Response().IsBodyStream() {
+			fields = append(fields, "bytes", len(c.Response().Body()))
+		}
+		if principal := auth.FromFiber(c); principal.Admin || principal.UserID != "" {
+			fields = append(fields, "user", principal.Actor())
+		}
+
+		// логгер запроса уже содержит request_id и trace_id
+		requestLogger := customLogger.FromContext(c.UserContext(), logger)
+		if status >= fiber.StatusInternalServerError {
+			requestLogger.Errorw("request", fields...)
+		} else {
+			requestLogger.Infow("request", fields...)
+		}
+		return nil
+	}
+}
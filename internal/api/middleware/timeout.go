@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeout задаёт контексту запроса срок timeout. Сервис передаёт этот контекст во все запросы к базе,
+// поэтому по истечении срока PostgreSQL прерывает запрос, а клиент получает 504 REQUEST_TIMEOUT.
+// Ставится обработчиком маршрута, а не через app.Use: маршрут без него (долгая загрузка файла) выполняется
+// без срока, и путь запроса для этого сравнивать не нужно. Нулевой timeout отключает проверку
+func Timeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	customLogger "TemplatestPGSQL/internal/logger"
+	"TemplatestPGSQL/internal/tracing"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Tracing открывает серверный спан запроса, продолжая трассировку из заголовка traceparent.
+// Спан попадает в контекст сервиса, так что запросы к базе становятся его дочерними спанами,
+// а trace_id - в логгер запроса. Стоит перед AccessLog, чтобы видеть окончательный статус
+func Tracing(logger *zap.SugaredLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), propagation.HeaderCarrier(c.GetReqHeaders()))
+		ctx, span := tracing.Tracer().Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.ClientAddress(c.IP()),
+			),
+		)
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			requestLogger := customLogger.FromContext(ctx, logger).With("trace_id", spanContext.TraceID().String())
+			ctx = customLogger.WithContext(ctx, requestLogger)
+		}
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// шаблон маршрута известен только после маршрутизации
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
+	}
+}
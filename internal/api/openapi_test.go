@@ -0,0 +1,127 @@
+package api
+
+import (
+	"TemplatestPGSQL/internal/api/middleware"
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/gql"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	"TemplatestPGSQL/pkg/openapi"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const docsFile = "../../docs/openapi.yaml"
+
+var update = flag.Bool("update", false, "rewrite docs/openapi.yaml")
+
+func newTestApp(t *testing.T) *fiber.App {
+	logger := zap.NewNop().Sugar()
+	signer := auth.NewSigner("secret", auth.DefaultTokenTTL)
+	repository := mocks.NewRepository(t)
+	serviceInstance := service.NewService(repository, logger, signer)
+	return NewRouters(&Routers{
+		Service:     serviceInstance,
+		Signer:      signer,
+		Stream:      stream.NewHub(nil, logger),
+		GraphQL:     gql.NewServer(serviceInstance, repository, logger),
+		Idempotency: middleware.NewIdempotency(repository, time.Hour, logger),
+		Logger:      logger,
+	}, "token")
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	routes := newTestApp(t).GetRoutes(true)
+	doc := apiSpec.Build(routes)
+
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		key := openapi.RouteKey(route.Method, route.Path)
+		registered[key] = true
+		assert.False(t, findOperation(t, doc, route).Undocumented, "route %s is missing from apiSpec.Routes", key)
+	}
+	for key := range apiSpec.Routes {
+		assert.True(t, registered[key], "apiSpec.Routes describes %s, but it is not registered", key)
+	}
+}
+
+func TestOpenAPISchemaConstraints(t *testing.T) {
+	doc := apiSpec.Build(newTestApp(t).GetRoutes(true))
+
+	webhook := doc.Components.Schemas["PostWebhookRequest"]
+	require.NotNil(t, webhook)
+	assert.ElementsMatch(t, []string{"url", "event_types", "secret"}, webhook.Required)
+	assert.Equal(t, 16, *webhook.Properties["secret"].MinLength)
+	assert.Equal(t, "uri", webhook.Properties["url"].Format)
+	assert.Contains(t, webhook.Properties["event_types"].Items.Enum, "task.created")
+
+	update := doc.Components.Schemas["UpdateRequest"]
+	assert.Equal(t, []string{"new", "in_progress", "done"}, update.Properties["status"].Enum)
+	assert.NotContains(t, update.Properties, "ID", "path parameter must not leak into the body")
+
+	var limit *openapi.Parameter
+	for i, param := range doc.Paths["/v1/tasks/all"]["get"].Parameters {
+		if param.Name == "limit" {
+			limit = &doc.Paths["/v1/tasks/all"]["get"].Parameters[i]
+		}
+	}
+	require.NotNil(t, limit)
+	assert.Equal(t, 1000.0, *limit.Schema.Maximum)
+}
+
+// TestOpenAPIDocument сверяет docs/openapi.yaml с документом, который отдаёт приложение.
+// После изменения маршрутов файл обновляется: go test ./internal/api -run TestOpenAPIDocument -update
+func TestOpenAPIDocument(t *testing.T) {
+	app := newTestApp(t)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, specPath, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var served openapi.Document
+	require.NoError(t, json.Unmarshal(raw, &served))
+	assert.Equal(t, openapi.Version, served.OpenAPI)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	require.NoError(t, encoder.Encode(apiSpec.Build(app.GetRoutes(true))))
+
+	if *update {
+		require.NoError(t, os.WriteFile(docsFile, buf.Bytes(), 0o644))
+	}
+	committed, err := os.ReadFile(docsFile)
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), string(committed), "docs/openapi.yaml is stale, rerun with -update")
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, docsPath, nil))
+	require.NoError(t, err)
+	page, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(page), `spec-url="/openapi.json"`))
+}
+
+func findOperation(t *testing.T, doc *openapi.Document, route fiber.Route) *openapi.Operation {
+	t.Helper()
+	op := doc.Paths[openapi.PathTemplate(route.Path)][strings.ToLower(route.Method)]
+	require.NotNil(t, op, "route %s %s is missing from the document", route.Method, route.Path)
+	return op
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+// Пакет аутентификации: кто выполняет запрос и как выдаются пользовательские токены
+
+const (
+	localsKey = "principal"
+
+	// AdminActor - имя, под которым в журнале фиксируются действия по сервисному токену
+	AdminActor = "admin"
+
+	DefaultTokenTTL = 24 * time.Hour
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal - автор запроса
+type Principal struct {
+	UserID string
+	Admin  bool
+}
+
+// Actor возвращает идентификатор автора для журнала аудита
+func (p Principal) Actor() string {
+	if p.Admin {
+		return AdminActor
+	}
+	return "user:" + p.UserID
+}
+
+// CanAccess проверяет, что автор может работать с объектом пользователя ownerID
+func (p Principal) CanAccess(ownerID string) bool {
+	return p.Admin || p.UserID == ownerID
+}
+
+type principalKey struct{}
+
+// SetPrincipal запоминает автора в запросе и в его UserContext, откуда его берёт сервис
+func SetPrincipal(ctx *fiber.Ctx, p Principal) {
+	ctx.Locals(localsKey, p)
+	ctx.SetUserContext(WithPrincipal(ctx.UserContext(), p))
+}
+
+func FromFiber(ctx *fiber.Ctx) Principal {
+	p, _ := ctx.Locals(localsKey).(Principal)
+	return p
+}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает автора запроса, пустой Principal - аноним
+func FromContext(ctx context.Context) Principal {
+	p, _ := ctx.Value(principalKey{}).(Principal)
+	return p
+}
+
+// Authenticate определяет автора по токену: сервисный токен даёт права администратора,
+// остальные токены проверяет signer. Общая проверка для REST и gRPC.
+func Authenticate(raw, serviceToken string, signer *Signer) (Principal, error) {
+	if subtle.ConstantTimeCompare([]byte(raw), []byte(serviceToken)) == 1 {
+		return Principal{Admin: true}, nil
+	}
+
+	userID, err := signer.Parse(raw)
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{UserID: userID}, nil
+}
+
+// Signer выдаёт и проверяет подписанные пользовательские токены вида <user_id>.<exp>.<sign>
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte(secret), ttl: ttl}
+}
+
+func (s *Signer) Issue(userID string) string {
+	payload := userID + "." + strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)
+	return payload + "." + s.sign(payload)
+}
+
+func (s *Signer) Parse(token string) (string, error) {
+	idx := strings.LastIndexByte(token, '.')
+	if idx < 0 {
+		return "", ErrInvalidToken
+	}
+	payload, sign := token[:idx], token[idx+1:]
+	if !hmac.Equal([]byte(sign), []byte(s.sign(payload))) {
+		return "", ErrInvalidToken
+	}
+
+	userID, rawExp, ok := strings.Cut(payload, ".")
+	if !ok || userID == "" {
+		return "", ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(rawExp, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return "", ErrInvalidToken
+	}
+
+	return userID, nil
+}
+
+// FeedToken выдаёт токен календарной ленты пользователя. Календари не умеют обновлять токены,
+// поэтому срок у него не ограничен; смена секрета отзывает все ленты.
+func (s *Signer) FeedToken(userID string) string {
+	return s.sign("feed." + userID)
+}
+
+func (s *Signer) VerifyFeedToken(userID, token string) bool {
+	return userID != "" && hmac.Equal([]byte(token), []byte(s.FeedToken(userID)))
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,28 +1,105 @@
 package config
 
-import "time"
-
-const EnvPath = "local.env"
+import (
+	"TemplatestPGSQL/internal/ratelimit"
+	"time"
+)
 
+// Теги полей: envconfig - переменная окружения, yaml - ключ в файле конфигурации и имя флага,
+// default - значение по умолчанию, required - значение обязательно, secret - скрывается при выводе
 type AppConfig struct {
-	LogLevel string
-	Rest     Rest
-	Memory   Memory
+	LogLevel string `envconfig:"LOG_LEVEL" yaml:"log_level" default:"info"`
+	// DefaultLanguage - язык сообщений об ошибках, если Accept-Language не подходит ни к одному каталогу
+	DefaultLanguage string `envconfig:"DEFAULT_LANGUAGE" yaml:"default_language" default:"en"`
+	// ShutdownTimeout - срок остановки сервиса: дождаться запросов, фоновых обработчиков и закрыть пул
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" yaml:"shutdown_timeout" default:"30s"`
+	// ShutdownDelay - пауза между отказом /readyz и закрытием портов: балансировщик успевает убрать экземпляр,
+	// и новые запросы не получают отказ соединения. Входит в ShutdownTimeout
+	ShutdownDelay time.Duration `envconfig:"SHUTDOWN_DELAY" yaml:"shutdown_delay" default:"5s"`
+	Auth          Auth          `yaml:"auth"`
+	Rest          Rest          `yaml:"rest"`
+	Grpc          Grpc          `yaml:"grpc"`
+	Admin         Admin         `yaml:"admin"`
+	Memory        Memory        `yaml:"db"`
+	Webhook       Webhook       `yaml:"webhook"`
+	Tracing       Tracing       `yaml:"tracing"`
+	RateLimit     RateLimit     `yaml:"rate_limit"`
+}
+
+// Auth - сервисный токен администратора и подпись пользовательских токенов
+type Auth struct {
+	Token string `envconfig:"AUTH_TOKEN" yaml:"token" required:"true" secret:"true"`
+	// SigningKey подписывает токены из /v1/login и ссылки на календарь, должен отличаться от Token
+	SigningKey string        `envconfig:"AUTH_SIGNING_KEY" yaml:"signing_key" required:"true" secret:"true"`
+	TokenTTL   time.Duration `envconfig:"AUTH_TOKEN_TTL" yaml:"token_ttl" default:"24h"`
 }
 
 type Rest struct {
-	Port           string        `envconfig:"PORT" default:"8080"`
-	RequestTimeout time.Duration `envconfig:"REQUEST_TIMEOUT" default:"30"`
+	Port           string        `envconfig:"PORT" yaml:"port" default:":8080"`
+	RequestTimeout time.Duration `envconfig:"REQUEST_TIMEOUT" yaml:"request_timeout" default:"30s"`
+	// ExportTimeout - срок записи выгрузки и ленты календаря, которые пишутся потоком после ответа обработчика
+	ExportTimeout time.Duration `envconfig:"EXPORT_TIMEOUT" yaml:"export_timeout" default:"10m"`
+	// IdempotencyTTL - сколько хранится ответ на запрос с заголовком Idempotency-Key
+	IdempotencyTTL time.Duration `envconfig:"IDEMPOTENCY_TTL" yaml:"idempotency_ttl" default:"24h"`
+}
+
+type Grpc struct {
+	Port string `envconfig:"GRPC_PORT" yaml:"port" default:":9090"`
+}
+
+// Admin - служебный порт с метриками и проверками готовности, закрывается от внешнего трафика
+type Admin struct {
+	Port string `envconfig:"ADMIN_PORT" yaml:"port" default:":9100"`
+	// MetricsTimeout ограничивает запросы к базе при сборе метрик
+	MetricsTimeout time.Duration `envconfig:"METRICS_TIMEOUT" yaml:"metrics_timeout" default:"2s"`
+	// HealthTimeout ограничивает каждую проверку готовности
+	HealthTimeout time.Duration `envconfig:"HEALTH_TIMEOUT" yaml:"health_timeout" default:"2s"`
 }
 
 type Memory struct {
-	Host                string        `envconfig:"DB_HOST" required:"true"`
-	Port                int           `envconfig:"DB_PORT" required:"true"`
-	Name                string        `envconfig:"DB_NAME" required:"true"`
-	User                string        `envconfig:"DB_USER" required:"true"`
-	Password            string        `envconfig:"DB_PASSWORD" required:"true"`
-	SSLMode             string        `envconfig:"DB_SSL_MODE" default:"disable"`
-	PoolMaxConns        int           `envconfig:"DB_POOL_MAX_CONNS" default:"5"`
-	PoolMaxConnLifetime time.Duration `envconfig:"DB_POOL_MAX_CONN_LIFETIME" default:"180s"`
-	PoolMaxConnIdleTime time.Duration `envconfig:"DB_POOL_MAX_CONN_IDLE_TIME" default:"100s"`
+	Host                string        `envconfig:"DB_HOST" yaml:"host" required:"true"`
+	Port                int           `envconfig:"DB_PORT" yaml:"port" required:"true"`
+	Name                string        `envconfig:"DB_NAME" yaml:"name" required:"true"`
+	User                string        `envconfig:"DB_USER" yaml:"user" required:"true"`
+	Password            string        `envconfig:"DB_PASSWORD" yaml:"password" required:"true" secret:"true"`
+	SSLMode             string        `envconfig:"DB_SSL_MODE" yaml:"ssl_mode" default:"disable"`
+	PoolMaxConns        int           `envconfig:"DB_POOL_MAX_CONNS" yaml:"pool_max_conns" default:"5"`
+	PoolMaxConnLifetime time.Duration `envconfig:"DB_POOL_MAX_CONN_LIFETIME" yaml:"pool_max_conn_lifetime" default:"180s"`
+	PoolMaxConnIdleTime time.Duration `envconfig:"DB_POOL_MAX_CONN_IDLE_TIME" yaml:"pool_max_conn_idle_time" default:"100s"`
+}
+
+type Webhook struct {
+	PollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" yaml:"poll_interval" default:"2s"`
+	BatchSize    int           `envconfig:"WEBHOOK_BATCH_SIZE" yaml:"batch_size" default:"50"`
+	Timeout      time.Duration `envconfig:"WEBHOOK_TIMEOUT" yaml:"timeout" default:"10s"`
+	MaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" yaml:"max_attempts" default:"8"`
+	BackoffBase  time.Duration `envconfig:"WEBHOOK_BACKOFF_BASE" yaml:"backoff_base" default:"5s"`
+	BackoffMax   time.Duration `envconfig:"WEBHOOK_BACKOFF_MAX" yaml:"backoff_max" default:"1h"`
+}
+
+// Tracing - экспорт трассировки OpenTelemetry. Без Endpoint спаны пишутся в stdout
+type Tracing struct {
+	Endpoint    string  `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT" yaml:"endpoint"`
+	Insecure    bool    `envconfig:"OTEL_EXPORTER_OTLP_INSECURE" yaml:"insecure" default:"true"`
+	ServiceName string  `envconfig:"OTEL_SERVICE_NAME" yaml:"service_name" default:"tasks"`
+	SampleRatio float64 `envconfig:"OTEL_SAMPLE_RATIO" yaml:"sample_ratio" default:"1"`
+}
+
+// RateLimit - ограничение частоты запросов по автору, без авторизации - по IP клиента.
+// Ограничение группы задаётся как "запросов/период" (600/1m), 0 отключает его
+type RateLimit struct {
+	// Backend - memory (корзины у каждой реплики свои) или postgres (общие для всех реплик)
+	Backend string          `envconfig:"RATE_LIMIT_BACKEND" yaml:"backend" default:"memory"`
+	API     ratelimit.Limit `envconfig:"RATE_LIMIT_API" yaml:"api" default:"600/1m"`
+	// Heavy - импорт, выгрузка и пакетные операции, учитываются и в API
+	Heavy ratelimit.Limit `envconfig:"RATE_LIMIT_HEAVY" yaml:"heavy" default:"10/1m"`
+	Login ratelimit.Limit `envconfig:"RATE_LIMIT_LOGIN" yaml:"login" default:"10/1m"`
+}
+
+func (r RateLimit) Limits() ratelimit.Limits {
+	return ratelimit.Limits{
+		ratelimit.GroupAPI:   r.API,
+		ratelimit.GroupHeavy: r.Heavy,
+		ratelimit.GroupLogin: r.Login,
+	}
 }
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"TemplatestPGSQL/internal/ratelimit"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requiredEnv - обязательные значения, без которых конфигурация не проходит проверку
+var requiredEnv = map[string]string{
+	"AUTH_TOKEN":       "service-token",
+	"AUTH_SIGNING_KEY": "signing-key",
+	"DB_HOST":          "env-host",
+	"DB_PORT":          "5432",
+	"DB_NAME":          "tasks",
+	"DB_USER":          "tasks",
+	"DB_PASSWORD":      "db-secret",
+}
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+rest:
+  port: ":8081"
+  request_timeout: 10s
+db:
+  host: file-host
+  pool_max_conns: 20
+webhook:
+  batch_size: 10
+rate_limit:
+  api: 100/1m
+`)
+	env := map[string]string{"WEBHOOK_BATCH_SIZE": "30", "LOG_LEVEL": "debug"}
+	for name, value := range requiredEnv {
+		env[name] = value
+	}
+
+	cfg, args, err := Load([]string{"-config", path, "-webhook.batch_size=40", "import", "-dry-run"}, lookup(env))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"import", "-dry-run"}, args)
+	// значение по умолчанию
+	assert.Equal(t, ":9090", cfg.Grpc.Port)
+	// файл переопределяет значение по умолчанию
+	assert.Equal(t, ":8081", cfg.Rest.Port)
+	assert.Equal(t, 10*time.Second, cfg.Rest.RequestTimeout)
+	assert.Equal(t, 20, cfg.Memory.PoolMaxConns)
+	assert.Equal(t, ratelimit.Limit{Requests: 100, Period: time.Minute}, cfg.RateLimit.API)
+	// окружение переопределяет файл, флаг - окружение
+	assert.Equal(t, "env-host", cfg.Memory.Host)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 40, cfg.Webhook.BatchSize)
+}
+
+func TestLoadLocalEnv(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+rest:
+  port: ":8081"
+db:
+  name: file-db
+tracing:
+  endpoint: collector:4317
+`)
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(EnvPath, []byte("PORT=:8080\nDB_NAME=\nDB_USER=dotenv-user\nLOG_LEVEL=warn\n"), 0o600))
+	env := make(map[string]string)
+	for name, value := range requiredEnv {
+		env[name] = value
+	}
+	delete(env, "DB_NAME")
+	delete(env, "DB_USER")
+	env["LOG_LEVEL"] = "debug"
+
+	cfg, _, err := Load([]string{"-config", path}, lookup(env))
+
+	require.NoError(t, err)
+	// файл перекрывает local.env, пустое значение в local.env ничего не задаёт
+	assert.Equal(t, ":8081", cfg.Rest.Port)
+	assert.Equal(t, "file-db", cfg.Memory.Name)
+	assert.Equal(t, "collector:4317", cfg.Tracing.Endpoint)
+	// local.env задаёт то, чего нет в файле, окружение процесса перекрывает local.env
+	assert.Equal(t, "dotenv-user", cfg.Memory.User)
+	assert.Equal(t, "debug", cfg.LogLevel)
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+log_level = "warn"
+
+[tracing]
+sample_ratio = 0.25
+insecure = false
+
+[webhook]
+max_attempts = 3
+`)
+	cfg, _, err := Load([]string{"-config", path}, lookup(requiredEnv))
+
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+	assert.False(t, cfg.Tracing.Insecure)
+	assert.Equal(t, 3, cfg.Webhook.MaxAttempts)
+}
+
+func TestLoadErrors(t *testing.T) {
+	env := func(overrides map[string]string) map[string]string {
+		merged := make(map[string]string)
+		for name, value := range requiredEnv {
+			merged[name] = value
+		}
+		for name, value := range overrides {
+			merged[name] = value
+		}
+		return merged
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr []string
+	}{
+		{"Missing required", nil, map[string]string{"DB_HOST": "db"},
+			[]string{"auth.token (AUTH_TOKEN) is required", "auth.signing_key (AUTH_SIGNING_KEY) is required",
+				"db.password (DB_PASSWORD) is required"}},
+		{"Signing key equals token", nil, env(map[string]string{"AUTH_SIGNING_KEY": "service-token"}),
+			[]string{"auth.signing_key"}},
+		{"Secret flag", []string{"-db.password=secret"}, requiredEnv,
+			[]string{"flag provided but not defined: -db.password"}},
+		{"Bad duration", nil, env(map[string]string{"REQUEST_TIMEOUT": "30"}),
+			[]string{"rest.request_timeout (REQUEST_TIMEOUT)"}},
+		{"Validation", []string{"-rest.port=8080", "-db.pool_max_conns=0"},
+			env(map[string]string{"WEBHOOK_BACKOFF_MAX": "1s", "RATE_LIMIT_BACKEND": "redis"}),
+			[]string{"rest.port", "db.pool_max_conns", "webhook.backoff_max", "rate_limit.backend"}},
+		{"Shutdown delay longer than timeout", []string{"-shutdown_delay=30s"}, requiredEnv,
+			[]string{"shutdown_delay: must be in [0, shutdown_timeout)"}},
+		{"Unknown file key", []string{"-config", writeFile(t, "config.yaml", "rest:\n  prot: \":8080\"\n")},
+			requiredEnv, []string{`unknown key "rest.prot"`}},
+		{"Unsupported file format", []string{"-config", writeFile(t, "config.json", "{}")},
+			requiredEnv, []string{`unsupported format ".json"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Load(tt.args, lookup(tt.env))
+
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	cfg, _, err := Load(nil, lookup(requiredEnv))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+
+	assert.Contains(t, out.String(), "token: '******' # AUTH_TOKEN")
+	assert.NotContains(t, out.String(), "service-token")
+	assert.NotContains(t, out.String(), "signing-key")
+	assert.NotContains(t, out.String(), "db-secret")
+
+	// вывод - рабочий файл конфигурации: без секретов из окружения получается та же конфигурация
+	path := writeFile(t, "config.yaml", out.String())
+	printed, _, err := Load([]string{"-config", path}, lookup(map[string]string{
+		"AUTH_TOKEN": requiredEnv["AUTH_TOKEN"], "AUTH_SIGNING_KEY": requiredEnv["AUTH_SIGNING_KEY"],
+		"DB_PASSWORD": requiredEnv["DB_PASSWORD"],
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, cfg, printed)
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPath - необязательный файл переменных окружения для локального запуска
+	EnvPath = "local.env"
+	// FileEnv - переменная окружения с путём к файлу конфигурации, флаг -config переопределяет её
+	FileEnv = "CONFIG_FILE"
+
+	redacted = "******"
+)
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// field - одно значение конфигурации
+type field struct {
+	// key - путь в файле конфигурации и имя флага: rest.port
+	key      string
+	env      string
+	def      string
+	required bool
+	secret   bool
+	value    reflect.Value
+}
+
+// fields перечисляет значения cfg в порядке объявления
+func fields(cfg *AppConfig) []field {
+	var all []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for i := 0; i < v.NumField(); i++ {
+			sf, fv := v.Type().Field(i), v.Field(i)
+			key := prefix + sf.Tag.Get("yaml")
+			if sf.Type.Kind() == reflect.Struct && !reflect.PointerTo(sf.Type).Implements(textUnmarshalerType) {
+				walk(fv, key+".")
+				continue
+			}
+			all = append(all, field{
+				key:      key,
+				env:      sf.Tag.Get("envconfig"),
+				def:      sf.Tag.Get("default"),
+				required: sf.Tag.Get("required") == "true",
+				secret:   sf.Tag.Get("secret") == "true",
+				value:    fv,
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return all
+}
+
+// Load собирает конфигурацию по слоям, каждый следующий переопределяет предыдущий: значения по умолчанию,
+// local.env, файл YAML или TOML (-config или CONFIG_FILE), переменные окружения, флаги -rest.port=:8081
+// (кроме секретов). local.env - локальные значения по умолчанию, пустое значение в нём не задаёт ничего.
+// Возвращает проверенную конфигурацию и аргументы после флагов - подкоманду
+func Load(args []string, lookupEnv func(string) (string, bool)) (AppConfig, []string, error) {
+	var cfg AppConfig
+	all := fields(&cfg)
+
+	envFile, err := godotenv.Read(EnvPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, nil, fmt.Errorf("failed to read %s: %w", EnvPath, err)
+	}
+	dotenv := func(name string) (string, bool) {
+		value := envFile[name]
+		return value, value != ""
+	}
+
+	flags := flag.NewFlagSet("tasks", flag.ContinueOnError)
+	configFile, found := lookupEnv(FileEnv)
+	if !found {
+		configFile, _ = dotenv(FileEnv)
+	}
+	flags.StringVar(&configFile, "config", configFile, "YAML or TOML config file, also "+FileEnv)
+	// у секретов флагов нет: аргументы процесса видны в ps и /proc всем пользователям машины
+	overrides := make(map[string]*string, len(all))
+	for _, f := range all {
+		if !f.secret {
+			overrides[f.key] = flags.String(f.key, "", "overrides "+f.env)
+		}
+	}
+	if err = flags.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	var file map[string]string
+	if configFile != "" {
+		if file, err = readFile(configFile, all); err != nil {
+			return cfg, nil, err
+		}
+	}
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var errs []error
+	for _, f := range all {
+		value, ok := f.def, f.def != ""
+		if v, found := dotenv(f.env); found {
+			value, ok = v, true
+		}
+		if v, found := file[f.key]; found {
+			value, ok = v, true
+		}
+		if v, found := lookupEnv(f.env); found {
+			value, ok = v, true
+		}
+		if set[f.key] {
+			value, ok = *overrides[f.key], true
+		}
+
+		// пустое значение оставляет поле нулевым
+		if !ok || value == "" {
+			if f.required {
+				errs = append(errs, fmt.Errorf("%s (%s) is required", f.key, f.env))
+			}
+			continue
+		}
+		if err = setValue(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", f.key, f.env, err))
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, cfg.Validate())
+	}
+	// аргументы возвращаются и с ошибкой: config print показывает, что получилось
+	if err = errors.Join(errs...); err != nil {
+		return cfg, flags.Args(), fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, flags.Args(), nil
+}
+
+// readFile читает файл конфигурации в значения по ключам полей; неизвестный ключ - ошибка, чтобы опечатка не
+// оставила значение по умолчанию незаметно
+func readFile(path string, all []field) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	tree := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &tree)
+	case ".toml":
+		err = toml.Unmarshal(raw, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q, expected .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten(tree, "", values)
+	known := make(map[string]bool, len(all))
+	for _, f := range all {
+		known[f.key] = true
+	}
+	for key := range values {
+		if !known[key] {
+			return nil, fmt.Errorf("config file %s: unknown key %q", path, key)
+		}
+	}
+	return values, nil
+}
+
+func flatten(tree map[string]any, prefix string, values map[string]string) {
+	for key, value := range tree {
+		if nested, ok := value.(map[string]any); ok {
+			flatten(nested, prefix+key+".", values)
+			continue
+		}
+		// ключ без значения - пустая строка, а не "<nil>"
+		if value == nil {
+			value = ""
+		}
+		values[prefix+key] = fmt.Sprint(value)
+	}
+}
+
+// setValue разбирает значение из строки, как его задают в переменной окружения
+func setValue(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Print выводит действующую конфигурацию в формате файла YAML, секреты скрыты
+func (c AppConfig) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{"": root}
+	for _, f := range fields(&c) {
+		parent, name := "", f.key
+		if i := strings.LastIndex(f.key, "."); i >= 0 {
+			parent, name = f.key[:i], f.key[i+1:]
+		}
+		section, ok := sections[parent]
+		if !ok {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			sections[parent] = section
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: parent}, section)
+		}
+
+		value := fmt.Sprint(f.value.Interface())
+		if f.secret && value != "" {
+			value = redacted
+		}
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: value, LineComment: f.env}
+		if value == "" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, node)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+	return encoder.Close()
+}
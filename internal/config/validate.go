@@ -0,0 +1,80 @@
+package config
+
+import (
+	"TemplatestPGSQL/internal/ratelimit"
+	"TemplatestPGSQL/pkg/i18n"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Validate проверяет значения, которые иначе всплыли бы только при первом запросе или не всплыли бы вовсе:
+// адреса портов, сроки, размеры пулов и пакетов. Возвращает все найденные ошибки сразу
+func (c AppConfig) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		check(d > 0, key, "must be a positive duration, got %s", d)
+	}
+
+	_, err := zap.ParseAtomicLevel(c.LogLevel)
+	check(err == nil, "log_level", "unknown level %q", c.LogLevel)
+	_, ok := i18n.Lookup(c.DefaultLanguage)
+	check(ok, "default_language", "unsupported language %q, expected one of %v", c.DefaultLanguage, i18n.Languages())
+	positive("shutdown_timeout", c.ShutdownTimeout)
+	check(c.ShutdownDelay >= 0 && c.ShutdownDelay < c.ShutdownTimeout, "shutdown_delay",
+		"must be in [0, shutdown_timeout), got %s", c.ShutdownDelay)
+
+	// утечка ключа подписи не должна давать права администратора
+	check(c.Auth.SigningKey != c.Auth.Token, "auth.signing_key", "must differ from auth.token")
+	positive("auth.token_ttl", c.Auth.TokenTTL)
+
+	errs = append(errs, listenAddress("rest.port", c.Rest.Port), listenAddress("grpc.port", c.Grpc.Port),
+		listenAddress("admin.port", c.Admin.Port))
+	check(c.Rest.RequestTimeout >= 0, "rest.request_timeout", "must not be negative, got %s", c.Rest.RequestTimeout)
+	positive("rest.export_timeout", c.Rest.ExportTimeout)
+	positive("rest.idempotency_ttl", c.Rest.IdempotencyTTL)
+	positive("admin.metrics_timeout", c.Admin.MetricsTimeout)
+	positive("admin.health_timeout", c.Admin.HealthTimeout)
+
+	check(c.Memory.Port > 0 && c.Memory.Port <= 65535, "db.port", "must be in 1..65535, got %d", c.Memory.Port)
+	check(c.Memory.PoolMaxConns > 0, "db.pool_max_conns", "must be positive, got %d", c.Memory.PoolMaxConns)
+	positive("db.pool_max_conn_lifetime", c.Memory.PoolMaxConnLifetime)
+	positive("db.pool_max_conn_idle_time", c.Memory.PoolMaxConnIdleTime)
+
+	positive("webhook.poll_interval", c.Webhook.PollInterval)
+	check(c.Webhook.BatchSize > 0, "webhook.batch_size", "must be positive, got %d", c.Webhook.BatchSize)
+	positive("webhook.timeout", c.Webhook.Timeout)
+	check(c.Webhook.MaxAttempts > 0, "webhook.max_attempts", "must be positive, got %d", c.Webhook.MaxAttempts)
+	positive("webhook.backoff_base", c.Webhook.BackoffBase)
+	check(c.Webhook.BackoffMax >= c.Webhook.BackoffBase, "webhook.backoff_max",
+		"must not be less than backoff_base %s, got %s", c.Webhook.BackoffBase, c.Webhook.BackoffMax)
+
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio",
+		"must be in 0..1, got %v", c.Tracing.SampleRatio)
+
+	check(c.RateLimit.Backend == ratelimit.BackendMemory || c.RateLimit.Backend == ratelimit.BackendPostgres,
+		"rate_limit.backend", "expected %s or %s, got %q", ratelimit.BackendMemory, ratelimit.BackendPostgres, c.RateLimit.Backend)
+
+	return errors.Join(errs...)
+}
+
+// listenAddress проверяет адрес вида host:port или :port
+func listenAddress(key, address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%s: expected host:port or :port, got %q", key, address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("%s: port must be in 1..65535, got %q", key, port)
+	}
+	return nil
+}
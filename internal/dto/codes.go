@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"TemplatestPGSQL/pkg/i18n"
+	"net/http"
+)
+
+// CodeInfo - запись каталога кодов ошибок
+type CodeInfo struct {
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+}
+
+// Catalog - каталог кодов ошибок API. Клиенты сравнивают коды, а не тексты, поэтому код
+// не переименовывается и не удаляется, а новый добавляется в конец. Title берётся из каталога
+// сообщений языка запроса (pkg/i18n).
+var Catalog = []CodeInfo{
+	{Code: NotFound, Status: http.StatusNotFound},
+	{Code: FieldBadFormat, Status: http.StatusBadRequest},
+	{Code: FieldIncorrect, Status: http.StatusBadRequest},
+	{Code: ServiceUnavailable, Status: http.StatusInternalServerError},
+	{Code: Unauthorized, Status: http.StatusUnauthorized},
+	{Code: Forbidden, Status: http.StatusForbidden},
+	{Code: BulkAborted, Status: http.StatusBadRequest},
+	{Code: IdempotencyKeyReused, Status: http.StatusUnprocessableEntity},
+	{Code: IdempotencyKeyInProgress, Status: http.StatusConflict},
+	{Code: PreconditionFailed, Status: http.StatusPreconditionFailed},
+	{Code: PreconditionRequired, Status: http.StatusPreconditionRequired},
+	{Code: MethodNotAllowed, Status: http.StatusMethodNotAllowed},
+	{Code: RequestTooLarge, Status: http.StatusRequestEntityTooLarge},
+	{Code: RequestTimeout, Status: http.StatusGatewayTimeout},
+	{Code: RateLimited, Status: http.StatusTooManyRequests},
+}
+
+// ProblemTypeBase - адрес каталога ошибок, type документа Problem - его якорь с кодом
+const ProblemTypeBase = "/errors"
+
+var catalogIndex = make(map[string]CodeInfo, len(Catalog))
+
+func init() {
+	for _, info := range Catalog {
+		catalogIndex[info.Code] = info
+	}
+}
+
+// Lookup - запись каталога с описанием на языке lang; код не из каталога считается ошибкой клиента (400)
+func Lookup(lang, code string) CodeInfo {
+	info, ok := catalogIndex[code]
+	if !ok {
+		info = CodeInfo{Code: code, Status: http.StatusBadRequest}
+	}
+	info.Title = i18n.Code(lang, code, http.StatusText(info.Status))
+	return info
+}
+
+// LocalizedCatalog - каталог с описаниями на языке lang
+func LocalizedCatalog(lang string) []CodeInfo {
+	catalog := make([]CodeInfo, 0, len(Catalog))
+	for _, info := range Catalog {
+		catalog = append(catalog, Lookup(lang, info.Code))
+	}
+	return catalog
+}
+
+func ProblemType(code string) string {
+	return ProblemTypeBase + "#" + code
+}
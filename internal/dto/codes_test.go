@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"TemplatestPGSQL/pkg/i18n"
+	"TemplatestPGSQL/pkg/validator"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageConstants - значения всех строковых констант messages.go
+func messageConstants(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "messages.go", nil, 0)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, value := range spec.(*ast.ValueSpec).Values {
+				lit, ok := value.(*ast.BasicLit)
+				require.True(t, ok && lit.Kind == token.STRING, "message constants must be string literals")
+				message, err := strconv.Unquote(lit.Value)
+				require.NoError(t, err)
+				messages = append(messages, message)
+			}
+		}
+	}
+	return messages
+}
+
+func TestCatalogsCoverEveryCode(t *testing.T) {
+	codes := make(map[string]bool, len(Catalog))
+	for _, info := range Catalog {
+		codes[info.Code] = true
+	}
+	messages := append(messageConstants(t), validator.Messages...)
+	assert.Contains(t, messages, MsgUserNotFound)
+
+	for _, lang := range i18n.Languages() {
+		t.Run(lang, func(t *testing.T) {
+			catalog, ok := i18n.Lookup(lang)
+			assert.True(t, ok)
+			for code := range codes {
+				assert.NotEmpty(t, catalog.Codes[code], "code %s", code)
+			}
+			// устаревшие коды в каталоге означают, что код переименовали
+			for code := range catalog.Codes {
+				assert.True(t, codes[code], "unknown code %s", code)
+			}
+			if lang == i18n.Source {
+				return
+			}
+			for _, message := range messages {
+				assert.NotEmpty(t, catalog.Messages[message], "message %q", message)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	assert.Equal(t, CodeInfo{Code: NotFound, Status: 404, Title: "Объект не найден"}, Lookup("ru", NotFound))
+	assert.Equal(t, CodeInfo{Code: "UNKNOWN", Status: 400, Title: "Bad Request"}, Lookup("en", "UNKNOWN"))
+}
@@ -3,5 +3,6 @@ package dto
 import "github.com/pkg/errors"
 
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound        = errors.New(MsgNotFound)
+	ErrVersionConflict = errors.New("version conflict")
 )
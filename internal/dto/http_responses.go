@@ -1,15 +1,33 @@
 package dto
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"TemplatestPGSQL/pkg/i18n"
+	"TemplatestPGSQL/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 const (
-	NotFound           = "NOT_FOUND"
-	FieldBadFormat     = "FIELD_BADFORMAT"
-	FieldIncorrect     = "FIELD_INCORRECT"
-	ServiceUnavailable = "SERVICE_UNAVAILABLE"
-	InternalError      = "Service is currently unavailable. Please try again later."
+	NotFound                 = "NOT_FOUND"
+	FieldBadFormat           = "FIELD_BADFORMAT"
+	FieldIncorrect           = "FIELD_INCORRECT"
+	ServiceUnavailable       = "SERVICE_UNAVAILABLE"
+	Unauthorized             = "UNAUTHORIZED"
+	Forbidden                = "FORBIDDEN"
+	BulkAborted              = "BULK_ABORTED"
+	IdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	IdempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	PreconditionFailed       = "PRECONDITION_FAILED"
+	PreconditionRequired     = "PRECONDITION_REQUIRED"
+	MethodNotAllowed         = "METHOD_NOT_ALLOWED"
+	RequestTooLarge          = "REQUEST_TOO_LARGE"
+	RequestTimeout           = "REQUEST_TIMEOUT"
+	RateLimited              = "RATE_LIMITED"
 )
 
+// MIMEProblemJSON - тип ответа об ошибке по RFC 9457
+const MIMEProblemJSON = "application/problem+json"
+
 type Response struct {
 	Status string `json:"status"`
 	Error  *Error `json:"error,omitempty"`
@@ -19,34 +37,74 @@ type Response struct {
 type Error struct {
 	Code string `json:"code"`
 	Desc string `json:"desc"`
+	// Fields - все нарушенные правила полей для FIELD_INCORRECT
+	Fields []validator.FieldError `json:"fields,omitempty"`
+}
+
+// Problem - ответ об ошибке по RFC 9457 (application/problem+json)
+type Problem struct {
+	// Type - ссылка на код в каталоге ошибок, /errors#NOT_FOUND
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code - код из каталога, тот же, что в конверте Response
+	Code   string                 `json:"code"`
+	Errors []validator.FieldError `json:"errors,omitempty"`
+	Data   any                    `json:"data,omitempty"`
+}
+
+// Fail отвечает ошибкой со статусом из каталога на языке запроса. Формат выбирается по Accept:
+// конверт Response по умолчанию, Problem - если клиент предпочитает application/problem+json.
+func Fail(ctx *fiber.Ctx, e Error, data any) error {
+	lang := i18n.FromContext(ctx.UserContext())
+	info := Lookup(lang, e.Code)
+	e.Desc = i18n.Text(lang, e.Desc)
+	ctx.Status(info.Status)
+	if ctx.Accepts(fiber.MIMEApplicationJSON, MIMEProblemJSON) != MIMEProblemJSON {
+		return ctx.JSON(Response{Status: "error", Error: &e, Data: data})
+	}
+	return ctx.JSON(Problem{
+		Type:     ProblemType(e.Code),
+		Title:    info.Title,
+		Status:   info.Status,
+		Detail:   e.Desc,
+		Instance: ctx.OriginalURL(),
+		Code:     e.Code,
+		Errors:   e.Fields,
+		Data:     data,
+	}, MIMEProblemJSON)
 }
 
 func InternalServerError(ctx *fiber.Ctx) error {
-	return ctx.Status(fiber.StatusInternalServerError).JSON(Response{
-		Status: "error",
-		Error: &Error{
-			Code: ServiceUnavailable,
-			Desc: InternalError,
-		},
-	})
+	return Fail(ctx, Error{Code: ServiceUnavailable, Desc: InternalError}, nil)
 }
 
 func BadResponseError(ctx *fiber.Ctx, code, desc string) error {
-	return ctx.Status(fiber.StatusBadRequest).JSON(Response{
-		Status: "error",
-		Error: &Error{
-			Code: code,
-			Desc: desc,
-		},
-	})
+	return Fail(ctx, Error{Code: code, Desc: desc}, nil)
 }
 
 func NotFoundError(ctx *fiber.Ctx, code, desc string) error {
-	return ctx.Status(fiber.StatusBadRequest).JSON(Response{
-		Status: "error",
-		Error: &Error{
-			Code: code,
-			Desc: desc,
-		},
-	})
+	return Fail(ctx, Error{Code: code, Desc: desc}, nil)
+}
+
+func UnauthorizedError(ctx *fiber.Ctx) error {
+	return Fail(ctx, Error{Code: Unauthorized, Desc: MsgUnauthorized}, nil)
+}
+
+func ForbiddenError(ctx *fiber.Ctx) error {
+	return Fail(ctx, Error{Code: Forbidden, Desc: MsgForbidden}, nil)
+}
+
+func ConflictError(ctx *fiber.Ctx, code, desc string) error {
+	return Fail(ctx, Error{Code: code, Desc: desc}, nil)
+}
+
+func UnprocessableEntityError(ctx *fiber.Ctx, code, desc string) error {
+	return Fail(ctx, Error{Code: code, Desc: desc}, nil)
+}
+
+func TooManyRequestsError(ctx *fiber.Ctx) error {
+	return Fail(ctx, Error{Code: RateLimited, Desc: MsgRateLimited}, nil)
 }
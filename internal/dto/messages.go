@@ -0,0 +1,55 @@
+package dto
+
+// Тексты ошибок для клиента. Каталоги pkg/i18n переводят каждый из них по английскому тексту, поэтому текст
+// задаётся только константой отсюда. Подробности, которые не переводятся (id, имя поля, ошибка разбора),
+// добавляются после двоеточия: MsgUserNotFound + ": " + id
+const (
+	InternalError    = "Service is currently unavailable. Please try again later."
+	MsgUnauthorized  = "Missing or invalid authorization token"
+	MsgForbidden     = "Access denied"
+	MsgRateLimited   = "Too many requests, retry after the time in Retry-After"
+	MsgTimeout       = "Request did not complete in time, try again later"
+	MsgNotFound      = "not found"
+	MsgUserNotFound  = "user not found"
+	MsgTaskNotFound  = "task not found"
+	MsgRouteNotFound = "Route not found"
+
+	MsgMethodNotAllowed = "Method not allowed"
+	MsgRequestTooLarge  = "Request body is too large"
+	MsgBadRequest       = "Malformed request"
+	MsgInvalidBody      = "Invalid request body"
+	MsgInvalidQuery     = "Invalid query"
+	MsgNegativePage     = "limit and offset must not be negative"
+	MsgInvalidTimeBound = "created_from/created_to/due_from/due_to must be RFC3339 timestamps"
+	MsgInvalidAuditTime = "from/to must be RFC3339 timestamps"
+	MsgCalendarNoUser   = "user_id is required"
+
+	MsgIfMatchRequired          = "If-Match header is required"
+	MsgOperationVersionRequired = "Operation version is required"
+	MsgTaskVersionRequired      = "Task version is required"
+	MsgVersionOutdated          = "Task has been modified, version is outdated"
+
+	MsgIdempotencyKeyTooLong    = "Idempotency-Key must be at most 255 characters"
+	MsgIdempotencyKeyReused     = "Idempotency-Key was already used with a different request"
+	MsgIdempotencyKeyInProgress = "Request with this Idempotency-Key is still in progress"
+
+	MsgBulkRolledBack = "Bulk operation was rolled back because some items failed"
+
+	MsgInvalidMapping          = "Invalid column mapping, expected field=column"
+	MsgUnknownMappingField     = "Unknown task field in column mapping"
+	MsgUnsupportedImportFormat = "Unsupported import format"
+	MsgImportNoUser            = "user_id is required to import ics"
+	MsgInvalidCSVHeader        = "Failed to read CSV header"
+	MsgMappedColumnMissing     = "Mapped column is missing in CSV header"
+	MsgRequiredColumnMissing   = "CSV header has no column for a required field"
+	MsgInvalidCSVRow           = "Invalid CSV row"
+	MsgInvalidJSONRow          = "Invalid JSON line"
+	MsgInvalidNDJSON           = "Failed to read NDJSON"
+	MsgInvalidCalendarEntry    = "Invalid calendar entry"
+	MsgInvalidDueAt            = "due_at must be an RFC3339 timestamp"
+)
+
+// Detail добавляет к тексту msg подробности, которые не переводятся
+func Detail(msg, detail string) string {
+	return msg + ": " + detail
+}
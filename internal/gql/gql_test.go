@@ -0,0 +1,132 @@
+package gql
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"TemplatestPGSQL/internal/service"
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	admin = auth.WithPrincipal(context.Background(), auth.Principal{Admin: true})
+	user1 = auth.WithPrincipal(context.Background(), auth.Principal{UserID: "1"})
+)
+
+func newTestServer(repository repo2.Repository) *Server {
+	logger := zap.NewNop().Sugar()
+	return NewServer(service.NewService(repository, logger, auth.NewSigner("secret", auth.DefaultTokenTTL)), repository, logger)
+}
+
+func exec(t *testing.T, s *Server, ctx context.Context, query string) (map[string]any, []map[string]any) {
+	t.Helper()
+	raw, err := json.Marshal(s.Exec(ctx, GraphQLRequest{Query: query}))
+	require.NoError(t, err)
+
+	var resp struct {
+		Data   map[string]any   `json:"data"`
+		Errors []map[string]any `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	return resp.Data, resp.Errors
+}
+
+func task(id, userID, status string) repo2.Task {
+	return repo2.Task{DataObject: repo2.DataObject{ID: id, Title: "task " + id, Status: status}, UserID: userID}
+}
+
+func TestTasksLoadUsersInOneQuery(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetAllTasks", mock.Anything, repo2.TaskFilter{Status: "new", Limit: 100}).
+		Return([]repo2.Task{task("1", "1", "new"), task("2", "2", "new"), task("3", "1", "new")}, nil)
+	repository.On("GetUsersByIDs", mock.Anything, []string{"1", "2"}).
+		Return([]repo2.User{{ID: "1", Name: "ivan", Password: "p"}, {ID: "2", Name: "olga", Password: "p"}}, nil).Once()
+
+	data, errs := exec(t, newTestServer(repository), admin, `{ tasks(filter: {status: "new"}) { id user { name } } }`)
+	require.Empty(t, errs)
+	assert.Equal(t, []any{
+		map[string]any{"id": "1", "user": map[string]any{"name": "ivan"}},
+		map[string]any{"id": "2", "user": map[string]any{"name": "olga"}},
+		map[string]any{"id": "3", "user": map[string]any{"name": "ivan"}},
+	}, data["tasks"])
+}
+
+func TestUsersLoadTasksInOneQuery(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetUsers", mock.Anything).Return([]repo2.User{{ID: "1", Name: "ivan"}, {ID: "2", Name: "olga"}}, nil)
+	repository.On("GetTasksByUserIDs", mock.Anything, []string{"1", "2"}).
+		Return([]repo2.Task{task("1", "1", "new"), task("2", "1", "done"), task("3", "2", "new")}, nil).Once()
+
+	data, errs := exec(t, newTestServer(repository), admin, `{ users { name tasks(status: "new") { id } } }`)
+	require.Empty(t, errs)
+	assert.Equal(t, []any{
+		map[string]any{"name": "ivan", "tasks": []any{map[string]any{"id": "1"}}},
+		map[string]any{"name": "olga", "tasks": []any{map[string]any{"id": "3"}}},
+	}, data["users"])
+}
+
+func TestErrors(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, "7").Return(nil, dto.ErrNotFound)
+	s := newTestServer(repository)
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		query string
+		code  string
+	}{
+		{"Users for user", user1, `{ users { id } }`, dto.Forbidden},
+		{"Another user", user1, `{ user(id: "2") { id } }`, dto.Forbidden},
+		{"Not found", admin, `{ task(id: "7") { id } }`, dto.NotFound},
+		{"Invalid status", admin, `mutation { updateTaskStatus(id: "7", status: "lost", version: 1) { id } }`, dto.FieldIncorrect},
+		{"Missing version", admin, `mutation { deleteTask(id: "7") }`, dto.PreconditionRequired},
+		{"Invalid filter", admin, `{ tasks(filter: {dueFrom: "tomorrow"}) { id } }`, dto.FieldBadFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := exec(t, s, tt.ctx, tt.query)
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.code, errs[0]["extensions"].(map[string]any)["code"])
+		})
+	}
+}
+
+func TestLoaderBatchesConcurrentLoads(t *testing.T) {
+	var calls atomic.Int32
+	l := newLoader(func(_ context.Context, keys []string) (map[string]int, error) {
+		calls.Add(1)
+		values := make(map[string]int, len(keys))
+		for _, key := range keys {
+			values[key] = len(key)
+		}
+		return values, nil
+	})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "bb", "a", "ccc"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := l.Load(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, len(key), value)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// загруженные ключи берутся из кэша
+	_, _ = l.Load(context.Background(), "bb")
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,130 @@
+package gql
+
+import (
+	"TemplatestPGSQL/internal/repo"
+	"context"
+	"sync"
+	"time"
+)
+
+// batchWait - окно, за которое ключи параллельных резолверов собираются в один запрос
+const batchWait = 2 * time.Millisecond
+
+// loader загружает значения по ключам пачками и кэширует их до конца запроса.
+// Резолверы списков заранее ставят ключи в очередь через Prime, поэтому связи всех строк
+// загружаются одним запросом независимо от того, сколько резолверов работает параллельно.
+type loader[V any] struct {
+	fetch func(ctx context.Context, keys []string) (map[string]V, error)
+
+	mu      sync.Mutex
+	results map[string]*result[V]
+	pending []string
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func newLoader[V any](fetch func(ctx context.Context, keys []string) (map[string]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, results: make(map[string]*result[V])}
+}
+
+// Load возвращает значение ключа; для ненайденного ключа - нулевое значение без ошибки
+func (l *loader[V]) Load(ctx context.Context, key string) (V, error) {
+	l.mu.Lock()
+	r := l.enqueue(ctx, key)
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Prime ставит ключи в очередь, не дожидаясь загрузки
+func (l *loader[V]) Prime(ctx context.Context, keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.enqueue(ctx, key)
+	}
+}
+
+func (l *loader[V]) enqueue(ctx context.Context, key string) *result[V] {
+	if r, ok := l.results[key]; ok {
+		return r
+	}
+
+	r := &result[V]{done: make(chan struct{})}
+	l.results[key] = r
+	l.pending = append(l.pending, key)
+	if len(l.pending) == 1 {
+		time.AfterFunc(batchWait, func() { l.dispatch(ctx) })
+	}
+	return r
+}
+
+func (l *loader[V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	values, err := l.fetch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		r := l.results[key]
+		r.value, r.err = values[key], err
+		close(r.done)
+	}
+}
+
+// loaders - загрузчики связей одного запроса
+type loaders struct {
+	users       *loader[*repo.User]
+	tasksByUser *loader[[]repo.Task]
+}
+
+func newLoaders(repository repo.Repository) *loaders {
+	return &loaders{
+		users: newLoader(func(ctx context.Context, ids []string) (map[string]*repo.User, error) {
+			users, err := repository.GetUsersByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]*repo.User, len(users))
+			for i := range users {
+				byID[users[i].ID] = &users[i]
+			}
+			return byID, nil
+		}),
+		tasksByUser: newLoader(func(ctx context.Context, ids []string) (map[string][]repo.Task, error) {
+			tasks, err := repository.GetTasksByUserIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byUser := make(map[string][]repo.Task, len(ids))
+			for _, task := range tasks {
+				byUser[task.UserID] = append(byUser[task.UserID], task)
+			}
+			return byUser, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
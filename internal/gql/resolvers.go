@@ -0,0 +1,283 @@
+package gql
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// resolver - корень схемы: запросы и мутации идут через доменный сервис, связи - через загрузчики
+type resolver struct {
+	server *Server
+}
+
+type taskFilterInput struct {
+	Status      *string
+	UserId      *graphql.ID
+	Tag         *string
+	CreatedFrom *string
+	CreatedTo   *string
+	DueFrom     *string
+	DueTo       *string
+}
+
+func (r *resolver) Tasks(ctx context.Context, args struct {
+	Filter *taskFilterInput
+	Limit  int32
+	Offset int32
+}) ([]*taskResolver, error) {
+	req := service.TaskListRequest{Limit: int(args.Limit), Offset: int(args.Offset)}
+	if f := args.Filter; f != nil {
+		req.Status, req.Tag = deref(f.Status), deref(f.Tag)
+		req.CreatedFrom, req.CreatedTo = deref(f.CreatedFrom), deref(f.CreatedTo)
+		req.DueFrom, req.DueTo = deref(f.DueFrom), deref(f.DueTo)
+		if f.UserId != nil {
+			req.UserID = string(*f.UserId)
+		}
+	}
+
+	tasks, err := r.server.service.GetAllTasks(ctx, req)
+	if err != nil {
+		return nil, r.server.fail(ctx, err)
+	}
+	return r.taskList(ctx, tasks), nil
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	task, err := r.server.service.GetTaskByID(ctx, service.RequestWithId{ID: string(args.ID)})
+	if err != nil {
+		return nil, r.server.fail(ctx, err)
+	}
+	return &taskResolver{server: r.server, task: *task}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Limit  int32
+	Offset int32
+}) ([]*userResolver, error) {
+	if !auth.FromContext(ctx).Admin {
+		return nil, r.server.fail(ctx, service.ErrForbidden)
+	}
+	if args.Limit < 0 || args.Offset < 0 {
+		return nil, r.server.fail(ctx, &service.Error{Code: dto.FieldIncorrect, Desc: dto.MsgNegativePage})
+	}
+
+	users, err := r.server.service.GetUsers(ctx)
+	if err != nil {
+		return nil, r.server.fail(ctx, err)
+	}
+	users = page(users, int(args.Limit), int(args.Offset))
+
+	resolvers := make([]*userResolver, 0, len(users))
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		resolvers = append(resolvers, &userResolver{server: r.server, user: user})
+		ids = append(ids, user.ID)
+	}
+	if graphql.HasSelectedField(ctx, "tasks") {
+		loadersFrom(ctx).tasksByUser.Prime(ctx, ids...)
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	if !auth.FromContext(ctx).CanAccess(string(args.ID)) {
+		return nil, r.server.fail(ctx, service.ErrForbidden)
+	}
+	return r.server.loadUser(ctx, string(args.ID))
+}
+
+func (r *resolver) CreateTask(ctx context.Context, args struct {
+	Input struct {
+		Title  string
+		Data   *string
+		UserId graphql.ID
+		DueAt  *graphql.Time
+	}
+}) (*taskResolver, error) {
+	req := service.PostRequest{
+		Title:  args.Input.Title,
+		Data:   deref(args.Input.Data),
+		UserID: string(args.Input.UserId),
+	}
+	if args.Input.DueAt != nil {
+		req.DueAt = &args.Input.DueAt.Time
+	}
+
+	task, err := r.server.service.CreateTask(ctx, req)
+	if err != nil {
+		return nil, r.server.fail(ctx, err)
+	}
+	return &taskResolver{server: r.server, task: *task}, nil
+}
+
+func (r *resolver) UpdateTaskStatus(ctx context.Context, args struct {
+	ID      graphql.ID
+	Status  string
+	Version *int32
+}) (*taskResolver, error) {
+	if deref(args.Version) == 0 {
+		return nil, r.server.fail(ctx, service.ErrVersionRequired)
+	}
+	err := r.server.service.UpdateStatusByID(ctx, service.UpdateRequest{
+		ID:      string(args.ID),
+		Status:  args.Status,
+		Version: int(deref(args.Version)),
+	})
+	if err != nil {
+		return nil, r.server.fail(ctx, err)
+	}
+	return r.Task(ctx, struct{ ID graphql.ID }{args.ID})
+}
+
+func (r *resolver) DeleteTask(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version *int32
+}) (graphql.ID, error) {
+	if deref(args.Version) == 0 {
+		return "", r.server.fail(ctx, service.ErrVersionRequired)
+	}
+	err := r.server.service.DeleteTaskByID(ctx, service.DeleteTaskRequest{ID: string(args.ID), Version: int(deref(args.Version))})
+	if err != nil {
+		return "", r.server.fail(ctx, err)
+	}
+	return args.ID, nil
+}
+
+// taskList оборачивает задачи и заранее ставит их владельцев в очередь загрузки, если они запрошены
+func (r *resolver) taskList(ctx context.Context, tasks []repo.Task) []*taskResolver {
+	resolvers := make([]*taskResolver, 0, len(tasks))
+	userIDs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		resolvers = append(resolvers, &taskResolver{server: r.server, task: task})
+		userIDs = append(userIDs, task.UserID)
+	}
+	if graphql.HasSelectedField(ctx, "user") {
+		loadersFrom(ctx).users.Prime(ctx, userIDs...)
+	}
+	return resolvers
+}
+
+type taskResolver struct {
+	server *Server
+	task   repo.Task
+}
+
+func (t *taskResolver) ID() graphql.ID {
+	return graphql.ID(t.task.ID)
+}
+
+func (t *taskResolver) Title() string {
+	return t.task.Title
+}
+
+func (t *taskResolver) Data() string {
+	return t.task.Data
+}
+
+func (t *taskResolver) Status() string {
+	return t.task.Status
+}
+
+func (t *taskResolver) Tags() []string {
+	if t.task.Tags == nil {
+		return []string{}
+	}
+	return t.task.Tags
+}
+
+func (t *taskResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: t.task.CreatedAt}
+}
+
+func (t *taskResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: t.task.UpdatedAt}
+}
+
+func (t *taskResolver) Version() int32 {
+	return int32(t.task.Version)
+}
+
+func (t *taskResolver) DueAt() *graphql.Time {
+	if t.task.DueAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t.task.DueAt}
+}
+
+func (t *taskResolver) User(ctx context.Context) (*userResolver, error) {
+	return t.server.loadUser(ctx, t.task.UserID)
+}
+
+type userResolver struct {
+	server *Server
+	user   service.UserInfo
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(u.user.ID)
+}
+
+func (u *userResolver) Name() string {
+	return u.user.Name
+}
+
+func (u *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: u.user.CreatedAt}
+}
+
+func (u *userResolver) Tasks(ctx context.Context, args struct{ Status *string }) ([]*taskResolver, error) {
+	if !auth.FromContext(ctx).CanAccess(u.user.ID) {
+		return nil, u.server.fail(ctx, service.ErrForbidden)
+	}
+
+	tasks, err := loadersFrom(ctx).tasksByUser.Load(ctx, u.user.ID)
+	if err != nil {
+		return nil, u.server.fail(ctx, err)
+	}
+
+	resolvers := make([]*taskResolver, 0, len(tasks))
+	for _, task := range tasks {
+		if args.Status != nil && task.Status != *args.Status {
+			continue
+		}
+		resolvers = append(resolvers, &taskResolver{server: u.server, task: task})
+	}
+	return resolvers, nil
+}
+
+// loadUser загружает пользователя через загрузчик запроса, ненайденный пользователь - null
+func (s *Server) loadUser(ctx context.Context, id string) (*userResolver, error) {
+	user, err := loadersFrom(ctx).users.Load(ctx, id)
+	if err != nil {
+		return nil, s.fail(ctx, err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &userResolver{server: s, user: service.UserInfo{ID: user.ID, Name: user.Name, CreatedAt: user.CreatedAt}}, nil
+}
+
+func deref[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+// page отбирает страницу списка, нулевой limit означает без ограничения
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,99 @@
+package gql
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	customLogger "TemplatestPGSQL/internal/logger"
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/pkg/i18n"
+	"TemplatestPGSQL/pkg/validator"
+	"context"
+	_ "embed"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+)
+
+// Пакет GraphQL API. Запросы и мутации выполняет доменный сервис (те же проверки, аудит и события, что в REST),
+// связи задача→пользователь и пользователь→задачи загружаются пачками на время одного запроса.
+
+//go:embed schema.graphql
+var schemaSource string
+
+const (
+	// maxParallelism - сколько резолверов одного запроса работает одновременно
+	maxParallelism = 100
+	maxDepth       = 8
+)
+
+type Server struct {
+	schema  *graphql.Schema
+	service service.Service
+	repo    repo.Repository
+	log     *zap.SugaredLogger
+}
+
+func NewServer(s service.Service, repository repo.Repository, logger *zap.SugaredLogger) *Server {
+	server := &Server{service: s, repo: repository, log: logger}
+	server.schema = graphql.MustParseSchema(schemaSource, &resolver{server: server},
+		graphql.UseStringDescriptions(),
+		graphql.MaxParallelism(maxParallelism),
+		graphql.MaxDepth(maxDepth),
+	)
+	return server
+}
+
+// GraphQLRequest - тело запроса POST /graphql
+type GraphQLRequest struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Exec выполняет запрос со своими загрузчиками связей
+func (s *Server) Exec(ctx context.Context, req GraphQLRequest) *graphql.Response {
+	ctx = withLoaders(ctx, newLoaders(s.repo))
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+// Handler отвечает в формате GraphQL {data, errors}: ошибки резолверов не меняют статус ответа
+func (s *Server) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req GraphQLRequest
+		if err := json.Unmarshal(ctx.Body(), &req); err != nil || req.Query == "" {
+			return dto.BadResponseError(ctx, dto.FieldBadFormat, dto.MsgInvalidBody)
+		}
+		return ctx.JSON(s.Exec(ctx.UserContext(), req))
+	}
+}
+
+// Error - ошибка резолвера, код сервиса передаётся в extensions.code, неверные поля - в extensions.fields
+type Error struct {
+	Code    string
+	Message string
+	Fields  []validator.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.Code}
+	if len(e.Fields) > 0 {
+		extensions["fields"] = e.Fields
+	}
+	return extensions
+}
+
+// fail переводит ошибку сервиса в ошибку GraphQL на языке запроса; подробности внутренних ошибок остаются в логе
+func (s *Server) fail(ctx context.Context, err error) error {
+	lang := i18n.FromContext(ctx)
+	if svcErr, ok := service.AsError(ctx, err); ok {
+		return &Error{Code: svcErr.Code, Message: i18n.Text(lang, svcErr.Desc), Fields: svcErr.Fields}
+	}
+	customLogger.FromContext(ctx, s.log).Error("GraphQL resolver failed", zap.Error(err))
+	return &Error{Code: dto.ServiceUnavailable, Message: i18n.Text(lang, dto.InternalError)}
+}
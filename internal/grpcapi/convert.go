@@ -0,0 +1,47 @@
+package grpcapi
+
+import (
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	tasksv1 "TemplatestPGSQL/pkg/pb/tasks/v1"
+	"encoding/json"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func taskToProto(task *repo.Task) *tasksv1.Task {
+	msg := &tasksv1.Task{
+		Id:        task.ID,
+		UserId:    task.UserID,
+		Title:     task.Title,
+		Data:      task.Data,
+		Status:    task.Status,
+		Tags:      task.Tags,
+		CreatedAt: timestamppb.New(task.CreatedAt),
+		UpdatedAt: timestamppb.New(task.UpdatedAt),
+		Version:   int32(task.Version),
+	}
+	if task.DueAt != nil {
+		msg.DueAt = timestamppb.New(*task.DueAt)
+	}
+	return msg
+}
+
+func userToProto(user *service.UserInfo) *tasksv1.User {
+	return &tasksv1.User{Id: user.ID, Name: user.Name, CreatedAt: timestamppb.New(user.CreatedAt)}
+}
+
+// eventToProto разбирает задачу из уведомления; её нет у удалений и у слишком больших задач
+func eventToProto(event repo.TaskEvent) (*tasksv1.TaskEvent, error) {
+	msg := &tasksv1.TaskEvent{Type: event.Type, TaskId: event.TaskID, UserId: event.UserID}
+	if len(event.Task) == 0 {
+		return msg, nil
+	}
+
+	var task repo.Task
+	if err := json.Unmarshal(event.Task, &task); err != nil {
+		return nil, err
+	}
+	msg.Task = taskToProto(&task)
+	return msg, nil
+}
@@ -0,0 +1,58 @@
+package grpcapi
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/pkg/i18n"
+	"context"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes сопоставляет коды ошибок сервиса со статусами gRPC
+var statusCodes = map[string]codes.Code{
+	dto.NotFound:           codes.NotFound,
+	dto.FieldBadFormat:     codes.InvalidArgument,
+	dto.FieldIncorrect:     codes.InvalidArgument,
+	dto.Unauthorized:       codes.Unauthenticated,
+	dto.Forbidden:          codes.PermissionDenied,
+	dto.BulkAborted:        codes.Aborted,
+	dto.PreconditionFailed: codes.Aborted,
+	dto.RequestTimeout:     codes.DeadlineExceeded,
+	dto.RateLimited:        codes.ResourceExhausted,
+}
+
+// toStatus переводит ошибку сервиса в статус gRPC на языке запроса; всё, что не *service.Error,
+// клиент видит как Internal
+func toStatus(ctx context.Context, err error) error {
+	lang := i18n.FromContext(ctx)
+	svcErr, ok := service.AsError(ctx, err)
+	if !ok {
+		return status.Error(codes.Internal, i18n.Text(lang, dto.InternalError))
+	}
+
+	code, ok := statusCodes[svcErr.Code]
+	if !ok {
+		code = codes.FailedPrecondition
+	}
+	st := status.New(code, i18n.Text(lang, svcErr.Desc))
+	if len(svcErr.Fields) == 0 {
+		return st.Err()
+	}
+
+	// неверные поля передаются деталью BadRequest, как принято в gRPC
+	badRequest := &errdetails.BadRequest{}
+	for _, fieldErr := range svcErr.Fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldErr.Field,
+			Description: fieldErr.Message,
+			Reason:      fieldErr.Rule,
+		})
+	}
+	if detailed, err := st.WithDetails(badRequest); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
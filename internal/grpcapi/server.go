@@ -0,0 +1,210 @@
+package grpcapi
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/ratelimit"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	"TemplatestPGSQL/pkg/i18n"
+	tasksv1 "TemplatestPGSQL/pkg/pb/tasks/v1"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+)
+
+// Пакет gRPC API: те же операции, что у REST, поверх того же доменного сервиса.
+// Авторизация и идентификатор запроса передаются метаданными authorization и x-request-id.
+
+const (
+	authorizationKey = "authorization"
+	requestIDKey     = "x-request-id"
+	languageKey      = "accept-language"
+	retryAfterKey    = "retry-after"
+	bearerPrefix     = "Bearer "
+)
+
+var (
+	apiPrefix = "/" + string(tasksv1.File_tasks_v1_tasks_proto.Package()) + "."
+
+	// publicMethods вызываются без токена
+	publicMethods = map[string]bool{
+		tasksv1.UserService_Login_FullMethodName: true,
+	}
+	// adminMethods доступны только по сервисному токену, как маршруты с middleware.AdminOnly
+	adminMethods = map[string]bool{
+		tasksv1.UserService_ListUsers_FullMethodName:  true,
+		tasksv1.UserService_DeleteUser_FullMethodName: true,
+	}
+)
+
+type Server struct {
+	Service service.Service
+	Signer  *auth.Signer
+	Stream  *stream.Hub
+	Logger  *zap.SugaredLogger
+	// Timeout - срок унарного вызова, как REQUEST_TIMEOUT у REST; более короткий срок клиента сохраняется
+	Timeout time.Duration
+	// RateLimit - ограничения частоты вызовов, те же, что у REST; nil - без ограничений
+	RateLimit *ratelimit.Policy
+}
+
+// NewServer собирает gRPC-сервер с сервисами задач и пользователей, проверкой здоровья и reflection
+func NewServer(s *Server, token string) *grpc.Server {
+	authorizer := &authorizer{token: token, signer: s.Signer}
+	limiter := &limiter{policy: s.RateLimit, log: s.Logger}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(timeout(s.Timeout), authorizer.unary, limiter.unary),
+		grpc.ChainStreamInterceptor(authorizer.stream, limiter.stream),
+	)
+
+	tasksv1.RegisterTaskServiceServer(server, &taskServer{service: s.Service, stream: s.Stream, log: s.Logger})
+	tasksv1.RegisterUserServiceServer(server, &userServer{service: s.Service})
+
+	healthServer := health.NewServer()
+	for name := range server.GetServiceInfo() {
+		healthServer.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+
+	return server
+}
+
+// timeout ограничивает унарные вызовы; потоки WatchTasks живут, пока клиент их не закроет
+func timeout(d time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if d <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+type authorizer struct {
+	token  string
+	signer *auth.Signer
+}
+
+func (a *authorizer) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorizer) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorize кладёт автора, идентификатор запроса и язык в контекст, откуда их берёт сервис
+func (a *authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(requestIDKey); len(ids) > 0 {
+		ctx = service.WithRequestID(ctx, ids[0])
+	}
+	ctx = i18n.WithLanguage(ctx, i18n.Match(strings.Join(md.Get(languageKey), ",")))
+
+	// проверка здоровья и reflection не относятся к API задач
+	if publicMethods[method] || !strings.HasPrefix(method, apiPrefix) {
+		return ctx, nil
+	}
+
+	var raw string
+	if values := md.Get(authorizationKey); len(values) > 0 {
+		raw = values[0]
+	}
+	if !strings.HasPrefix(raw, bearerPrefix) {
+		return nil, toStatus(ctx, service.ErrUnauthorized)
+	}
+	principal, err := auth.Authenticate(strings.TrimPrefix(raw, bearerPrefix), a.token, a.signer)
+	if err != nil {
+		return nil, toStatus(ctx, service.ErrUnauthorized)
+	}
+	if adminMethods[method] && !principal.Admin {
+		return nil, toStatus(ctx, service.ErrForbidden)
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// serverStream подменяет контекст потока на контекст с автором запроса
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// limiter ограничивает частоту вызовов API задач: Login - по IP, остальные методы - по автору
+type limiter struct {
+	policy *ratelimit.Policy
+	log    *zap.SugaredLogger
+}
+
+func (l *limiter) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := l.take(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *limiter) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := l.take(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// take учитывает вызов; превышение - ResourceExhausted с метаданными retry-after в секундах
+func (l *limiter) take(ctx context.Context, method string) error {
+	if l.policy == nil || !strings.HasPrefix(method, apiPrefix) {
+		return nil
+	}
+
+	group := ratelimit.GroupAPI
+	if method == tasksv1.UserService_Login_FullMethodName {
+		group = ratelimit.GroupLogin
+	}
+	result, err := l.policy.Take(ctx, group, ratelimit.Subject(auth.FromContext(ctx), peerIP(ctx)))
+	if err != nil {
+		l.log.Errorw("Failed to check rate limit", "group", group, "error", err)
+		return nil
+	}
+	if result.Allowed {
+		return nil
+	}
+
+	seconds := int((result.RetryAfter + time.Second - 1) / time.Second)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterKey, strconv.Itoa(seconds)))
+	return toStatus(ctx, service.ErrRateLimited)
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
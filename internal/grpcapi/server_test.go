@@ -0,0 +1,197 @@
+package grpcapi
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	tasksv1 "TemplatestPGSQL/pkg/pb/tasks/v1"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testToken = "token"
+
+var testSigner = auth.NewSigner("secret", auth.DefaultTokenTTL)
+
+// fakeListener отдаёт хабу уведомления из канала
+type fakeListener chan string
+
+func (l fakeListener) Listen(ctx context.Context, _ string, handle func(payload string)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case payload := <-l:
+			handle(payload)
+		}
+	}
+}
+
+func newTestConn(t *testing.T, repository repo2.Repository, listener stream.Listener) *grpc.ClientConn {
+	t.Helper()
+	logger := zap.NewNop().Sugar()
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := stream.NewHub(listener, logger)
+	if listener != nil {
+		go hub.Run(ctx)
+	}
+
+	server := NewServer(&Server{
+		Service: service.NewService(repository, logger, testSigner),
+		Signer:  testSigner,
+		Stream:  hub,
+		Logger:  logger,
+	}, testToken)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		cancel()
+		server.Stop()
+	})
+	return conn
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationKey, bearerPrefix+token)
+}
+
+func TestServerErrors(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetTaskByID", mock.Anything, "7").Return(nil, dto.ErrNotFound)
+	conn := newTestConn(t, repository, nil)
+	tasks := tasksv1.NewTaskServiceClient(conn)
+	users := tasksv1.NewUserServiceClient(conn)
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"No token", func() error {
+			_, err := tasks.GetTask(context.Background(), &tasksv1.GetTaskRequest{Id: "7"})
+			return err
+		}, codes.Unauthenticated},
+		{"Bad token", func() error {
+			_, err := tasks.GetTask(withToken("bad"), &tasksv1.GetTaskRequest{Id: "7"})
+			return err
+		}, codes.Unauthenticated},
+		{"Not found", func() error {
+			_, err := tasks.GetTask(withToken(testToken), &tasksv1.GetTaskRequest{Id: "7"})
+			return err
+		}, codes.NotFound},
+		{"Invalid field", func() error {
+			_, err := tasks.CreateTask(withToken(testToken), &tasksv1.CreateTaskRequest{Title: "t"})
+			return err
+		}, codes.InvalidArgument},
+		{"Missing version", func() error {
+			_, err := tasks.DeleteTask(withToken(testToken), &tasksv1.DeleteTaskRequest{Id: "7"})
+			return err
+		}, codes.FailedPrecondition},
+		{"Admin only", func() error {
+			_, err := users.ListUsers(withToken(testSigner.Issue("1")), &tasksv1.ListUsersRequest{})
+			return err
+		}, codes.PermissionDenied},
+		{"Wrong password", func() error {
+			_, err := users.Login(context.Background(), &tasksv1.LoginRequest{Name: "u", Password: "p"})
+			return err
+		}, codes.Unauthenticated},
+	}
+	repository.On("GetUserByName", mock.Anything, "u").Return(nil, dto.ErrNotFound)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, status.Code(tt.call()))
+		})
+	}
+}
+
+func TestServerListTasks(t *testing.T) {
+	due := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	repository := mocks.NewRepository(t)
+	repository.On("GetAllTasks", mock.Anything, repo2.TaskFilter{UserID: "1", Status: "new"}).Return([]repo2.Task{
+		{DataObject: repo2.DataObject{ID: "3", Title: "t", Status: "new"}, UserID: "1", DueAt: &due},
+	}, nil)
+	conn := newTestConn(t, repository, nil)
+
+	// фильтр пользователя подставляется сервисом из токена
+	resp, err := tasksv1.NewTaskServiceClient(conn).ListTasks(withToken(testSigner.Issue("1")),
+		&tasksv1.ListTasksRequest{Status: "new"})
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 1)
+	assert.Equal(t, "3", resp.Tasks[0].Id)
+	assert.Equal(t, due, resp.Tasks[0].DueAt.AsTime())
+}
+
+func TestServerWatchTasks(t *testing.T) {
+	listener := make(fakeListener)
+	conn := newTestConn(t, mocks.NewRepository(t), listener)
+
+	ctx, cancel := context.WithCancel(withToken(testSigner.Issue("1")))
+	defer cancel()
+	watch, err := tasksv1.NewTaskServiceClient(conn).WatchTasks(ctx, &tasksv1.WatchTasksRequest{})
+	require.NoError(t, err)
+	// заголовки приходят после подписки
+	_, err = watch.Header()
+	require.NoError(t, err)
+
+	listener <- `{"type":"task.created","task_id":"10","user_id":"2","task":{"id":"10","user_id":"2"}}`
+	listener <- `{"type":"task.created","task_id":"11","user_id":"1","task":{"id":"11","user_id":"1","title":"t"}}`
+	listener <- `{"type":"task.deleted","task_id":"11","user_id":"1"}`
+
+	event, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "11", event.TaskId)
+	assert.Equal(t, "t", event.Task.Title)
+
+	event, err = watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "task.deleted", event.Type)
+	assert.Nil(t, event.Task)
+}
+
+func TestServerHealthAndReflection(t *testing.T) {
+	conn := newTestConn(t, mocks.NewRepository(t), nil)
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(),
+		&healthpb.HealthCheckRequest{Service: tasksv1.TaskService_ServiceDesc.ServiceName})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+
+	info, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, info.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := info.Recv()
+	require.NoError(t, err)
+
+	var names []string
+	for _, svc := range resp.GetListServicesResponse().Service {
+		names = append(names, svc.Name)
+	}
+	assert.Contains(t, names, tasksv1.TaskService_ServiceDesc.ServiceName)
+	assert.Contains(t, names, tasksv1.UserService_ServiceDesc.ServiceName)
+}
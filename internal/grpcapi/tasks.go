@@ -0,0 +1,123 @@
+package grpcapi
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/internal/stream"
+	tasksv1 "TemplatestPGSQL/pkg/pb/tasks/v1"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type taskServer struct {
+	tasksv1.UnimplementedTaskServiceServer
+	service service.Service
+	stream  *stream.Hub
+	log     *zap.SugaredLogger
+}
+
+func (s *taskServer) CreateTask(ctx context.Context, req *tasksv1.CreateTaskRequest) (*tasksv1.Task, error) {
+	var dueAt *time.Time
+	if req.DueAt != nil {
+		due := req.DueAt.AsTime()
+		dueAt = &due
+	}
+
+	task, err := s.service.CreateTask(ctx, service.PostRequest{
+		Title:  req.Title,
+		Data:   req.Data,
+		UserID: req.UserId,
+		DueAt:  dueAt,
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return taskToProto(task), nil
+}
+
+func (s *taskServer) GetTask(ctx context.Context, req *tasksv1.GetTaskRequest) (*tasksv1.Task, error) {
+	task, err := s.service.GetTaskByID(ctx, service.RequestWithId{ID: req.Id})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return taskToProto(task), nil
+}
+
+func (s *taskServer) ListTasks(ctx context.Context, req *tasksv1.ListTasksRequest) (*tasksv1.ListTasksResponse, error) {
+	tasks, err := s.service.GetAllTasks(ctx, service.TaskListRequest{
+		Status:      req.Status,
+		UserID:      req.UserId,
+		Tag:         req.Tag,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		DueFrom:     req.DueFrom,
+		DueTo:       req.DueTo,
+		Limit:       int(req.Limit),
+		Offset:      int(req.Offset),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &tasksv1.ListTasksResponse{Tasks: make([]*tasksv1.Task, 0, len(tasks))}
+	for i := range tasks {
+		resp.Tasks = append(resp.Tasks, taskToProto(&tasks[i]))
+	}
+	return resp, nil
+}
+
+func (s *taskServer) UpdateTaskStatus(ctx context.Context, req *tasksv1.UpdateTaskStatusRequest) (*tasksv1.UpdateTaskStatusResponse, error) {
+	if req.Version == 0 {
+		return nil, toStatus(ctx, service.ErrVersionRequired)
+	}
+	if err := s.service.UpdateStatusByID(ctx, service.UpdateRequest{ID: req.Id, Status: req.Status, Version: int(req.Version)}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &tasksv1.UpdateTaskStatusResponse{}, nil
+}
+
+func (s *taskServer) DeleteTask(ctx context.Context, req *tasksv1.DeleteTaskRequest) (*tasksv1.DeleteTaskResponse, error) {
+	if req.Version == 0 {
+		return nil, toStatus(ctx, service.ErrVersionRequired)
+	}
+	if err := s.service.DeleteTaskByID(ctx, service.DeleteTaskRequest{ID: req.Id, Version: int(req.Version)}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &tasksv1.DeleteTaskResponse{}, nil
+}
+
+// WatchTasks отдаёт изменения задач так же, как SSE и WebSocket. Заголовки отправляются сразу после
+// подписки: получив их, клиент знает, что не пропустит последующие изменения.
+func (s *taskServer) WatchTasks(_ *tasksv1.WatchTasksRequest, ss grpc.ServerStreamingServer[tasksv1.TaskEvent]) error {
+	ctx := ss.Context()
+	events, unsubscribe := s.stream.Subscribe(auth.FromContext(ctx))
+	defer unsubscribe()
+
+	if err := ss.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stream.Done():
+			return status.Error(codes.Unavailable, "server shutdown")
+		case event := <-events:
+			msg, err := eventToProto(event)
+			if err != nil {
+				s.log.Error("Invalid task event", zap.Error(err))
+				continue
+			}
+			if err = ss.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
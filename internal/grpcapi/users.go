@@ -0,0 +1,48 @@
+package grpcapi
+
+import (
+	"TemplatestPGSQL/internal/service"
+	tasksv1 "TemplatestPGSQL/pkg/pb/tasks/v1"
+	"context"
+)
+
+type userServer struct {
+	tasksv1.UnimplementedUserServiceServer
+	service service.Service
+}
+
+func (s *userServer) Login(ctx context.Context, req *tasksv1.LoginRequest) (*tasksv1.LoginResponse, error) {
+	result, err := s.service.Login(ctx, service.PostUserRequest{Name: req.Name, Password: req.Password})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &tasksv1.LoginResponse{Token: result.Token, UserId: result.UserID}, nil
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *tasksv1.CreateUserRequest) (*tasksv1.User, error) {
+	user, err := s.service.CreateUser(ctx, service.PostUserRequest{Name: req.Name, Password: req.Password})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return userToProto(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, _ *tasksv1.ListUsersRequest) (*tasksv1.ListUsersResponse, error) {
+	users, err := s.service.GetUsers(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &tasksv1.ListUsersResponse{Users: make([]*tasksv1.User, 0, len(users))}
+	for i := range users {
+		resp.Users = append(resp.Users, userToProto(&users[i]))
+	}
+	return resp, nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *tasksv1.DeleteUserRequest) (*tasksv1.DeleteUserResponse, error) {
+	if err := s.service.DeleteUserByID(ctx, service.RequestWithId{ID: req.Id}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &tasksv1.DeleteUserResponse{}, nil
+}
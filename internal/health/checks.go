@@ -0,0 +1,35 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// Store - хранилище, реализуется репозиторием
+type Store interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (version int64, dirty bool, err error)
+}
+
+// Postgres проверяет, что пул соединений может выполнить запрос
+func Postgres(store Store) CheckFunc {
+	return store.Ping
+}
+
+// Migrations проверяет, что схема базы не старее версии, с которой собран сервис,
+// и что последняя миграция не оборвалась на середине
+func Migrations(store Store, expected int64) CheckFunc {
+	return func(ctx context.Context) error {
+		version, dirty, err := store.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version < expected {
+			return fmt.Errorf("schema version %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
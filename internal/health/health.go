@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Пакет проверок живости и готовности сервиса для оркестратора.
+// /healthz отвечает, пока процесс жив, /readyz - пока все зависимости доступны и сервис не останавливается.
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	// shutdownCheck - проверка, которая не проходит после начала остановки
+	shutdownCheck = "shutdown"
+)
+
+// CheckFunc проверяет одну зависимость, ошибка означает, что она недоступна
+type CheckFunc func(ctx context.Context) error
+
+// Result - итог одной проверки
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report - общий итог и результат каждой проверки
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry - набор проверок готовности. Подсистемы добавляют в него свои проверки через Register
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry - timeout ограничивает каждую проверку
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout, checks: make(map[string]CheckFunc)}
+}
+
+// Register добавляет проверку, проверка с тем же именем заменяется
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Shutdown переводит сервис в неготовность, чтобы оркестратор перестал направлять в него трафик
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check выполняет все проверки параллельно
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]CheckFunc, len(r.checks)+1)
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+	checks[shutdownCheck] = r.checkShutdown
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := r.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, check CheckFunc) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := Result{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (r *Registry) checkShutdown(context.Context) error {
+	if r.shuttingDown.Load() {
+		return errors.New("service is shutting down")
+	}
+	return nil
+}
+
+// Liveness отвечает 200, пока процесс обрабатывает запросы
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: StatusOK, Checks: map[string]Result{}})
+	})
+}
+
+// Readiness отвечает 200, если прошли все проверки, иначе 503; в теле - результат каждой проверки
+func (r *Registry) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
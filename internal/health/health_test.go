@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	pingErr error
+	version int64
+	dirty   bool
+}
+
+func (f fakeStore) Ping(context.Context) error {
+	return f.pingErr
+}
+
+func (f fakeStore) SchemaVersion(context.Context) (int64, bool, error) {
+	return f.version, f.dirty, nil
+}
+
+func readiness(t *testing.T, registry *Registry) (int, Report) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	registry.Readiness().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	return recorder.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      fakeStore
+		wantStatus int
+		wantFailed []string
+	}{
+		{"Ready", fakeStore{version: 8}, http.StatusOK, nil},
+		{"Newer schema", fakeStore{version: 9}, http.StatusOK, nil},
+		{"DB unreachable", fakeStore{pingErr: errors.New("connection refused"), version: 8}, http.StatusServiceUnavailable, []string{"postgres"}},
+		{"Outdated schema", fakeStore{version: 7}, http.StatusServiceUnavailable, []string{"migrations"}},
+		{"Dirty migration", fakeStore{version: 8, dirty: true}, http.StatusServiceUnavailable, []string{"migrations"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(time.Second)
+			registry.Register("postgres", Postgres(tt.store))
+			registry.Register("migrations", Migrations(tt.store, 8))
+
+			status, report := readiness(t, registry)
+
+			assert.Equal(t, tt.wantStatus, status)
+			require.Len(t, report.Checks, 3)
+			var failed []string
+			for _, name := range []string{"migrations", "postgres", shutdownCheck} {
+				if report.Checks[name].Status == StatusFail {
+					failed = append(failed, name)
+					assert.NotEmpty(t, report.Checks[name].Error)
+				}
+			}
+			assert.Equal(t, tt.wantFailed, failed)
+		})
+	}
+}
+
+func TestReadinessShutdown(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.Register("postgres", Postgres(fakeStore{}))
+	registry.Shutdown()
+
+	status, report := readiness(t, registry)
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, StatusFail, report.Checks[shutdownCheck].Status)
+	assert.Equal(t, StatusOK, report.Checks["postgres"].Status)
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	registry := NewRegistry(10 * time.Millisecond)
+	registry.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	status, report := readiness(t, registry)
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
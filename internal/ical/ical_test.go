@@ -0,0 +1,86 @@
+package ical
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTodo(t *testing.T) {
+	due := time.Date(2025, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*3600))
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Begin("Tasks"))
+	require.NoError(t, w.WriteTodo(Item{
+		UID:         "task-1@example",
+		Summary:     "Call; then, write",
+		Description: strings.Repeat("я", 60),
+		Status:      "COMPLETED",
+		Categories:  []string{"work", "a,b"},
+		Due:         &due,
+	}))
+	require.NoError(t, w.End())
+
+	out := buf.String()
+	assert.Contains(t, out, "SUMMARY:Call\\; then\\, write\r\n")
+	assert.Contains(t, out, "DUE:20250301T090000Z\r\n")
+	assert.Contains(t, out, "CATEGORIES:work,a\\,b\r\n")
+	assert.Contains(t, out, "PERCENT-COMPLETE:100\r\n")
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+
+	// перенесённые строки собираются обратно
+	_, item, err := NewReader(&buf).NextTodo()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("я", 60), item.Description)
+	assert.Equal(t, "Call; then, write", item.Summary)
+	assert.Equal(t, []string{"work", "a,b"}, item.Categories)
+	assert.True(t, due.Equal(*item.Due))
+}
+
+func TestReaderNextTodo(t *testing.T) {
+	src := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:skipped\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:First\r\n" +
+		"DUE;TZID=Europe/Moscow:20250301T120000\r\n" +
+		"BEGIN:VALARM\r\nSUMMARY:alarm\r\nEND:VALARM\r\n" +
+		"STATUS:in-process\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Broken\r\n" +
+		"DUE:2025-03-01\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Date only\r\n" +
+		"DUE;VALUE=DATE:20250302\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	r := NewReader(strings.NewReader(src))
+
+	line, item, err := r.NextTodo()
+	require.NoError(t, err)
+	assert.Equal(t, 6, line)
+	assert.Equal(t, "First", item.Summary)
+	assert.Equal(t, "IN-PROCESS", item.Status)
+	assert.Equal(t, time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), item.Due.UTC())
+
+	_, _, err = r.NextTodo()
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 16, parseErr.Line)
+
+	_, item, err = r.NextTodo()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), *item.Due)
+
+	_, _, err = r.NextTodo()
+	assert.ErrorIs(t, err, io.EOF)
+}
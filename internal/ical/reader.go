@@ -0,0 +1,278 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	localDateTimeFormat = "20060102T150405"
+	maxLineSize         = 1 << 20
+)
+
+// ParseError - ошибка разбора одного компонента, после неё можно читать следующий
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Reader читает VTODO из календаря потоком, остальные компоненты пропускаются
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	// строка, прочитанная заранее, чтобы понять, продолжается ли предыдущая
+	next     string
+	nextLine int
+	hasNext  bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// NextTodo возвращает номер строки BEGIN:VTODO и разобранную задачу, io.EOF в конце данных.
+// Ошибка в значениях задачи возвращается как *ParseError.
+func (r *Reader) NextTodo() (int, Item, error) {
+	for {
+		line, content, err := r.readLine()
+		if err != nil {
+			return 0, Item{}, err
+		}
+		prop, err := parseProperty(content)
+		if err == nil && prop.name == "BEGIN" && strings.EqualFold(prop.value, ComponentTodo) {
+			return r.readTodo(line)
+		}
+	}
+}
+
+func (r *Reader) readTodo(start int) (int, Item, error) {
+	var item Item
+	var parseErr *ParseError
+	// вложенные компоненты (VALARM) пропускаются
+	depth := 0
+	for {
+		line, content, err := r.readLine()
+		if errors.Is(err, io.EOF) {
+			return start, Item{}, &ParseError{Line: start, Err: errors.New("VTODO is not closed")}
+		}
+		if err != nil {
+			return 0, Item{}, err
+		}
+
+		prop, err := parseProperty(content)
+		switch {
+		case err != nil:
+		case prop.name == "BEGIN":
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case prop.name == "END":
+			if parseErr != nil {
+				return start, Item{}, parseErr
+			}
+			return start, item, nil
+		case depth > 0:
+			continue
+		default:
+			err = item.set(prop)
+		}
+		if err != nil && parseErr == nil {
+			parseErr = &ParseError{Line: line, Err: err}
+		}
+	}
+}
+
+func (item *Item) set(prop property) error {
+	switch prop.name {
+	case "UID":
+		item.UID = UnescapeText(prop.value)
+	case "SUMMARY":
+		item.Summary = UnescapeText(prop.value)
+	case "DESCRIPTION":
+		item.Description = UnescapeText(prop.value)
+	case "STATUS":
+		item.Status = strings.ToUpper(prop.value)
+	case "CATEGORIES":
+		for _, category := range splitText(prop.value) {
+			if category = strings.TrimSpace(category); category != "" {
+				item.Categories = append(item.Categories, category)
+			}
+		}
+	case "DUE":
+		due, err := parseTime(prop)
+		if err != nil {
+			return err
+		}
+		item.Due = &due
+	case "CREATED":
+		created, err := parseTime(prop)
+		if err != nil {
+			return err
+		}
+		item.Created = created
+	}
+	return nil
+}
+
+// readLine возвращает логическую строку, собранную из перенесённых, и номер её первой строки
+func (r *Reader) readLine() (int, string, error) {
+	for {
+		if !r.hasNext {
+			text, ok := r.physicalLine()
+			if !ok {
+				return 0, "", r.eof()
+			}
+			r.next, r.nextLine, r.hasNext = text, r.line, true
+		}
+
+		start, content := r.nextLine, r.next
+		r.hasNext = false
+		for {
+			text, ok := r.physicalLine()
+			if !ok {
+				break
+			}
+			if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+				content += text[1:]
+				continue
+			}
+			r.next, r.nextLine, r.hasNext = text, r.line, true
+			break
+		}
+
+		if content != "" {
+			return start, content, nil
+		}
+	}
+}
+
+func (r *Reader) physicalLine() (string, bool) {
+	if !r.scanner.Scan() {
+		return "", false
+	}
+	r.line++
+	return strings.TrimSuffix(r.scanner.Text(), "\r"), true
+}
+
+func (r *Reader) eof() error {
+	if err := r.scanner.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read line %d", r.line+1)
+	}
+	return io.EOF
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseProperty разбирает строку вида NAME;PARAM=VALUE:value, двоеточия в кавычках параметров не учитываются
+func parseProperty(content string) (property, error) {
+	var parts []string
+	inQuotes, from := false, 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';', ':':
+			if inQuotes {
+				continue
+			}
+			parts = append(parts, content[from:i])
+			from = i + 1
+			if content[i] == ':' {
+				prop := property{name: strings.ToUpper(parts[0]), params: make(map[string]string), value: content[from:]}
+				for _, param := range parts[1:] {
+					key, value, _ := strings.Cut(param, "=")
+					prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+				}
+				return prop, nil
+			}
+		}
+	}
+	return property{}, errors.Errorf("invalid content line %q", content)
+}
+
+// parseTime разбирает DATE и DATE-TIME; время без зоны и без TZID считается UTC
+func parseTime(prop property) (time.Time, error) {
+	loc := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, errors.Errorf("%s: unknown time zone %q", prop.name, tzid)
+		}
+	}
+
+	var t time.Time
+	var err error
+	switch {
+	case prop.params["VALUE"] == "DATE" || len(prop.value) == len(dateFormat):
+		t, err = time.ParseInLocation(dateFormat, prop.value, loc)
+	case strings.HasSuffix(prop.value, "Z"):
+		t, err = time.Parse(dateTimeFormat, prop.value)
+	default:
+		t, err = time.ParseInLocation(localDateTimeFormat, prop.value, loc)
+	}
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s: invalid date %q", prop.name, prop.value)
+	}
+	return t, nil
+}
+
+// UnescapeText снимает экранирование значения типа TEXT
+func UnescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			b.WriteByte('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
+
+// splitText делит список значений TEXT по неэкранированным запятым
+func splitText(value string) []string {
+	var parts []string
+	from, escaped := 0, false
+	for i := 0; i < len(value); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case value[i] == '\\':
+			escaped = true
+		case value[i] == ',':
+			parts = append(parts, UnescapeText(value[from:i]))
+			from = i + 1
+		}
+	}
+	return append(parts, UnescapeText(value[from:]))
+}
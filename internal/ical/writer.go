@@ -0,0 +1,145 @@
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Пакет чтения и записи iCalendar (RFC 5545) в объёме, нужном для задач: VTODO и VEVENT
+
+const (
+	ProdID = "-//TemplatestPGSQL//Tasks//RU"
+
+	ComponentTodo  = "VTODO"
+	ComponentEvent = "VEVENT"
+
+	dateTimeFormat = "20060102T150405Z"
+	dateFormat     = "20060102"
+	maxLineOctets  = 75
+)
+
+// Item - задача или событие календаря
+type Item struct {
+	UID         string
+	Summary     string
+	Description string
+	// Status - значение STATUS в терминах iCalendar (NEEDS-ACTION, COMPLETED, CONFIRMED...)
+	Status     string
+	Categories []string
+	Due        *time.Time
+	Created    time.Time
+}
+
+// Writer пишет календарь потоком; первая ошибка записи запоминается и возвращается всеми следующими вызовами
+type Writer struct {
+	w     io.Writer
+	stamp string
+	err   error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, stamp: formatDateTime(time.Now())}
+}
+
+// Begin открывает VCALENDAR, name - отображаемое имя календаря
+func (w *Writer) Begin(name string) error {
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + ProdID)
+	w.line("CALSCALE:GREGORIAN")
+	if name != "" {
+		w.line("X-WR-CALNAME:" + EscapeText(name))
+	}
+	return w.err
+}
+
+// WriteTodo пишет VTODO, срок задачи попадает в DUE
+func (w *Writer) WriteTodo(item Item) error {
+	w.line("BEGIN:" + ComponentTodo)
+	w.common(item)
+	if item.Due != nil {
+		w.line("DUE:" + formatDateTime(*item.Due))
+	}
+	if item.Status == "COMPLETED" {
+		w.line("PERCENT-COMPLETE:100")
+	}
+	w.line("END:" + ComponentTodo)
+	return w.err
+}
+
+// WriteEvent пишет VEVENT, который начинается и заканчивается в момент срока задачи
+func (w *Writer) WriteEvent(item Item) error {
+	w.line("BEGIN:" + ComponentEvent)
+	w.common(item)
+	if item.Due != nil {
+		w.line("DTSTART:" + formatDateTime(*item.Due))
+	}
+	w.line("END:" + ComponentEvent)
+	return w.err
+}
+
+// End закрывает VCALENDAR
+func (w *Writer) End() error {
+	w.line("END:VCALENDAR")
+	return w.err
+}
+
+func (w *Writer) common(item Item) {
+	w.line("UID:" + EscapeText(item.UID))
+	w.line("DTSTAMP:" + w.stamp)
+	if !item.Created.IsZero() {
+		w.line("CREATED:" + formatDateTime(item.Created))
+	}
+	w.line("SUMMARY:" + EscapeText(item.Summary))
+	if item.Description != "" {
+		w.line("DESCRIPTION:" + EscapeText(item.Description))
+	}
+	if item.Status != "" {
+		w.line("STATUS:" + item.Status)
+	}
+	if len(item.Categories) > 0 {
+		categories := make([]string, len(item.Categories))
+		for i, category := range item.Categories {
+			categories[i] = EscapeText(category)
+		}
+		w.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+}
+
+// line пишет строку содержимого, перенося её по 75 октетов без разрыва символов UTF-8
+func (w *Writer) line(content string) {
+	if w.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// пробел в начале строки продолжения тоже занимает октет
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+
+	_, w.err = io.WriteString(w.w, b.String())
+}
+
+// EscapeText экранирует значение типа TEXT
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
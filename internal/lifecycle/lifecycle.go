@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Пакет остановки сервиса: шаги выполняются по порядку регистрации, все вместе - не дольше заданного срока.
+
+// ErrDeadlineExceeded - остановка не уложилась в срок, часть работы могла быть прервана
+var ErrDeadlineExceeded = errors.New("shutdown deadline exceeded")
+
+type step struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager запускает фоновые обработчики и останавливает сервис
+type Manager struct {
+	deadline time.Duration
+	log      *zap.SugaredLogger
+
+	steps []step
+
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+}
+
+func New(deadline time.Duration, logger *zap.SugaredLogger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{deadline: deadline, log: logger, workersCtx: ctx, stopWorkers: cancel}
+}
+
+// OnShutdown добавляет шаг остановки. ctx шага истекает вместе со сроком всей остановки
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.steps = append(m.steps, step{name: name, fn: fn})
+}
+
+// Go запускает фоновый обработчик. Его контекст отменяется шагом StopWorkers
+func (m *Manager) Go(run func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		run(m.workersCtx)
+	}()
+}
+
+// StopWorkers - шаг остановки: отменяет контекст фоновых обработчиков и ждёт их завершения
+func (m *Manager) StopWorkers(ctx context.Context) error {
+	m.stopWorkers()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown выполняет все шаги, даже если предыдущие завершились ошибкой или срок вышел:
+// после срока шаги получают отменённый контекст и освобождают ресурсы без ожидания.
+// Возвращает ErrDeadlineExceeded, если остановка не уложилась в срок
+func (m *Manager) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.deadline)
+	defer cancel()
+
+	var errs []error
+	for _, s := range m.steps {
+		start := time.Now()
+		if err := s.fn(ctx); err != nil {
+			m.log.Errorw("Shutdown step failed", "step", s.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			continue
+		}
+		m.log.Infow("Shutdown step completed", "step", s.name, "duration", time.Since(start))
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		errs = append(errs, ErrDeadlineExceeded)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	m := New(time.Second, zap.NewNop().Sugar())
+
+	var stopped bool
+	m.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		stopped = true
+	})
+
+	var order []string
+	record := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	m.OnShutdown("readiness", record("readiness", nil))
+	m.OnShutdown("http", record("http", errors.New("listener closed")))
+	m.OnShutdown("workers", func(ctx context.Context) error {
+		order = append(order, "workers")
+		return m.StopWorkers(ctx)
+	})
+	m.OnShutdown("postgres", record("postgres", nil))
+
+	err := m.Shutdown()
+
+	// ошибка шага не прерывает остановку, но возвращается
+	assert.Equal(t, []string{"readiness", "http", "workers", "postgres"}, order)
+	assert.ErrorContains(t, err, "http: listener closed")
+	assert.NotErrorIs(t, err, ErrDeadlineExceeded)
+	assert.True(t, stopped)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	m := New(20*time.Millisecond, zap.NewNop().Sugar())
+
+	// обработчик не реагирует на отмену
+	release := make(chan struct{})
+	defer close(release)
+	m.Go(func(context.Context) { <-release })
+
+	var closed bool
+	m.OnShutdown("workers", m.StopWorkers)
+	m.OnShutdown("postgres", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Shutdown()
+
+	assert.ErrorIs(t, err, ErrDeadlineExceeded)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// ресурсы освобождаются и после истечения срока
+	assert.True(t, closed)
+}
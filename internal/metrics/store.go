@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// taskStatuses - статусы, которые выводятся всегда, даже без задач
+var taskStatuses = []string{"new", "in_progress", "done"}
+
+// Source - источник метрик хранилища, реализуется репозиторием
+type Source interface {
+	Stat() *pgxpool.Stat
+	CountTasksByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// StoreCollector снимает состояние пула соединений и число задач по статусам в момент сбора метрик
+type StoreCollector struct {
+	source  Source
+	timeout time.Duration
+	log     *zap.SugaredLogger
+
+	acquiredConns    *prometheus.Desc
+	idleConns        *prometheus.Desc
+	totalConns       *prometheus.Desc
+	maxConns         *prometheus.Desc
+	acquireCount     *prometheus.Desc
+	acquireDuration  *prometheus.Desc
+	emptyAcquires    *prometheus.Desc
+	emptyAcquireWait *prometheus.Desc
+	tasks            *prometheus.Desc
+}
+
+// NewStoreCollector - timeout ограничивает запрос числа задач, чтобы медленная база не держала сбор метрик
+func NewStoreCollector(source Source, timeout time.Duration, logger *zap.SugaredLogger) *StoreCollector {
+	pool := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &StoreCollector{
+		source:  source,
+		timeout: timeout,
+		log:     logger,
+
+		acquiredConns:    pool("acquired_connections", "Connections currently in use."),
+		idleConns:        pool("idle_connections", "Idle connections in the pool."),
+		totalConns:       pool("total_connections", "All connections in the pool, including ones being established."),
+		maxConns:         pool("max_connections", "Maximum size of the pool."),
+		acquireCount:     pool("acquires_total", "Successful connection acquires."),
+		acquireDuration:  pool("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquires:    pool("empty_acquires_total", "Acquires that had to wait for a connection."),
+		emptyAcquireWait: pool("empty_acquire_wait_seconds_total", "Total time spent waiting for a connection when the pool was empty."),
+		tasks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "tasks"),
+			"Tasks by status.", []string{"status"}, nil),
+	}
+}
+
+func (c *StoreCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquires
+	ch <- c.emptyAcquireWait
+	ch <- c.tasks
+}
+
+func (c *StoreCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.source.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireWait, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	counts, err := c.source.CountTasksByStatus(ctx)
+	if err != nil {
+		// без числа задач остальные метрики всё равно отдаются
+		c.log.Error("Failed to count tasks by status", zap.Error(err))
+		return
+	}
+	for _, status := range taskStatuses {
+		ch <- prometheus.MustNewConstMetric(c.tasks, prometheus.GaugeValue, float64(counts[status]), status)
+	}
+}
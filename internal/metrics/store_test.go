@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeSource - пул без соединений (pgxpool подключается лениво) и заданное число задач
+type fakeSource struct {
+	pool   *pgxpool.Pool
+	counts map[string]int64
+	err    error
+}
+
+func (f fakeSource) Stat() *pgxpool.Stat {
+	return f.pool.Stat()
+}
+
+func (f fakeSource) CountTasksByStatus(context.Context) (map[string]int64, error) {
+	return f.counts, f.err
+}
+
+func newFakeSource(t *testing.T, counts map[string]int64, err error) fakeSource {
+	t.Helper()
+	pool, err2 := pgxpool.New(context.Background(), "host=127.0.0.1 port=1 pool_max_conns=4")
+	require.NoError(t, err2)
+	t.Cleanup(pool.Close)
+	return fakeSource{pool: pool, counts: counts, err: err}
+}
+
+func TestStoreCollector(t *testing.T) {
+	source := newFakeSource(t, map[string]int64{"new": 3, "done": 1}, nil)
+	collector := NewStoreCollector(source, time.Second, zap.NewNop().Sugar())
+
+	expected := `
+# HELP tasks_db_pool_max_connections Maximum size of the pool.
+# TYPE tasks_db_pool_max_connections gauge
+tasks_db_pool_max_connections 4
+# HELP tasks_tasks Tasks by status.
+# TYPE tasks_tasks gauge
+tasks_tasks{status="done"} 1
+tasks_tasks{status="in_progress"} 0
+tasks_tasks{status="new"} 3
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "tasks_db_pool_max_connections", "tasks_tasks")
+	assert.NoError(t, err)
+}
+
+func TestStoreCollectorCountFailed(t *testing.T) {
+	source := newFakeSource(t, nil, errors.New("db is down"))
+	collector := NewStoreCollector(source, time.Second, zap.NewNop().Sugar())
+
+	// метрики пула отдаются и без числа задач
+	assert.Equal(t, 8, testutil.CollectAndCount(collector))
+}
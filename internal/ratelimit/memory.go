@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memoryPurgeEvery = time.Minute
+
+// Memory хранит корзины в памяти процесса: у каждой реплики свои ограничения
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *Memory) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		m.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = b.refill(now)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return newResult(limit, b.tokens, false), nil
+	}
+	b.tokens--
+	return newResult(limit, b.tokens, true), nil
+}
+
+// Run удаляет заполненные корзины, пока ctx не отменён: они не отличаются от новых
+func (m *Memory) Run(ctx context.Context) {
+	ticker := time.NewTicker(memoryPurgeEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.purge()
+		}
+	}
+}
+
+func (m *Memory) purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key, b := range m.buckets {
+		if b.refill(now) >= float64(b.limit.Requests) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// refill - жетоны в корзине к моменту now
+func (b *bucket) refill(now time.Time) float64 {
+	return min(float64(b.limit.Requests), b.tokens+now.Sub(b.updated).Seconds()*b.limit.rate())
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const postgresPurgeEvery = 10 * time.Minute
+
+// Store - общие корзины в PostgreSQL, реализуется репозиторием
+type Store interface {
+	TakeRateLimitToken(ctx context.Context, key string, burst, rate float64) (float64, bool, error)
+	DeleteIdleRateLimits(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// Postgres хранит корзины в базе: ограничения общие для всех реплик сервиса
+type Postgres struct {
+	store Store
+	// idle - корзины, не тронутые дольше, заполнены и удаляются
+	idle time.Duration
+	log  *zap.SugaredLogger
+}
+
+func NewPostgres(store Store, idle time.Duration, logger *zap.SugaredLogger) *Postgres {
+	return &Postgres{store: store, idle: idle, log: logger}
+}
+
+func (p *Postgres) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	tokens, allowed, err := p.store.TakeRateLimitToken(ctx, key, float64(limit.Requests), limit.rate())
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, tokens, allowed), nil
+}
+
+// Run удаляет заполненные корзины, пока ctx не отменён
+func (p *Postgres) Run(ctx context.Context) {
+	ticker := time.NewTicker(postgresPurgeEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.store.DeleteIdleRateLimits(ctx, p.idle); err != nil {
+				p.log.Error("Failed to delete idle rate limits", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Пакет ограничения частоты запросов: корзина жетонов (token bucket) на каждого автора в каждой группе маршрутов.
+// Корзина вмещает Limit.Requests жетонов и равномерно пополняется за Limit.Period, запрос забирает один жетон.
+
+// Группы маршрутов с отдельными ограничениями
+const (
+	// GroupAPI - все запросы к API задач
+	GroupAPI = "api"
+	// GroupHeavy - импорт, выгрузка и пакетные операции, учитываются дополнительно к GroupAPI
+	GroupHeavy = "heavy"
+	// GroupLogin - выдача токенов, ограничивается по IP
+	GroupLogin = "login"
+)
+
+// Хранилища корзин
+const (
+	// BackendMemory - корзины в памяти, у каждой реплики свои
+	BackendMemory = "memory"
+	// BackendPostgres - корзины в базе, общие для всех реплик
+	BackendPostgres = "postgres"
+)
+
+// Limit - Requests запросов за Period, нулевой Limit - без ограничения
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit разбирает ограничение вида "600/1m"; пустая строка и "0" - без ограничения
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: expected requests/period, e.g. 600/1m", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: requests must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: period must be a positive duration", s)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// UnmarshalText позволяет задавать Limit в конфигурации строкой "600/1m"
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "0"
+	}
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// rate - пополнение корзины, жетонов в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Limits - ограничения по группам маршрутов
+type Limits map[string]Limit
+
+// MaxPeriod - самый длинный период: корзина, не тронутая дольше, заведомо полна
+func (l Limits) MaxPeriod() time.Duration {
+	var longest time.Duration
+	for _, limit := range l {
+		longest = max(longest, limit.Period)
+	}
+	return longest
+}
+
+// Result - состояние корзины после запроса
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining - сколько запросов ещё можно выполнить сразу
+	Remaining int
+	// RetryAfter - через сколько появится жетон, если запрос отклонён
+	RetryAfter time.Duration
+	// Reset - через сколько корзина заполнится полностью
+	Reset time.Duration
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Requests) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// Limiter хранит корзины жетонов
+type Limiter interface {
+	// Take забирает жетон из корзины key с ограничением limit
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Policy применяет ограничения групп маршрутов
+type Policy struct {
+	limiter Limiter
+	limits  Limits
+}
+
+func NewPolicy(limiter Limiter, limits Limits) *Policy {
+	return &Policy{limiter: limiter, limits: limits}
+}
+
+// Take учитывает запрос subject в группе group. Группа без ограничения пропускает запрос,
+// Result.Limit при этом нулевой
+func (p *Policy) Take(ctx context.Context, group, subject string) (Result, error) {
+	limit := p.limits[group]
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+	return p.limiter.Take(ctx, group+":"+subject, limit)
+}
+
+// Subject - чей запрос: авторизованный автор, а без авторизации - IP клиента
+func Subject(principal auth.Principal, ip string) string {
+	if principal.Admin || principal.UserID != "" {
+		return principal.Actor()
+	}
+	return "ip:" + ip
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"600/1m", Limit{Requests: 600, Period: time.Minute}, false},
+		{" 10/30s ", Limit{Requests: 10, Period: 30 * time.Second}, false},
+		{"0", Limit{}, false},
+		{"", Limit{}, false},
+		{"600", Limit{}, true},
+		{"-1/1m", Limit{}, true},
+		{"10/0s", Limit{}, true},
+		{"10/minute", Limit{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemoryTake(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Period: 10 * time.Second}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		result, err := m.Take(context.Background(), "k", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, _ := m.Take(context.Background(), "k", limit)
+	assert.False(t, result.Allowed)
+	// жетон пополняется за Period/Requests
+	assert.Equal(t, 5*time.Second, result.RetryAfter)
+	assert.Equal(t, 10*time.Second, result.Reset)
+
+	// корзины разных ключей независимы
+	result, _ = m.Take(context.Background(), "other", limit)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(5 * time.Second)
+	result, _ = m.Take(context.Background(), "k", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// заполненные корзины удаляются
+	now = now.Add(time.Minute)
+	m.purge()
+	assert.Empty(t, m.buckets)
+}
+
+func TestPolicy(t *testing.T) {
+	policy := NewPolicy(NewMemory(), Limits{GroupLogin: {Requests: 1, Period: time.Minute}})
+
+	result, err := policy.Take(context.Background(), GroupAPI, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.True(t, result.Limit.Unlimited())
+
+	first, _ := policy.Take(context.Background(), GroupLogin, "ip:10.0.0.1")
+	second, _ := policy.Take(context.Background(), GroupLogin, "ip:10.0.0.1")
+	assert.True(t, first.Allowed)
+	assert.False(t, second.Allowed)
+}
+
+func TestSubject(t *testing.T) {
+	assert.Equal(t, "user:7", Subject(auth.Principal{UserID: "7"}, "10.0.0.1"))
+	assert.Equal(t, auth.AdminActor, Subject(auth.Principal{Admin: true}, "10.0.0.1"))
+	assert.Equal(t, "ip:10.0.0.1", Subject(auth.Principal{}, "10.0.0.1"))
+}
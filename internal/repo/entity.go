@@ -1,25 +1,186 @@
 package repo
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // DataObject - шаблонная структура, для хранения
 type DataObject struct {
 	ID        string    `json:"id"`
 	Title     string    `json:"title"`
-	Data      string    `json:"data"`
+	Data      string    `json:"data" db:"description"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Version - растёт при каждом изменении, отдаётся клиенту как ETag
+	Version int `json:"version"`
 }
 
 type Task struct {
 	DataObject
-	UserID string `json:"user_id"`
+	UserID string     `json:"user_id"`
+	Tags   []string   `json:"tags"`
+	DueAt  *time.Time `json:"due_at,omitempty"`
+}
+
+// TaskFilter - фильтры списка задач, пустые поля не учитываются
+type TaskFilter struct {
+	Status      string
+	UserID      string
+	Tag         string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// WithDue оставляет только задачи со сроком
+	WithDue bool
+	DueFrom *time.Time
+	DueTo   *time.Time
+	Limit   int
+	Offset  int
+}
+
+const (
+	TaskOpCreate       = "create"
+	TaskOpUpdateStatus = "update_status"
+	TaskOpDelete       = "delete"
+	TaskOpTag          = "tag"
+)
+
+// TaskOperation - одна операция пакетного изменения задач
+type TaskOperation struct {
+	Op     string
+	Task   Task
+	ID     string
+	Status string
+	Tags   []string
+	// Owner - операция применяется только к задачам этого пользователя, пустой - к любым
+	Owner string
+	// Version - ожидаемая версия изменяемой задачи
+	Version int
+}
+
+// TaskOperationResult - результат операции: Task - задача после неё (созданная, изменённая, для удаления -
+// последнее состояние), Before - до изменения, nil для создания.
+// Err - dto.ErrNotFound, если задачи нет, dto.ErrVersionConflict, если версия устарела (Task - текущее состояние)
+type TaskOperationResult struct {
+	Task   *Task
+	Before *Task
+	Err    error
 }
 
 type User struct {
 	ID        string    `json:"id"`
-	Name      string    `json:"name"`
+	Name      string    `json:"name" db:"username"`
 	Password  string    `json:"password"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// AuditRecord - неизменяемая запись журнала аудита
+type AuditRecord struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Diff      json.RawMessage `json:"diff"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter - фильтры выборки журнала аудита, пустые поля не учитываются
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// OutboxEvent - событие, записанное в одной транзакции с изменением и ожидающее рассылки
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// TraceParent - W3C traceparent запроса, вызвавшего событие; доставка продолжает его трассировку
+	TraceParent string `json:"trace_parent"`
+}
+
+// IdempotencyRecord - первый ответ на запрос с ключом идемпотентности; StatusCode 0 - запрос ещё выполняется
+type IdempotencyRecord struct {
+	Actor       string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Response    []byte
+	// ContentType - Content-Type ответа, пустой у записей до его сохранения
+	ContentType string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Webhook - подписка внешней системы на события
+type Webhook struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery - состояние доставки одного события одной подписке
+type WebhookDelivery struct {
+	ID            int64     `json:"id"`
+	WebhookID     string    `json:"webhook_id"`
+	EventID       int64     `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	ResponseCode  int       `json:"response_code"`
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// DeliveryJob - доставка, взятая в работу, вместе со всем нужным для отправки
+type DeliveryJob struct {
+	ID          int64
+	Attempts    int
+	URL         string
+	Secret      string
+	EventID     int64
+	EventType   string
+	Payload     json.RawMessage
+	OccurredAt  time.Time
+	TraceParent string
+}
+
+// DeliveryResult - итог попытки доставки
+type DeliveryResult struct {
+	ID            int64
+	Status        string
+	ResponseCode  int
+	Error         string
+	NextAttemptAt time.Time
+}
+
+// TaskEventsChannel - канал LISTEN/NOTIFY, по которому экземпляры сервиса узнают об изменениях задач
+const TaskEventsChannel = "task_events"
+
+// TaskEvent - уведомление об изменении задачи
+type TaskEvent struct {
+	Type   string          `json:"type"`
+	TaskID string          `json:"task_id"`
+	UserID string          `json:"user_id"`
+	Task   json.RawMessage `json:"task,omitempty"`
+}
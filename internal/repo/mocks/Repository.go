@@ -1,4 +1,4 @@
-// Code generated by mockery v2.53.4. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -7,6 +7,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -14,35 +16,307 @@ type Repository struct {
 	mock.Mock
 }
 
+// ApplyTaskOperations provides a mock function with given fields: ctx, ops
+func (_m *Repository) ApplyTaskOperations(ctx context.Context, ops []repo.TaskOperation) ([]repo.TaskOperationResult, error) {
+	ret := _m.Called(ctx, ops)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyTaskOperations")
+	}
+
+	var r0 []repo.TaskOperationResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.TaskOperation) ([]repo.TaskOperationResult, error)); ok {
+		return rf(ctx, ops)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.TaskOperation) []repo.TaskOperationResult); ok {
+		r0 = rf(ctx, ops)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.TaskOperationResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []repo.TaskOperation) error); ok {
+		r1 = rf(ctx, ops)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimWebhookDeliveries provides a mock function with given fields: ctx, limit, lease
+func (_m *Repository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]repo.DeliveryJob, error) {
+	ret := _m.Called(ctx, limit, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimWebhookDeliveries")
+	}
+
+	var r0 []repo.DeliveryJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) ([]repo.DeliveryJob, error)); ok {
+		return rf(ctx, limit, lease)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) []repo.DeliveryJob); ok {
+		r0 = rf(ctx, limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.DeliveryJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = rf(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteIdempotencyKey provides a mock function with given fields: ctx, actor, key, statusCode, contentType, response
+func (_m *Repository) CompleteIdempotencyKey(ctx context.Context, actor string, key string, statusCode int, contentType string, response []byte) error {
+	ret := _m.Called(ctx, actor, key, statusCode, contentType, response)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteIdempotencyKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, string, []byte) error); ok {
+		r0 = rf(ctx, actor, key, statusCode, contentType, response)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CopyTasks provides a mock function with given fields: ctx, tasks
+func (_m *Repository) CopyTasks(ctx context.Context, tasks []repo.Task) ([]repo.Task, error) {
+	ret := _m.Called(ctx, tasks)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CopyTasks")
+	}
+
+	var r0 []repo.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.Task) ([]repo.Task, error)); ok {
+		return rf(ctx, tasks)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.Task) []repo.Task); ok {
+		r0 = rf(ctx, tasks)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []repo.Task) error); ok {
+		r1 = rf(ctx, tasks)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAuditRecords provides a mock function with given fields: ctx, records
+func (_m *Repository) CreateAuditRecords(ctx context.Context, records []repo.AuditRecord) error {
+	ret := _m.Called(ctx, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAuditRecords")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.AuditRecord) error); ok {
+		r0 = rf(ctx, records)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOutboxEvents provides a mock function with given fields: ctx, events
+func (_m *Repository) CreateOutboxEvents(ctx context.Context, events []repo.OutboxEvent) error {
+	ret := _m.Called(ctx, events)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOutboxEvents")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.OutboxEvent) error); ok {
+		r0 = rf(ctx, events)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateTask provides a mock function with given fields: ctx, task
-func (_m *Repository) CreateTask(ctx context.Context, task repo.Task) error {
+func (_m *Repository) CreateTask(ctx context.Context, task repo.Task) (*repo.Task, error) {
 	ret := _m.Called(ctx, task)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateTask")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, repo.Task) error); ok {
+	var r0 *repo.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.Task) (*repo.Task, error)); ok {
+		return rf(ctx, task)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, repo.Task) *repo.Task); ok {
 		r0 = rf(ctx, task)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Task)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, repo.Task) error); ok {
+		r1 = rf(ctx, task)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // CreateUser provides a mock function with given fields: ctx, user
-func (_m *Repository) CreateUser(ctx context.Context, user repo.User) error {
+func (_m *Repository) CreateUser(ctx context.Context, user repo.User) (*repo.User, error) {
 	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateUser")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, repo.User) error); ok {
+	var r0 *repo.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.User) (*repo.User, error)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, repo.User) *repo.User); ok {
 		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, repo.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, webhook
+func (_m *Repository) CreateWebhook(ctx context.Context, webhook repo.Webhook) (*repo.Webhook, error) {
+	ret := _m.Called(ctx, webhook)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 *repo.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.Webhook) (*repo.Webhook, error)); ok {
+		return rf(ctx, webhook)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, repo.Webhook) *repo.Webhook); ok {
+		r0 = rf(ctx, webhook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, repo.Webhook) error); ok {
+		r1 = rf(ctx, webhook)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpiredIdempotencyKeys provides a mock function with given fields: ctx
+func (_m *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredIdempotencyKeys")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteIdleRateLimits provides a mock function with given fields: ctx, idle
+func (_m *Repository) DeleteIdleRateLimits(ctx context.Context, idle time.Duration) (int64, error) {
+	ret := _m.Called(ctx, idle)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteIdleRateLimits")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) (int64, error)); ok {
+		return rf(ctx, idle)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = rf(ctx, idle)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, idle)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteTaskByID provides a mock function with given fields: ctx, id, version
+func (_m *Repository) DeleteTaskByID(ctx context.Context, id string, version int) error {
+	ret := _m.Called(ctx, id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTaskByID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -50,12 +324,12 @@ func (_m *Repository) CreateUser(ctx context.Context, user repo.User) error {
 	return r0
 }
 
-// DeleteTaskByID provides a mock function with given fields: ctx, id
-func (_m *Repository) DeleteTaskByID(ctx context.Context, id string) error {
+// DeleteUserByID provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteUserByID(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteTaskByID")
+		panic("no return value specified for DeleteUserByID")
 	}
 
 	var r0 error
@@ -68,9 +342,73 @@ func (_m *Repository) DeleteTaskByID(ctx context.Context, id string) error {
 	return r0
 }
 
-// GetAllTasks provides a mock function with given fields: ctx
-func (_m *Repository) GetAllTasks(ctx context.Context) ([]repo.Task, error) {
-	ret := _m.Called(ctx)
+// DeleteWebhookByID provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteWebhookByID(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhookByID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FanOutOutboxEvents provides a mock function with given fields: ctx, limit
+func (_m *Repository) FanOutOutboxEvents(ctx context.Context, limit int) (int64, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FanOutOutboxEvents")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int64, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int64); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForEachTask provides a mock function with given fields: ctx, filter, fn
+func (_m *Repository) ForEachTask(ctx context.Context, filter repo.TaskFilter, fn func(repo.Task) error) error {
+	ret := _m.Called(ctx, filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForEachTask")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.TaskFilter, func(repo.Task) error) error); ok {
+		r0 = rf(ctx, filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllTasks provides a mock function with given fields: ctx, filter
+func (_m *Repository) GetAllTasks(ctx context.Context, filter repo.TaskFilter) ([]repo.Task, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAllTasks")
@@ -78,19 +416,19 @@ func (_m *Repository) GetAllTasks(ctx context.Context) ([]repo.Task, error) {
 
 	var r0 []repo.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]repo.Task, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, repo.TaskFilter) ([]repo.Task, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []repo.Task); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, repo.TaskFilter) []repo.Task); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]repo.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, repo.TaskFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -128,6 +466,66 @@ func (_m *Repository) GetAllTasksByUserID(ctx context.Context, id string) ([]rep
 	return r0, r1
 }
 
+// GetAuditRecords provides a mock function with given fields: ctx, filter
+func (_m *Repository) GetAuditRecords(ctx context.Context, filter repo.AuditFilter) ([]repo.AuditRecord, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuditRecords")
+	}
+
+	var r0 []repo.AuditRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.AuditFilter) ([]repo.AuditRecord, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, repo.AuditFilter) []repo.AuditRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.AuditRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, repo.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExistingUserIDs provides a mock function with given fields: ctx, ids
+func (_m *Repository) GetExistingUserIDs(ctx context.Context, ids []string) ([]string, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetExistingUserIDs")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLastTaskByUserID provides a mock function with given fields: ctx, id
 func (_m *Repository) GetLastTaskByUserID(ctx context.Context, id string) (*repo.Task, error) {
 	ret := _m.Called(ctx, id)
@@ -188,6 +586,36 @@ func (_m *Repository) GetTaskByID(ctx context.Context, id string) (*repo.Task, e
 	return r0, r1
 }
 
+// GetTasksByUserIDs provides a mock function with given fields: ctx, ids
+func (_m *Repository) GetTasksByUserIDs(ctx context.Context, ids []string) ([]repo.Task, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTasksByUserIDs")
+	}
+
+	var r0 []repo.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]repo.Task, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []repo.Task); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTasksByUserName provides a mock function with given fields: ctx, name
 func (_m *Repository) GetTasksByUserName(ctx context.Context, name string) ([]repo.Task, error) {
 	ret := _m.Called(ctx, name)
@@ -218,17 +646,341 @@ func (_m *Repository) GetTasksByUserName(ctx context.Context, name string) ([]re
 	return r0, r1
 }
 
-// UpdateStatusByID provides a mock function with given fields: ctx, id, status
-func (_m *Repository) UpdateStatusByID(ctx context.Context, id string, status string) error {
-	ret := _m.Called(ctx, id, status)
+// GetUserByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetUserByID(ctx context.Context, id string) (*repo.User, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateStatusByID")
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 *repo.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*repo.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *repo.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByName provides a mock function with given fields: ctx, name
+func (_m *Repository) GetUserByName(ctx context.Context, name string) (*repo.User, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByName")
+	}
+
+	var r0 *repo.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*repo.User, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *repo.User); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUsers provides a mock function with given fields: ctx
+func (_m *Repository) GetUsers(ctx context.Context) ([]repo.User, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsers")
+	}
+
+	var r0 []repo.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]repo.User, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []repo.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUsersByIDs provides a mock function with given fields: ctx, ids
+func (_m *Repository) GetUsersByIDs(ctx context.Context, ids []string) ([]repo.User, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsersByIDs")
+	}
+
+	var r0 []repo.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]repo.User, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []repo.User); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDeliveries provides a mock function with given fields: ctx, webhookID, limit
+func (_m *Repository) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]repo.WebhookDelivery, error) {
+	ret := _m.Called(ctx, webhookID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDeliveries")
+	}
+
+	var r0 []repo.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]repo.WebhookDelivery, error)); ok {
+		return rf(ctx, webhookID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []repo.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, webhookID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhooks provides a mock function with given fields: ctx
+func (_m *Repository) GetWebhooks(ctx context.Context) ([]repo.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhooks")
+	}
+
+	var r0 []repo.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]repo.Webhook, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []repo.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repo.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InTx provides a mock function with given fields: ctx, fn
+func (_m *Repository) InTx(ctx context.Context, fn func(repo.Repository) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InTx")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(repo.Repository) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Notify provides a mock function with given fields: ctx, channel, payloads
+func (_m *Repository) Notify(ctx context.Context, channel string, payloads ...[]byte) error {
+	_va := make([]interface{}, len(payloads))
+	for _i := range payloads {
+		_va[_i] = payloads[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, channel)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Notify")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...[]byte) error); ok {
+		r0 = rf(ctx, channel, payloads...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseIdempotencyKey provides a mock function with given fields: ctx, actor, key
+func (_m *Repository) ReleaseIdempotencyKey(ctx context.Context, actor string, key string) error {
+	ret := _m.Called(ctx, actor, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseIdempotencyKey")
 	}
 
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, id, status)
+		r0 = rf(ctx, actor, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReserveIdempotencyKey provides a mock function with given fields: ctx, actor, key, requestHash, ttl
+func (_m *Repository) ReserveIdempotencyKey(ctx context.Context, actor string, key string, requestHash string, ttl time.Duration) (*repo.IdempotencyRecord, error) {
+	ret := _m.Called(ctx, actor, key, requestHash, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveIdempotencyKey")
+	}
+
+	var r0 *repo.IdempotencyRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Duration) (*repo.IdempotencyRecord, error)); ok {
+		return rf(ctx, actor, key, requestHash, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Duration) *repo.IdempotencyRecord); ok {
+		r0 = rf(ctx, actor, key, requestHash, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repo.IdempotencyRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, time.Duration) error); ok {
+		r1 = rf(ctx, actor, key, requestHash, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeRateLimitToken provides a mock function with given fields: ctx, key, burst, rate
+func (_m *Repository) TakeRateLimitToken(ctx context.Context, key string, burst float64, rate float64) (float64, bool, error) {
+	ret := _m.Called(ctx, key, burst, rate)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeRateLimitToken")
+	}
+
+	var r0 float64
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, float64, float64) (float64, bool, error)); ok {
+		return rf(ctx, key, burst, rate)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, float64, float64) float64); ok {
+		r0 = rf(ctx, key, burst, rate)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, float64, float64) bool); ok {
+		r1 = rf(ctx, key, burst, rate)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, float64, float64) error); ok {
+		r2 = rf(ctx, key, burst, rate)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdateStatusByID provides a mock function with given fields: ctx, id, status, version
+func (_m *Repository) UpdateStatusByID(ctx context.Context, id string, status string, version int) error {
+	ret := _m.Called(ctx, id, status, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateStatusByID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) error); ok {
+		r0 = rf(ctx, id, status, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebhookDelivery provides a mock function with given fields: ctx, result
+func (_m *Repository) UpdateWebhookDelivery(ctx context.Context, result repo.DeliveryResult) error {
+	ret := _m.Called(ctx, result)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhookDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.DeliveryResult) error); ok {
+		r0 = rf(ctx, result)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1,39 +1,171 @@
 package repo
 
 const (
-	InitQuery = `CREATE TABLE IF NOT EXISTS users (
-						id SERIAL PRIMARY KEY,
-						username TEXT UNIQUE NOT NULL,
-						password TEXT NOT NULL, -- для упрощения, в продакшн разработке пароли в открытом виде не хранятся, хранится хеш или зашифрованные паро
-						created_at TIMESTAMP DEFAULT now()
-				);
-
-				CREATE TABLE IF NOT EXISTS tasks (
-						id SERIAL PRIMARY KEY,
-						user_id INT REFERENCES users(id) ON DELETE CASCADE,
-						title TEXT NOT NULL,
-						description TEXT DEFAULT 'new',
-						status TEXT CHECK (status IN ('new', 'in_progress', 'done')) DEFAULT 'new',
-						created_at TIMESTAMP DEFAULT now()
-				);
-
-				CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks(user_id);
-`
-
-	GetAllTasksQuery           = `SELECT id, user_id, title, description, status, created_at FROM tasks;`
-	GetTaskByIdQuery           = `SELECT id, user_id, title, description, status, created_at FROM tasks WHERE id = $1;`
-	GetAllTasksByUserIdQuery   = `SELECT id, user_id, title, description, status, created_at FROM tasks WHERE user_id = $1;`
-	GetLastTaskByUserIdQuery   = `SELECT id, user_id, title, description, status, created_at FROM tasks WHERE user_id = $1 limit 1;`
-	GetAllTasksByUserNameQuery = `SELECT t.* FROM user AS u LEFT JOIN tasks AS t ON t.user_id = u.id WHERE u.username = $1;`
-
-	CreateTaskQuery = `INSERT INTO tasks (user_id, title, description) SELECT $1, $2, $3 
-					   WHERE EXISTS (SELECT 1 FROM users WHERE id = $1);`
-
-	UpdateTaskStatusByIDQuery = `UPDATE tasks SET status = $1 WHERE id = $2;`
-
-	DeleteTaskByIdQuery = `DELETE FROM tasks WHERE id = $1;`
-
-	CreateUserQuery    = `INSERT INTO users (username, password) VALUES ($1, $2);`
-	GetUserByIdQuery   = `SELECT id, username, password, created_at FROM users WHERE id = $1;`
-	GetUserByNameQuery = `SELECT id, username, password, created_at FROM users WHERE username = $1;`
+	// таблица версий схемы в формате golang-migrate
+	CreateSchemaMigrationsQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);`
+	// миграции применяет один экземпляр сервиса, остальные ждут конца его транзакции
+	LockSchemaMigrationsQuery = `SELECT pg_advisory_xact_lock(hashtext('schema_migrations'));`
+	// таблицы задач есть, а версии схемы нет: базу создала версия сервиса без миграций
+	UnversionedSchemaQuery = `SELECT to_regclass('tasks') IS NOT NULL;`
+
+	// golang-migrate хранит одну строку - последнюю применённую миграцию, более старая версия заменяется на $1
+	SetSchemaVersionQuery = `WITH outdated AS (DELETE FROM schema_migrations WHERE version < $1)
+							 INSERT INTO schema_migrations (version, dirty)
+							 SELECT $1, false WHERE NOT EXISTS (SELECT 1 FROM schema_migrations WHERE version >= $1);`
+	GetSchemaVersionQuery = `SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1;`
+
+	// пустые фильтры не учитываются, нулевой limit означает без ограничения
+	GetAllTasksQuery = `SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks
+						WHERE ($1::text = '' OR status = $1) AND ($2::text = '' OR user_id::text = $2)
+						  AND ($3::text = '' OR $3 = ANY(tags))
+						  AND ($4::timestamp IS NULL OR created_at >= $4) AND ($5::timestamp IS NULL OR created_at < $5)
+						  AND (NOT $8::bool OR due_at IS NOT NULL)
+						  AND ($9::timestamptz IS NULL OR due_at >= $9) AND ($10::timestamptz IS NULL OR due_at < $10)
+						ORDER BY id LIMIT NULLIF($6::int, 0) OFFSET $7;`
+	CountTasksByStatusQuery    = `SELECT status, count(*) FROM tasks GROUP BY status;`
+	GetTaskByIdQuery           = `SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks WHERE id = $1;`
+	GetAllTasksByUserIdQuery   = `SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks WHERE user_id = $1;`
+	GetLastTaskByUserIdQuery   = `SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks WHERE user_id = $1 limit 1;`
+	GetAllTasksByUserNameQuery = `SELECT t.id, t.user_id, t.title, t.description, t.status, t.created_at, t.tags, t.due_at, t.updated_at, t.version 
+								  FROM users AS u JOIN tasks AS t ON t.user_id = u.id WHERE u.username = $1;`
+
+	// задачи нескольких пользователей для пакетной загрузки связей в GraphQL
+	GetTasksByUserIdsQuery = `SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks 
+							  WHERE user_id::text = ANY($1::text[]) ORDER BY id;`
+
+	CreateTaskQuery = `INSERT INTO tasks (user_id, title, description, due_at) SELECT $1, $2, $3, $4 
+					   WHERE EXISTS (SELECT 1 FROM users WHERE id = $1)
+					   RETURNING id, user_id, title, description, status, created_at, tags, due_at, updated_at, version;`
+
+	// версия 0 - изменение без проверки версии
+	UpdateTaskStatusByIDQuery = `UPDATE tasks SET status = $2, version = version + 1, updated_at = now()
+								 WHERE id = $1 AND ($3::int = 0 OR version = $3);`
+
+	DeleteTaskByIdQuery = `DELETE FROM tasks WHERE id = $1 AND ($2::int = 0 OR version = $2);`
+	// запросы пакетных операций возвращают строку "before" - задачу до изменения и, если операция применена, "after" - после него.
+	// запросы пакетных операций возвращают состояние задачи до изменения и applied - применена ли операция.
+	// Задача чужого автора ($3 - id владельца, пустой для администратора) не находится, изменение с версией,
+	// отличной от $4, не применяется
+	UpdateTaskStatusReturningQuery = `WITH before AS (
+										  SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks 
+										  WHERE id = $1 AND ($3::text = '' OR user_id::text = $3) FOR UPDATE
+									  ), updated AS (
+										  UPDATE tasks AS t SET status = $2, version = t.version + 1, updated_at = now() 
+										  FROM before WHERE t.id = before.id AND before.version = $4
+										  RETURNING t.id, t.user_id, t.title, t.description, t.status, t.created_at, t.tags, t.due_at, 
+													t.updated_at, t.version
+									  )
+									  SELECT 'before' AS state, before.* FROM before 
+									  UNION ALL SELECT 'after', updated.* FROM updated;`
+	AddTaskTagsReturningQuery = `WITH before AS (
+									 SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks 
+									 WHERE id = $1 AND ($3::text = '' OR user_id::text = $3) FOR UPDATE
+								 ), updated AS (
+									 UPDATE tasks AS t SET tags = ARRAY(SELECT DISTINCT unnest(t.tags || $2::text[]) ORDER BY 1), 
+										 version = t.version + 1, updated_at = now()
+									 FROM before WHERE t.id = before.id AND before.version = $4
+									 RETURNING t.id, t.user_id, t.title, t.description, t.status, t.created_at, t.tags, t.due_at, 
+											   t.updated_at, t.version
+								 )
+								 SELECT 'before' AS state, before.* FROM before 
+								 UNION ALL SELECT 'after', updated.* FROM updated;`
+	DeleteTaskReturningQuery = `WITH before AS (
+									SELECT id, user_id, title, description, status, created_at, tags, due_at, updated_at, version FROM tasks 
+									WHERE id = $1 AND ($2::text = '' OR user_id::text = $2) FOR UPDATE
+								), deleted AS (
+									DELETE FROM tasks AS t USING before WHERE t.id = before.id AND before.version = $3
+									RETURNING t.id
+								)
+								SELECT 'before' AS state, before.* FROM before 
+								UNION ALL SELECT 'after', before.* FROM before WHERE EXISTS (SELECT 1 FROM deleted);`
+
+	// импорт: строки загружаются COPY во временную таблицу, откуда одним запросом переносятся в tasks
+	CreateTasksImportTableQuery = `CREATE TEMP TABLE IF NOT EXISTS tasks_import (
+									   user_id INT, title TEXT, description TEXT, status TEXT, tags TEXT[], due_at TIMESTAMPTZ
+								   ) ON COMMIT DROP;`
+	MoveTasksImportQuery = `INSERT INTO tasks (user_id, title, description, status, tags, due_at)
+							SELECT user_id, title, description, COALESCE(NULLIF(status, ''), 'new'), COALESCE(tags, '{}'), 
+								   due_at
+							FROM tasks_import
+							RETURNING id, user_id, title, description, status, created_at, tags, due_at, updated_at, version;`
+	TruncateTasksImportQuery = `TRUNCATE tasks_import;`
+
+	CreateUserQuery         = `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id, username, password, created_at;`
+	GetUserByIdQuery        = `SELECT id, username, password, created_at FROM users WHERE id = $1;`
+	GetUserByNameQuery      = `SELECT id, username, password, created_at FROM users WHERE username = $1;`
+	GetExistingUserIdsQuery = `SELECT id::text FROM users WHERE id::text = ANY($1::text[]);`
+	GetUsersQuery           = `SELECT id, username, password, created_at FROM users ORDER BY id;`
+	GetUsersByIdsQuery      = `SELECT id, username, password, created_at FROM users WHERE id::text = ANY($1::text[]);`
+	DeleteUserByIdQuery     = `DELETE FROM users WHERE id = $1;`
+
+	GetAuditRecordsQuery = `SELECT id, actor, action, entity, entity_id, diff, request_id, created_at FROM audit_log
+							WHERE ($1::text = '' OR actor = $1) AND ($2::text = '' OR action = $2)
+							  AND ($3::text = '' OR entity = $3) AND ($4::text = '' OR entity_id = $4)
+							  AND ($5::timestamp IS NULL OR created_at >= $5) AND ($6::timestamp IS NULL OR created_at < $6)
+							ORDER BY id DESC LIMIT $7 OFFSET $8;`
+
+	// раскладывает новые события по подпискам и помечает их разобранными одним запросом
+	FanOutOutboxEventsQuery = `WITH events AS (
+								   SELECT id, event_type FROM outbox_events WHERE dispatched_at IS NULL
+								   ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+							   ), deliveries AS (
+								   INSERT INTO webhook_deliveries (webhook_id, event_id)
+								   SELECT w.id, e.id FROM events AS e JOIN webhooks AS w ON e.event_type = ANY(w.event_types)
+							   )
+							   UPDATE outbox_events SET dispatched_at = now() WHERE id IN (SELECT id FROM events);`
+
+	CreateWebhookQuery = `INSERT INTO webhooks (url, event_types, secret) VALUES ($1, $2, $3) 
+							   RETURNING id, url, event_types, secret, created_at;`
+	GetWebhooksQuery          = `SELECT id, url, event_types, secret, created_at FROM webhooks ORDER BY id;`
+	DeleteWebhookByIdQuery    = `DELETE FROM webhooks WHERE id = $1;`
+	GetWebhookDeliveriesQuery = `SELECT d.id, d.webhook_id, d.event_id, e.event_type, d.status, d.attempts, d.response_code, 
+									   d.last_error, d.next_attempt_at, d.created_at, d.updated_at
+								FROM webhook_deliveries AS d JOIN outbox_events AS e ON e.id = d.event_id
+								WHERE d.webhook_id = $1 ORDER BY d.id DESC LIMIT $2;`
+	// забирает готовые к отправке доставки, сдвигая next_attempt_at на время аренды,
+	// чтобы их не взял другой экземпляр сервиса
+	ClaimWebhookDeliveriesQuery = `WITH claimed AS (
+									   UPDATE webhook_deliveries SET attempts = attempts + 1, 
+											  next_attempt_at = now() + $2 * interval '1 second'
+									   WHERE id IN (
+										   SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= now()
+										   ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED
+									   )
+									   RETURNING id, webhook_id, event_id, attempts
+								   )
+								   SELECT c.id, c.attempts, w.url, w.secret, e.id AS event_id, e.event_type, e.payload, 
+										  e.created_at AS occurred_at, e.trace_parent
+								   FROM claimed AS c 
+								   JOIN webhooks AS w ON w.id = c.webhook_id 
+								   JOIN outbox_events AS e ON e.id = c.event_id;`
+	UpdateWebhookDeliveryQuery = `UPDATE webhook_deliveries SET status = $2, response_code = $3, last_error = $4, 
+								  next_attempt_at = $5, updated_at = now() WHERE id = $1;`
+
+	// занимает ключ; истёкший ключ занимается заново, действующий не меняется и запрос не возвращает строк
+	ReserveIdempotencyKeyQuery = `INSERT INTO idempotency_keys (actor, key, request_hash, expires_at) 
+								  VALUES ($1, $2, $3, now() + $4 * interval '1 second')
+								  ON CONFLICT (actor, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, 
+									  status_code = 0, response = NULL, content_type = '', created_at = now(), 
+									  expires_at = EXCLUDED.expires_at
+								  WHERE idempotency_keys.expires_at <= now()
+								  RETURNING actor;`
+	GetIdempotencyKeyQuery = `SELECT actor, key, request_hash, status_code, response, content_type, created_at, expires_at 
+							  FROM idempotency_keys WHERE actor = $1 AND key = $2;`
+	CompleteIdempotencyKeyQuery = `UPDATE idempotency_keys SET status_code = $3, response = $4, content_type = $5 
+								   WHERE actor = $1 AND key = $2;`
+	ReleaseIdempotencyKeyQuery       = `DELETE FROM idempotency_keys WHERE actor = $1 AND key = $2 AND status_code = 0;`
+	DeleteExpiredIdempotencyKeyQuery = `DELETE FROM idempotency_keys WHERE expires_at <= now();`
+
+	// забирает жетон из корзины $1 ёмкостью $2, пополняемой на $3 жетонов в секунду;
+	// если жетона нет, корзина не меняется и запрос не возвращает строк
+	TakeRateLimitTokenQuery = `INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2::float8 - 1, now())
+							   ON CONFLICT (key) DO UPDATE SET updated_at = now(),
+								   tokens = LEAST($2::float8, rate_limits.tokens + EXTRACT(EPOCH FROM now() - rate_limits.updated_at)::float8 * $3::float8) - 1
+							   WHERE LEAST($2::float8, rate_limits.tokens + EXTRACT(EPOCH FROM now() - rate_limits.updated_at)::float8 * $3::float8) >= 1
+							   RETURNING tokens;`
+	GetRateLimitTokensQuery = `SELECT LEAST($2::float8, tokens + EXTRACT(EPOCH FROM now() - updated_at)::float8 * $3::float8)
+							   FROM rate_limits WHERE key = $1;`
+	DeleteIdleRateLimitsQuery = `DELETE FROM rate_limits WHERE updated_at < now() - $1 * interval '1 second';`
+
+	NotifyQuery = `SELECT pg_notify($1, payload) FROM unnest($2::text[]) AS payload;`
 )
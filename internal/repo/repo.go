@@ -3,28 +3,98 @@ package repo
 import (
 	"TemplatestPGSQL/internal/config"
 	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/tracing"
+	"TemplatestPGSQL/migrations"
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
+	"strconv"
+	"time"
 )
 
 type Repository interface {
-	CreateTask(ctx context.Context, task Task) error
-	GetAllTasks(ctx context.Context) ([]Task, error)
+	// InTx выполняет fn в одной транзакции, переданный fn репозиторий работает внутри неё
+	InTx(ctx context.Context, fn func(r Repository) error) error
+
+	CreateTask(ctx context.Context, task Task) (*Task, error)
+	GetAllTasks(ctx context.Context, filter TaskFilter) ([]Task, error)
+	// ForEachTask читает задачи по одной, не загружая выборку в память
+	ForEachTask(ctx context.Context, filter TaskFilter, fn func(task Task) error) error
 	GetTaskByID(ctx context.Context, id string) (*Task, error)
 	GetLastTaskByUserID(ctx context.Context, id string) (*Task, error)
 	GetTasksByUserName(ctx context.Context, name string) ([]Task, error)
 	GetAllTasksByUserID(ctx context.Context, id string) ([]Task, error)
-	UpdateStatusByID(ctx context.Context, id string, status string) error
-	DeleteTaskByID(ctx context.Context, id string) error
+	// GetTasksByUserIDs возвращает задачи нескольких пользователей одним запросом, упорядоченные по id
+	GetTasksByUserIDs(ctx context.Context, ids []string) ([]Task, error)
+	UpdateStatusByID(ctx context.Context, id string, status string, version int) error
+	DeleteTaskByID(ctx context.Context, id string, version int) error
+
+	CreateUser(ctx context.Context, user User) (*User, error)
+	GetUserByName(ctx context.Context, name string) (*User, error)
+	GetUserByID(ctx context.Context, id string) (*User, error)
+	GetUsers(ctx context.Context) ([]User, error)
+	// GetUsersByIDs возвращает найденных пользователей из ids, отсутствующие пропускаются
+	GetUsersByIDs(ctx context.Context, ids []string) ([]User, error)
+	// DeleteUserByID удаляет пользователя вместе с его задачами
+	DeleteUserByID(ctx context.Context, id string) error
+	GetExistingUserIDs(ctx context.Context, ids []string) ([]string, error)
+
+	// ApplyTaskOperations выполняет операции одним пакетом; ненайденные задачи дают dto.ErrNotFound в результате операции
+	ApplyTaskOperations(ctx context.Context, ops []TaskOperation) ([]TaskOperationResult, error)
+	// CopyTasks загружает задачи через COPY и возвращает созданные
+	CopyTasks(ctx context.Context, tasks []Task) ([]Task, error)
+
+	CreateAuditRecords(ctx context.Context, records []AuditRecord) error
+	GetAuditRecords(ctx context.Context, filter AuditFilter) ([]AuditRecord, error)
+
+	CreateOutboxEvents(ctx context.Context, events []OutboxEvent) error
+	FanOutOutboxEvents(ctx context.Context, limit int) (int64, error)
+
+	CreateWebhook(ctx context.Context, webhook Webhook) (*Webhook, error)
+	GetWebhooks(ctx context.Context) ([]Webhook, error)
+	DeleteWebhookByID(ctx context.Context, id string) error
+	GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DeliveryJob, error)
+	UpdateWebhookDelivery(ctx context.Context, result DeliveryResult) error
+
+	// ReserveIdempotencyKey занимает ключ actor+key на ttl. Если ключ уже занят и не истёк,
+	// возвращает его запись и ничего не меняет
+	ReserveIdempotencyKey(ctx context.Context, actor, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error)
+	// CompleteIdempotencyKey сохраняет ответ на запрос с занятым ключом вместе с его Content-Type
+	CompleteIdempotencyKey(ctx context.Context, actor, key string, statusCode int, contentType string, response []byte) error
+	// ReleaseIdempotencyKey освобождает ключ незавершённого запроса, чтобы его можно было повторить
+	ReleaseIdempotencyKey(ctx context.Context, actor, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+
+	// TakeRateLimitToken забирает жетон из корзины key ёмкостью burst, пополняемой на rate жетонов в секунду.
+	// Возвращает оставшиеся жетоны и false, если жетона не хватило
+	TakeRateLimitToken(ctx context.Context, key string, burst, rate float64) (float64, bool, error)
+	// DeleteIdleRateLimits удаляет корзины, не тронутые дольше idle
+	DeleteIdleRateLimits(ctx context.Context, idle time.Duration) (int64, error)
+
+	// Notify отправляет уведомления в канал, внутри транзакции они уходят только после COMMIT
+	Notify(ctx context.Context, channel string, payloads ...[]byte) error
+}
 
-	CreateUser(ctx context.Context, user User) error
+// dbtx - общее подмножество методов pgxpool.Pool и pgx.Tx
+type dbtx interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
+// cancelDeadlineDelay - сколько ждать ответа на CancelRequest, прежде чем закрыть соединение
+const cancelDeadlineDelay = time.Second
+
 type repository struct {
 	pool *pgxpool.Pool
+	db   dbtx
 }
 
 func NewRepository(ctx context.Context, cfg config.Memory) (*repository, error) {
@@ -48,25 +118,109 @@ func NewRepository(ctx context.Context, cfg config.Memory) (*repository, error)
 	}
 
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheDescribe
+	config.ConnConfig.Tracer = newQueryTracer(tracing.Tracer())
+	// по истечении срока запроса PostgreSQL получает CancelRequest и прерывает выполнение запроса у себя,
+	// а соединение закрывается, только если сервер не ответил за cancelDeadlineDelay
+	config.ConnConfig.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelDeadlineDelay}
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create DB connection pool")
 	}
 
-	return &repository{pool}, nil
+	return &repository{pool: pool, db: pool}, nil
 }
 
+func (r *repository) InTx(ctx context.Context, fn func(r Repository) error) error {
+	// внутри уже открытой транзакции pgx создаёт точку сохранения
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return fn(&repository{pool: r.pool, db: tx})
+	})
+}
+
+// InitTables применяет миграции migrations/postgres новее версии из schema_migrations одной транзакцией:
+// при ошибке схема остаётся прежней. Экземпляры, запущенные одновременно, применяют их по очереди
 func (r *repository) InitTables(ctx context.Context) error {
-	_, err := r.pool.Exec(ctx, InitQuery)
-	if err != nil {
-		return errors.Wrap(err, "failed to initialise tables")
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, LockSchemaMigrationsQuery); err != nil {
+			return errors.Wrap(err, "failed to lock schema migrations")
+		}
+		if _, err := tx.Exec(ctx, CreateSchemaMigrationsQuery); err != nil {
+			return errors.Wrap(err, "failed to create schema_migrations")
+		}
+
+		current, dirty, err := (&repository{pool: r.pool, db: tx}).SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return errors.Errorf("schema version %d is dirty: fix the schema and mark it with migrate force", current)
+		}
+		if current == 0 {
+			var unversioned bool
+			if err = tx.QueryRow(ctx, UnversionedSchemaQuery).Scan(&unversioned); err != nil {
+				return errors.Wrap(err, "failed to check schema")
+			}
+			if unversioned {
+				return errors.New("schema has tables but no version in schema_migrations: " +
+					"mark the applied migration with migrate force")
+			}
+		}
+
+		applied := current
+		for _, migration := range migrations.All() {
+			if migration.Version <= current {
+				continue
+			}
+			if _, err = tx.Exec(ctx, migration.SQL); err != nil {
+				return errors.Wrapf(err, "failed to apply migration %s", migration.Name)
+			}
+			applied = migration.Version
+		}
+		if applied == current {
+			return nil
+		}
+		if _, err = tx.Exec(ctx, SetSchemaVersionQuery, applied); err != nil {
+			return errors.Wrap(err, "failed to set schema version")
+		}
+		return nil
+	})
+}
+
+// Close закрывает пул, дожидаясь возврата занятых соединений
+func (r *repository) Close() {
+	r.pool.Close()
+}
+
+// Ping проверяет, что база доступна
+func (r *repository) Ping(ctx context.Context) error {
+	if err := r.pool.Ping(ctx); err != nil {
+		return errors.Wrap(err, "failed to ping DB")
 	}
 	return nil
 }
 
-func (r *repository) GetAllTasks(ctx context.Context) ([]Task, error) {
-	pgRows, err := r.pool.Query(ctx, GetAllTasksQuery)
+// SchemaVersion - последняя применённая миграция и признак незавершённой миграции
+func (r *repository) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	pgRows, err := r.db.Query(ctx, GetSchemaVersionQuery)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to query schema version")
+	}
+
+	// без строки миграции не применялись, версия 0
+	var version int64
+	var dirty bool
+	_, err = pgx.ForEachRow(pgRows, []any{&version, &dirty}, func() error { return nil })
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to convert schema version")
+	}
+	return version, dirty, nil
+}
+
+func (r *repository) GetAllTasks(ctx context.Context, filter TaskFilter) ([]Task, error) {
+	pgRows, err := r.db.Query(ctx, GetAllTasksQuery, taskFilterArgs(filter)...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query all tasks")
 	}
@@ -80,14 +234,42 @@ func (r *repository) GetAllTasks(ctx context.Context) ([]Task, error) {
 	return tasks, nil
 }
 
+func (r *repository) ForEachTask(ctx context.Context, filter TaskFilter, fn func(task Task) error) error {
+	pgRows, err := r.db.Query(ctx, GetAllTasksQuery, taskFilterArgs(filter)...)
+	if err != nil {
+		return errors.Wrap(err, "failed to query tasks")
+	}
+
+	defer pgRows.Close()
+	for pgRows.Next() {
+		task, err := pgx.RowToStructByName[Task](pgRows)
+		if err != nil {
+			return errors.Wrap(err, "failed to convert task")
+		}
+		if err = fn(task); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(pgRows.Err(), "failed to read tasks")
+}
+
+func taskFilterArgs(filter TaskFilter) []any {
+	return []any{filter.Status, filter.UserID, filter.Tag, filter.CreatedFrom, filter.CreatedTo, filter.Limit, filter.Offset,
+		filter.WithDue, filter.DueFrom, filter.DueTo}
+}
+
 func (r *repository) GetTaskByID(ctx context.Context, id string) (*Task, error) {
-	pgRow, err := r.pool.Query(ctx, GetTaskByIdQuery, id)
+	pgRow, err := r.db.Query(ctx, GetTaskByIdQuery, id)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query task")
 	}
 
 	defer pgRow.Close()
 	task, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[Task])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, dto.ErrNotFound
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert task")
 	}
@@ -96,21 +278,21 @@ func (r *repository) GetTaskByID(ctx context.Context, id string) (*Task, error)
 }
 
 func (r *repository) GetLastTaskByUserID(ctx context.Context, id string) (*Task, error) {
-	user, err := r.getUserByName(ctx, id)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to check user exist")
-	}
-	if user == nil {
-		return nil, dto.ErrNotFound
+	if _, err := r.getUserByID(ctx, id); err != nil {
+		return nil, err
 	}
 
-	pgRow, err := r.pool.Query(ctx, GetLastTaskByUserIdQuery, id)
+	pgRow, err := r.db.Query(ctx, GetLastTaskByUserIdQuery, id)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query task")
 	}
 
 	defer pgRow.Close()
 	task, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[Task])
+	// у пользователя нет задач
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, dto.ErrNotFound
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert task")
 	}
@@ -119,15 +301,26 @@ func (r *repository) GetLastTaskByUserID(ctx context.Context, id string) (*Task,
 }
 
 func (r *repository) GetAllTasksByUserID(ctx context.Context, id string) ([]Task, error) {
-	user, err := r.getUserByID(ctx, id)
+	if _, err := r.getUserByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	pgRows, err := r.db.Query(ctx, GetAllTasksByUserIdQuery, id)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to check user exist")
+		return nil, errors.Wrap(err, "failed to query task")
 	}
-	if user == nil {
-		return nil, dto.ErrNotFound
+
+	defer pgRows.Close()
+	tasks, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[Task])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert task")
 	}
 
-	pgRows, err := r.pool.Query(ctx, GetAllTasksByUserIdQuery, id)
+	return tasks, nil
+}
+
+func (r *repository) GetTasksByUserIDs(ctx context.Context, ids []string) ([]Task, error) {
+	pgRows, err := r.db.Query(ctx, GetTasksByUserIdsQuery, ids)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query task")
 	}
@@ -142,15 +335,11 @@ func (r *repository) GetAllTasksByUserID(ctx context.Context, id string) ([]Task
 }
 
 func (r *repository) GetTasksByUserName(ctx context.Context, name string) ([]Task, error) {
-	user, err := r.getUserByName(ctx, name)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to check user exist")
-	}
-	if user == nil {
-		return nil, dto.ErrNotFound
+	if _, err := r.getUserByName(ctx, name); err != nil {
+		return nil, err
 	}
 
-	pgRows, err := r.pool.Query(ctx, GetAllTasksByUserNameQuery, name)
+	pgRows, err := r.db.Query(ctx, GetAllTasksByUserNameQuery, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query task")
 	}
@@ -164,72 +353,575 @@ func (r *repository) GetTasksByUserName(ctx context.Context, name string) ([]Tas
 }
 
 // status MUST BE IN ('new', 'in_progress', 'done')
-func (r *repository) UpdateStatusByID(ctx context.Context, id string, status string) error {
-	cmdTag, err := r.pool.Exec(ctx, UpdateTaskStatusByIDQuery, id, status)
+// version 0 - без проверки версии
+func (r *repository) UpdateStatusByID(ctx context.Context, id string, status string, version int) error {
+	cmdTag, err := r.db.Exec(ctx, UpdateTaskStatusByIDQuery, id, status, version)
 	if err != nil {
 		return errors.Wrap(err, "failed to query task")
 	}
 	if cmdTag.RowsAffected() == 0 {
-		return dto.ErrNotFound
+		return notAffected(version)
 	}
 	return nil
 }
 
-func (r *repository) DeleteTaskByID(ctx context.Context, id string) error {
-	cmdTag, err := r.pool.Exec(ctx, DeleteTaskByIdQuery, id)
+func (r *repository) DeleteTaskByID(ctx context.Context, id string, version int) error {
+	cmdTag, err := r.db.Exec(ctx, DeleteTaskByIdQuery, id, version)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete task")
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		return dto.ErrNotFound
+		return notAffected(version)
 	}
 
 	return nil
 }
 
-func (r *repository) CreateUser(ctx context.Context, user User) error {
-	_, err := r.pool.Exec(ctx, CreateUserQuery, user.Name, user.Password)
+// notAffected - задачи нет либо, при проверке версии, она уже изменена
+func notAffected(version int) error {
+	if version != 0 {
+		return dto.ErrVersionConflict
+	}
+	return dto.ErrNotFound
+}
+
+func (r *repository) CreateUser(ctx context.Context, user User) (*User, error) {
+	pgRow, err := r.db.Query(ctx, CreateUserQuery, user.Name, user.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create user")
+	}
+
+	defer pgRow.Close()
+	created, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[User])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert user")
+	}
+
+	return &created, nil
+}
+
+func (r *repository) CreateTask(ctx context.Context, task Task) (*Task, error) {
+	pgRow, err := r.db.Query(ctx, CreateTaskQuery, task.UserID, task.Title, task.Data, task.DueAt)
 	if err != nil {
-		return errors.Wrap(err, "failed to create user")
+		return nil, errors.Wrap(err, "failed to create task")
 	}
+
+	defer pgRow.Close()
+	created, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[Task])
+	// задача не создаётся, если пользователя user_id нет
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, dto.ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert task")
+	}
+
+	return &created, nil
+}
+
+func (r *repository) GetUserByName(ctx context.Context, name string) (*User, error) {
+	return r.getUserByName(ctx, name)
+}
+
+func (r *repository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	return r.getUserByID(ctx, id)
+}
+
+func (r *repository) GetUsers(ctx context.Context) ([]User, error) {
+	pgRows, err := r.db.Query(ctx, GetUsersQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query users")
+	}
+
+	users, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[User])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert users")
+	}
+
+	return users, nil
+}
+
+func (r *repository) GetUsersByIDs(ctx context.Context, ids []string) ([]User, error) {
+	pgRows, err := r.db.Query(ctx, GetUsersByIdsQuery, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query users")
+	}
+
+	users, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[User])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert users")
+	}
+
+	return users, nil
+}
+
+func (r *repository) DeleteUserByID(ctx context.Context, id string) error {
+	cmdTag, err := r.db.Exec(ctx, DeleteUserByIdQuery, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete user")
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return dto.ErrNotFound
+	}
+
 	return nil
 }
 
-func (r *repository) CreateTask(ctx context.Context, task Task) error {
-	_, err := r.pool.Exec(ctx, CreateTaskQuery, task.UserID, task.Title, task.Data)
+// stateTask - строка запроса изменения задачи: state "before" - задача до изменения, "after" - после него;
+// строки "after" нет, если версия не совпала
+type stateTask struct {
+	State string `db:"state"`
+	Task
+}
+
+func (r *repository) ApplyTaskOperations(ctx context.Context, ops []TaskOperation) ([]TaskOperationResult, error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, op := range ops {
+		switch op.Op {
+		case TaskOpCreate:
+			batch.Queue(CreateTaskQuery, op.Task.UserID, op.Task.Title, op.Task.Data, op.Task.DueAt)
+		case TaskOpUpdateStatus:
+			batch.Queue(UpdateTaskStatusReturningQuery, op.ID, op.Status, op.Owner, op.Version)
+		case TaskOpTag:
+			batch.Queue(AddTaskTagsReturningQuery, op.ID, op.Tags, op.Owner, op.Version)
+		case TaskOpDelete:
+			batch.Queue(DeleteTaskReturningQuery, op.ID, op.Owner, op.Version)
+		default:
+			return nil, errors.Errorf("unknown task operation %q", op.Op)
+		}
+	}
+
+	batchResults := r.db.SendBatch(ctx, batch)
+	defer batchResults.Close()
+
+	results := make([]TaskOperationResult, len(ops))
+	for i := range ops {
+		pgRow, err := batchResults.Query()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply task operation %d", i)
+		}
+		if ops[i].Op == TaskOpCreate {
+			created, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[Task])
+			if errors.Is(err, pgx.ErrNoRows) {
+				results[i].Err = dto.ErrNotFound
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to apply task operation %d", i)
+			}
+			results[i].Task = &created
+			continue
+		}
+
+		// остальные операции возвращают задачу до изменения и, если версия совпала, после него
+		rows, err := pgx.CollectRows(pgRow, pgx.RowToStructByName[stateTask])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply task operation %d", i)
+		}
+		for j := range rows {
+			if rows[j].State == "before" {
+				results[i].Before = &rows[j].Task
+			} else {
+				results[i].Task = &rows[j].Task
+			}
+		}
+		switch {
+		case results[i].Before == nil:
+			results[i].Err = dto.ErrNotFound
+		case results[i].Task == nil:
+			results[i].Task = results[i].Before
+			results[i].Err = dto.ErrVersionConflict
+		}
+	}
+
+	if err := batchResults.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to apply task operations")
+	}
+	return results, nil
+}
+
+func (r *repository) CopyTasks(ctx context.Context, tasks []Task) ([]Task, error) {
+	var created []Task
+	// временная таблица живёт до конца транзакции
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, CreateTasksImportTableQuery); err != nil {
+			return errors.Wrap(err, "failed to create import table")
+		}
+
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"tasks_import"},
+			[]string{"user_id", "title", "description", "status", "tags", "due_at"},
+			pgx.CopyFromSlice(len(tasks), func(i int) ([]any, error) {
+				t := tasks[i]
+				// COPY передаёт значения в бинарном формате, поэтому user_id приводится к числу
+				userID, err := strconv.Atoi(t.UserID)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid user_id %q", t.UserID)
+				}
+				return []any{userID, t.Title, t.Data, t.Status, t.Tags, t.DueAt}, nil
+			}))
+		if err != nil {
+			return errors.Wrap(err, "failed to copy tasks")
+		}
+
+		pgRows, err := tx.Query(ctx, MoveTasksImportQuery)
+		if err != nil {
+			return errors.Wrap(err, "failed to move imported tasks")
+		}
+		created, err = pgx.CollectRows(pgRows, pgx.RowToStructByName[Task])
+		if err != nil {
+			return errors.Wrap(err, "failed to convert imported tasks")
+		}
+
+		_, err = tx.Exec(ctx, TruncateTasksImportQuery)
+		return errors.Wrap(err, "failed to clean import table")
+	})
+	return created, err
+}
+
+func (r *repository) GetExistingUserIDs(ctx context.Context, ids []string) ([]string, error) {
+	pgRows, err := r.db.Query(ctx, GetExistingUserIdsQuery, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query users")
+	}
+
+	existing, err := pgx.CollectRows(pgRows, pgx.RowTo[string])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert users")
+	}
+
+	return existing, nil
+}
+
+func (r *repository) CreateAuditRecords(ctx context.Context, records []AuditRecord) error {
+	_, err := r.db.CopyFrom(ctx, pgx.Identifier{"audit_log"},
+		[]string{"actor", "action", "entity", "entity_id", "diff", "request_id"},
+		pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+			rec := records[i]
+			return []any{rec.Actor, rec.Action, rec.Entity, rec.EntityID, rec.Diff, rec.RequestID}, nil
+		}))
 	if err != nil {
-		return errors.Wrap(err, "failed to create task")
+		return errors.Wrap(err, "failed to create audit records")
 	}
 	return nil
 }
 
+func (r *repository) GetAuditRecords(ctx context.Context, filter AuditFilter) ([]AuditRecord, error) {
+	pgRows, err := r.db.Query(ctx, GetAuditRecordsQuery,
+		filter.Actor, filter.Action, filter.Entity, filter.EntityID,
+		filter.From, filter.To, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query audit records")
+	}
+
+	defer pgRows.Close()
+	records, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[AuditRecord])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert audit records")
+	}
+
+	return records, nil
+}
+
+// getUserByID возвращает dto.ErrNotFound, если пользователя нет
 func (r *repository) getUserByID(ctx context.Context, id string) (*User, error) {
-	pgRow, err := r.pool.Query(ctx, GetUserByIdQuery, id)
+	pgRow, err := r.db.Query(ctx, GetUserByIdQuery, id)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query user")
 	}
 
 	defer pgRow.Close()
-	User, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[User])
+	user, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[User])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, dto.ErrNotFound
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert user")
 	}
 
-	return &User, nil
+	return &user, nil
 }
 
+// getUserByName возвращает dto.ErrNotFound, если пользователя нет
 func (r *repository) getUserByName(ctx context.Context, name string) (*User, error) {
-	pgRow, err := r.pool.Query(ctx, GetUserByNameQuery, name)
+	pgRow, err := r.db.Query(ctx, GetUserByNameQuery, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query user")
 	}
 
 	defer pgRow.Close()
-	User, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[User])
+	user, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[User])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, dto.ErrNotFound
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert user")
 	}
 
-	return &User, nil
+	return &user, nil
+}
+
+func (r *repository) CreateOutboxEvents(ctx context.Context, events []OutboxEvent) error {
+	_, err := r.db.CopyFrom(ctx, pgx.Identifier{"outbox_events"},
+		[]string{"event_type", "payload", "trace_parent"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			return []any{events[i].EventType, events[i].Payload, events[i].TraceParent}, nil
+		}))
+	if err != nil {
+		return errors.Wrap(err, "failed to create outbox events")
+	}
+	return nil
+}
+
+func (r *repository) FanOutOutboxEvents(ctx context.Context, limit int) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, FanOutOutboxEventsQuery, limit)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to fan out outbox events")
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r *repository) CreateWebhook(ctx context.Context, webhook Webhook) (*Webhook, error) {
+	pgRow, err := r.db.Query(ctx, CreateWebhookQuery, webhook.URL, webhook.EventTypes, webhook.Secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create webhook")
+	}
+
+	defer pgRow.Close()
+	created, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByName[Webhook])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert webhook")
+	}
+
+	return &created, nil
+}
+
+func (r *repository) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	pgRows, err := r.db.Query(ctx, GetWebhooksQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query webhooks")
+	}
+
+	defer pgRows.Close()
+	webhooks, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[Webhook])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert webhooks")
+	}
+
+	return webhooks, nil
+}
+
+func (r *repository) DeleteWebhookByID(ctx context.Context, id string) error {
+	cmdTag, err := r.db.Exec(ctx, DeleteWebhookByIdQuery, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete webhook")
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return dto.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error) {
+	pgRows, err := r.db.Query(ctx, GetWebhookDeliveriesQuery, webhookID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query webhook deliveries")
+	}
+
+	defer pgRows.Close()
+	deliveries, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[WebhookDelivery])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+func (r *repository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DeliveryJob, error) {
+	pgRows, err := r.db.Query(ctx, ClaimWebhookDeliveriesQuery, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to claim webhook deliveries")
+	}
+
+	defer pgRows.Close()
+	jobs, err := pgx.CollectRows(pgRows, pgx.RowToStructByName[DeliveryJob])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert webhook deliveries")
+	}
+
+	return jobs, nil
+}
+
+func (r *repository) UpdateWebhookDelivery(ctx context.Context, result DeliveryResult) error {
+	_, err := r.db.Exec(ctx, UpdateWebhookDeliveryQuery,
+		result.ID, result.Status, result.ResponseCode, result.Error, result.NextAttemptAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to update webhook delivery")
+	}
+	return nil
+}
+
+func (r *repository) Notify(ctx context.Context, channel string, payloads ...[]byte) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(payloads))
+	for i, payload := range payloads {
+		texts[i] = string(payload)
+	}
+	_, err := r.db.Exec(ctx, NotifyQuery, channel, texts)
+	if err != nil {
+		return errors.Wrap(err, "failed to notify")
+	}
+	return nil
+}
+
+// Listen занимает отдельное соединение пула и вызывает handle на каждое уведомление канала,
+// пока ctx не отменён или соединение не разорвано
+func (r *repository) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return errors.Wrap(err, "failed to listen")
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to wait for notification")
+		}
+		handle(notification.Payload)
+	}
+}
+
+// Stat - состояние пула соединений для метрик
+func (r *repository) Stat() *pgxpool.Stat {
+	return r.pool.Stat()
+}
+
+// CountTasksByStatus возвращает число задач в каждом статусе
+func (r *repository) CountTasksByStatus(ctx context.Context) (map[string]int64, error) {
+	pgRows, err := r.db.Query(ctx, CountTasksByStatusQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count tasks")
+	}
+
+	counts := make(map[string]int64)
+	var status string
+	var count int64
+	_, err = pgx.ForEachRow(pgRows, []any{&status, &count}, func() error {
+		counts[status] = count
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert task counts")
+	}
+
+	return counts, nil
+}
+
+// ReserveIdempotencyKey занимает ключ вставкой, а занятый читает отдельным запросом. Между ними первый запрос
+// может освободить ключ после сбоя, тогда чтение не находит строки и ключ занимается ещё раз
+func (r *repository) ReserveIdempotencyKey(ctx context.Context, actor, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error) {
+	for attempt := 0; ; attempt++ {
+		reserved, err := r.reserveIdempotencyKey(ctx, actor, key, requestHash, ttl)
+		if err != nil || reserved {
+			return nil, err
+		}
+
+		pgRow, err := r.db.Query(ctx, GetIdempotencyKeyQuery, actor, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to query idempotency key")
+		}
+		record, err := pgx.CollectOneRow(pgRow, pgx.RowToStructByPos[IdempotencyRecord])
+		if errors.Is(err, pgx.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert idempotency key")
+		}
+		return &record, nil
+	}
+}
+
+func (r *repository) reserveIdempotencyKey(ctx context.Context, actor, key, requestHash string, ttl time.Duration) (bool, error) {
+	pgRows, err := r.db.Query(ctx, ReserveIdempotencyKeyQuery, actor, key, requestHash, ttl.Seconds())
+	if err != nil {
+		return false, errors.Wrap(err, "failed to reserve idempotency key")
+	}
+	reserved, err := pgx.CollectRows(pgRows, pgx.RowTo[string])
+	if err != nil {
+		return false, errors.Wrap(err, "failed to reserve idempotency key")
+	}
+	return len(reserved) > 0, nil
+}
+
+func (r *repository) CompleteIdempotencyKey(ctx context.Context, actor, key string, statusCode int, contentType string, response []byte) error {
+	if _, err := r.db.Exec(ctx, CompleteIdempotencyKeyQuery, actor, key, statusCode, response, contentType); err != nil {
+		return errors.Wrap(err, "failed to complete idempotency key")
+	}
+	return nil
+}
+
+func (r *repository) ReleaseIdempotencyKey(ctx context.Context, actor, key string) error {
+	if _, err := r.db.Exec(ctx, ReleaseIdempotencyKeyQuery, actor, key); err != nil {
+		return errors.Wrap(err, "failed to release idempotency key")
+	}
+	return nil
+}
+
+func (r *repository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, DeleteExpiredIdempotencyKeyQuery)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete expired idempotency keys")
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r *repository) TakeRateLimitToken(ctx context.Context, key string, burst, rate float64) (float64, bool, error) {
+	pgRows, err := r.db.Query(ctx, TakeRateLimitTokenQuery, key, burst, rate)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to take rate limit token")
+	}
+	taken, err := pgx.CollectRows(pgRows, pgx.RowTo[float64])
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to take rate limit token")
+	}
+	if len(taken) > 0 {
+		return taken[0], true, nil
+	}
+
+	pgRows, err = r.db.Query(ctx, GetRateLimitTokensQuery, key, burst, rate)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to query rate limit tokens")
+	}
+	tokens, err := pgx.CollectRows(pgRows, pgx.RowTo[float64])
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to query rate limit tokens")
+	}
+	// корзину успели удалить - она заполнена
+	if len(tokens) == 0 {
+		return burst, false, nil
+	}
+	return tokens[0], false, nil
+}
+
+func (r *repository) DeleteIdleRateLimits(ctx context.Context, idle time.Duration) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, DeleteIdleRateLimitsQuery, idle.Seconds())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete idle rate limits")
+	}
+	return cmdTag.RowsAffected(), nil
 }
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDB отвечает на запросы выборками rows по очереди, когда они кончаются - пустой, и запоминает запросы
+type stubDB struct {
+	dbtx
+	rows    []pgx.Rows
+	queries []string
+}
+
+func (db *stubDB) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	db.queries = append(db.queries, sql)
+	if len(db.rows) == 0 {
+		return emptyRows{}, nil
+	}
+	rows := db.rows[0]
+	db.rows = db.rows[1:]
+	return rows, nil
+}
+
+type emptyRows struct{ pgx.Rows }
+
+func (emptyRows) Close()                                       {}
+func (emptyRows) Err() error                                   { return nil }
+func (emptyRows) Next() bool                                   { return false }
+func (emptyRows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT 0") }
+func (emptyRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+
+// textRow - выборка из одной строки с одним текстовым значением
+type textRow struct {
+	emptyRows
+	value string
+	read  bool
+}
+
+func (r *textRow) Next() bool {
+	next := !r.read
+	r.read = true
+	return next
+}
+
+func (r *textRow) Scan(dest ...any) error {
+	*dest[0].(*string) = r.value
+	return nil
+}
+
+func TestUserLookupsNotFound(t *testing.T) {
+	tests := []struct {
+		name  string
+		call  func(r *repository) error
+		query string
+	}{
+		{"GetUserByID", func(r *repository) error { _, err := r.GetUserByID(context.Background(), "1"); return err }, GetUserByIdQuery},
+		{"GetUserByName", func(r *repository) error { _, err := r.GetUserByName(context.Background(), "bob"); return err }, GetUserByNameQuery},
+		{"GetLastTaskByUserID", func(r *repository) error {
+			_, err := r.GetLastTaskByUserID(context.Background(), "1")
+			return err
+		}, GetUserByIdQuery},
+		{"GetAllTasksByUserID", func(r *repository) error {
+			_, err := r.GetAllTasksByUserID(context.Background(), "1")
+			return err
+		}, GetUserByIdQuery},
+		{"GetTasksByUserName", func(r *repository) error {
+			_, err := r.GetTasksByUserName(context.Background(), "bob")
+			return err
+		}, GetUserByNameQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &stubDB{}
+			err := tt.call(&repository{db: db})
+
+			assert.ErrorIs(t, err, dto.ErrNotFound)
+			assert.Equal(t, []string{tt.query}, db.queries)
+		})
+	}
+}
+
+func TestReserveIdempotencyKeyReleasedMeanwhile(t *testing.T) {
+	// ключ занят, но освобождается до чтения: вторая вставка его занимает
+	db := &stubDB{rows: []pgx.Rows{emptyRows{}, emptyRows{}, &textRow{value: "admin"}}}
+	record, err := (&repository{db: db}).ReserveIdempotencyKey(context.Background(), "admin", "k1", "hash", time.Hour)
+
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Equal(t, []string{ReserveIdempotencyKeyQuery, GetIdempotencyKeyQuery, ReserveIdempotencyKeyQuery}, db.queries)
+
+	// ключ снова занят другим запросом и снова освобождён: повтор только один
+	db = &stubDB{}
+	_, err = (&repository{db: db}).ReserveIdempotencyKey(context.Background(), "admin", "k1", "hash", time.Hour)
+
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.Len(t, db.queries, 4)
+}
@@ -0,0 +1,142 @@
+package repo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statementNames - имена запросов для спанов. Спан называется по константе, а не по тексту SQL,
+// чтобы запросы в трассировке группировались. TestStatementNames следит, что здесь есть все константы
+var statementNames = map[string]string{
+	CreateSchemaMigrationsQuery:      "CreateSchemaMigrations",
+	LockSchemaMigrationsQuery:        "LockSchemaMigrations",
+	UnversionedSchemaQuery:           "UnversionedSchema",
+	SetSchemaVersionQuery:            "SetSchemaVersion",
+	GetSchemaVersionQuery:            "GetSchemaVersion",
+	GetAllTasksQuery:                 "GetAllTasks",
+	CountTasksByStatusQuery:          "CountTasksByStatus",
+	GetTaskByIdQuery:                 "GetTaskById",
+	GetAllTasksByUserIdQuery:         "GetAllTasksByUserId",
+	GetLastTaskByUserIdQuery:         "GetLastTaskByUserId",
+	GetAllTasksByUserNameQuery:       "GetAllTasksByUserName",
+	GetTasksByUserIdsQuery:           "GetTasksByUserIds",
+	CreateTaskQuery:                  "CreateTask",
+	UpdateTaskStatusByIDQuery:        "UpdateTaskStatusByID",
+	DeleteTaskByIdQuery:              "DeleteTaskById",
+	UpdateTaskStatusReturningQuery:   "UpdateTaskStatusReturning",
+	AddTaskTagsReturningQuery:        "AddTaskTagsReturning",
+	DeleteTaskReturningQuery:         "DeleteTaskReturning",
+	CreateTasksImportTableQuery:      "CreateTasksImportTable",
+	MoveTasksImportQuery:             "MoveTasksImport",
+	TruncateTasksImportQuery:         "TruncateTasksImport",
+	CreateUserQuery:                  "CreateUser",
+	GetUserByIdQuery:                 "GetUserById",
+	GetUserByNameQuery:               "GetUserByName",
+	GetExistingUserIdsQuery:          "GetExistingUserIds",
+	GetUsersQuery:                    "GetUsers",
+	GetUsersByIdsQuery:               "GetUsersByIds",
+	DeleteUserByIdQuery:              "DeleteUserById",
+	GetAuditRecordsQuery:             "GetAuditRecords",
+	FanOutOutboxEventsQuery:          "FanOutOutboxEvents",
+	CreateWebhookQuery:               "CreateWebhook",
+	GetWebhooksQuery:                 "GetWebhooks",
+	DeleteWebhookByIdQuery:           "DeleteWebhookById",
+	GetWebhookDeliveriesQuery:        "GetWebhookDeliveries",
+	ClaimWebhookDeliveriesQuery:      "ClaimWebhookDeliveries",
+	UpdateWebhookDeliveryQuery:       "UpdateWebhookDelivery",
+	ReserveIdempotencyKeyQuery:       "ReserveIdempotencyKey",
+	GetIdempotencyKeyQuery:           "GetIdempotencyKey",
+	CompleteIdempotencyKeyQuery:      "CompleteIdempotencyKey",
+	ReleaseIdempotencyKeyQuery:       "ReleaseIdempotencyKey",
+	DeleteExpiredIdempotencyKeyQuery: "DeleteExpiredIdempotencyKey",
+	TakeRateLimitTokenQuery:          "TakeRateLimitToken",
+	GetRateLimitTokensQuery:          "GetRateLimitTokens",
+	DeleteIdleRateLimitsQuery:        "DeleteIdleRateLimits",
+	NotifyQuery:                      "Notify",
+}
+
+// statementName - имя константы запроса, для прочего SQL (begin, commit, LISTEN) - его первое слово
+func statementName(sql string) string {
+	if name, ok := statementNames[sql]; ok {
+		return name
+	}
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "SQL"
+}
+
+// queryTracer пишет спан на каждый запрос pgx, пакет запросов и COPY: имя запроса, число строк и ошибку
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+func newQueryTracer(tracer trace.Tracer) *queryTracer {
+	return &queryTracer{tracer: tracer}
+}
+
+func (t *queryTracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	attrs = append(attrs, semconv.DBSystemNamePostgreSQL)
+	ctx, _ = t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (t *queryTracer) end(ctx context.Context, err error, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// rowsAttribute - для SELECT число прочитанных строк, для остальных команд - затронутых
+func rowsAttribute(tag pgconn.CommandTag) attribute.KeyValue {
+	if tag.Select() {
+		return semconv.DBResponseReturnedRows(int(tag.RowsAffected()))
+	}
+	return attribute.Int64("db.response.affected_rows", tag.RowsAffected())
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := statementName(data.SQL)
+	return t.start(ctx, name, semconv.DBQuerySummary(name), semconv.DBQueryText(data.SQL))
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.end(ctx, data.Err, rowsAttribute(data.CommandTag))
+}
+
+func (t *queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx, "Batch", attribute.Int("db.operation.batch.size", data.Batch.Len()))
+}
+
+// TraceBatchQuery отмечает запрос пакета событием спана пакета
+func (t *queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	attrs := []attribute.KeyValue{semconv.DBQuerySummary(statementName(data.SQL)), rowsAttribute(data.CommandTag)}
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error", data.Err.Error()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (t *queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	t.end(ctx, data.Err)
+}
+
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := strings.Join(data.TableName, ".")
+	return t.start(ctx, "Copy "+table, semconv.DBCollectionName(table))
+}
+
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, data.Err, rowsAttribute(data.CommandTag))
+}
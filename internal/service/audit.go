@@ -0,0 +1,168 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+const (
+	ActionCreate       = "create"
+	ActionUpdate       = "update"
+	ActionDelete       = "delete"
+	ActionStatusChange = "status_change"
+
+	EntityTask    = "task"
+	EntityUser    = "user"
+	EntityWebhook = "webhook"
+
+	defaultAuditLimit = 100
+	redactedValue     = "[REDACTED]"
+)
+
+// поля, значения которых не попадают в журнал
+var redactedFields = map[string]bool{"password": true}
+
+// FieldChange - значение поля до и после изменения
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// auditRecord формирует запись журнала об изменении
+func auditRecord(actor, requestID, action, entity, entityID string, before, after any) (repo2.AuditRecord, error) {
+	changes, err := diff(before, after)
+	if err != nil {
+		return repo2.AuditRecord{}, err
+	}
+
+	return repo2.AuditRecord{
+		Actor:     actor,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Diff:      changes,
+		RequestID: requestID,
+	}, nil
+}
+
+// diff возвращает только изменившиеся поля; для создания before = nil, для удаления after = nil
+func diff(before, after any) (json.RawMessage, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]FieldChange)
+	for name, value := range beforeFields {
+		if newValue, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, newValue) {
+			changes[name] = FieldChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = FieldChange{After: value}
+		}
+	}
+
+	return json.Marshal(changes)
+}
+
+func toFields(obj any) (map[string]any, error) {
+	if obj == nil || reflect.ValueOf(obj).Kind() == reflect.Ptr && reflect.ValueOf(obj).IsNil() {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	for name := range fields {
+		if redactedFields[name] {
+			fields[name] = redactedValue
+		}
+	}
+	return fields, nil
+}
+
+func (s *service) GetTaskActivity(ctx context.Context, req RequestWithId) ([]repo2.AuditRecord, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// Checks ownership, deleted tasks are visible to admin only
+	principal := auth.FromContext(ctx)
+	task, err := s.repo.GetTaskByID(ctx, req.ID)
+	switch {
+	case errors.Is(err, dto.ErrNotFound) && principal.Admin:
+	case err != nil:
+		return nil, s.fail(ctx, "Failed to get task", err)
+	case !principal.CanAccess(task.UserID):
+		return nil, ErrNotFound
+	}
+
+	// Gets from memory
+	records, err := s.repo.GetAuditRecords(ctx, repo2.AuditFilter{
+		Entity:   EntityTask,
+		EntityID: req.ID,
+		Limit:    defaultAuditLimit,
+	})
+	if err != nil {
+		return nil, s.fail(ctx, "Failed to get task activity", err)
+	}
+	return records, nil
+}
+
+func (s *service) GetAuditRecords(ctx context.Context, req AuditRequest) ([]repo2.AuditRecord, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	filter := repo2.AuditFilter{
+		Actor:    req.Actor,
+		Action:   req.Action,
+		Entity:   req.Entity,
+		EntityID: req.EntityID,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	for _, bound := range []struct {
+		raw string
+		dst **time.Time
+	}{{req.From, &filter.From}, {req.To, &filter.To}} {
+		if bound.raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			return nil, badFormat(dto.MsgInvalidAuditTime)
+		}
+		*bound.dst = &t
+	}
+
+	// Gets from memory
+	records, err := s.repo.GetAuditRecords(ctx, filter)
+	if err != nil {
+		return nil, s.fail(ctx, "Failed to get audit records", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	repo2 "TemplatestPGSQL/internal/repo"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	task := &repo2.Task{DataObject: repo2.DataObject{ID: "1", Title: "title", Status: "new"}, UserID: "2"}
+	updated := *task
+	updated.Status = "done"
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   map[string]FieldChange
+	}{
+		{
+			name:   "Status change",
+			before: task,
+			after:  &updated,
+			want:   map[string]FieldChange{"status": {Before: "new", After: "done"}},
+		},
+		{
+			name:   "No changes",
+			before: task,
+			after:  task,
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "Delete keeps every field",
+			before: &repo2.User{ID: "1", Name: "name", Password: "secret"},
+			after:  (*repo2.User)(nil),
+			want: map[string]FieldChange{
+				"id":         {Before: "1"},
+				"name":       {Before: "name"},
+				"password":   {Before: redactedValue},
+				"created_at": {Before: "0001-01-01T00:00:00Z"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := diff(tt.before, tt.after)
+			require.NoError(t, err)
+
+			var got map[string]FieldChange
+			require.NoError(t, json.Unmarshal(raw, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
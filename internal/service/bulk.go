@@ -0,0 +1,219 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/pkg/validator"
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+const (
+	BulkModeAtomic  = "atomic"
+	BulkModePartial = "partial"
+
+	BulkItemSuccess = "success"
+	BulkItemError   = "error"
+	BulkItemSkipped = "skipped"
+)
+
+var errBulkAborted = errors.New("bulk operation aborted")
+
+func (s *service) BulkTasks(ctx context.Context, req BulkRequest) ([]BulkResult, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	atomic := req.Mode != BulkModePartial
+
+	// validates every item, invalid ones are not sent to memory
+	results := make([]BulkResult, len(req.Operations))
+	ops := make([]repo2.TaskOperation, 0, len(req.Operations))
+	positions := make([]int, 0, len(req.Operations))
+	principal := auth.FromContext(ctx)
+	for i, item := range req.Operations {
+		results[i] = BulkResult{Index: i, Status: BulkItemSkipped}
+		op, itemErr := toTaskOperation(ctx, principal, item)
+		if itemErr != nil {
+			results[i].Status = BulkItemError
+			results[i].Error = itemErr
+			continue
+		}
+		ops = append(ops, op)
+		positions = append(positions, i)
+	}
+	if atomic && len(ops) < len(req.Operations) {
+		return nil, bulkAbortedError(results)
+	}
+
+	// applies to memory
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		opResults, err := s.applyOperations(ctx, r, ops, atomic)
+		if err != nil {
+			return err
+		}
+
+		changes := newChangeSet(ctx)
+		failed := false
+		for j, res := range opResults {
+			item := &results[positions[j]]
+			if res.Err != nil {
+				failed = true
+				item.Status = BulkItemError
+				item.Error = bulkItemError(ops[j], res.Err)
+				// при устаревшей версии клиент получает текущее состояние задачи
+				if errors.Is(res.Err, dto.ErrVersionConflict) {
+					item.Data = res.Task
+				}
+				continue
+			}
+
+			item.Status = BulkItemSuccess
+			item.Data = res.Task
+			if err = collectOperation(changes, ops[j], res); err != nil {
+				return err
+			}
+		}
+
+		if atomic && failed {
+			return errBulkAborted
+		}
+		return changes.flush(ctx, r)
+	})
+	if errors.Is(err, errBulkAborted) {
+		for i := range results {
+			if results[i].Status == BulkItemSuccess {
+				results[i].Status = BulkItemSkipped
+				results[i].Data = nil
+			}
+		}
+		return nil, bulkAbortedError(results)
+	}
+	if err != nil {
+		s.logger(ctx).Error("Failed to apply bulk operations", zap.Error(err))
+		return nil, err
+	}
+	s.logger(ctx).Infof("bulk of %d task operations was applied", len(ops))
+
+	return results, nil
+}
+
+// applyOperations выполняет операции одним пакетом. Ошибка в пакете прерывает все последующие операции,
+// поэтому в режиме partial после неё операции повторяются по одной, каждая в своей точке сохранения.
+func (s *service) applyOperations(ctx context.Context, r repo2.Repository, ops []repo2.TaskOperation, atomic bool) ([]repo2.TaskOperationResult, error) {
+	var results []repo2.TaskOperationResult
+	err := r.InTx(ctx, func(tx repo2.Repository) error {
+		var err error
+		results, err = tx.ApplyTaskOperations(ctx, ops)
+		return err
+	})
+	if err == nil || atomic {
+		return results, err
+	}
+	s.logger(ctx).Warn("Bulk batch failed, applying operations one by one", zap.Error(err))
+
+	results = make([]repo2.TaskOperationResult, len(ops))
+	for i := range ops {
+		var single []repo2.TaskOperationResult
+		err = r.InTx(ctx, func(tx repo2.Repository) error {
+			var err error
+			single, err = tx.ApplyTaskOperations(ctx, ops[i:i+1])
+			return err
+		})
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i] = single[0]
+	}
+	return results, nil
+}
+
+// toTaskOperation проверяет операцию пакета. Пользователь создаёт задачи только себе и изменяет только свои задачи:
+// чужие для него не существуют. Изменение задачи требует версии, как If-Match у одиночных запросов
+func toTaskOperation(ctx context.Context, principal auth.Principal, item BulkOperation) (repo2.TaskOperation, *dto.Error) {
+	op := repo2.TaskOperation{Op: item.Op, Version: item.Version}
+	if !principal.Admin {
+		op.Owner = principal.UserID
+		// пустой владелец в запросе к базе означает любого
+		if op.Owner == "" {
+			return op, &dto.Error{Code: dto.Unauthorized, Desc: dto.MsgUnauthorized}
+		}
+	}
+
+	var req any
+	switch item.Op {
+	case repo2.TaskOpCreate:
+		req = item.Task
+		op.Task = repo2.Task{
+			DataObject: repo2.DataObject{
+				Title: item.Task.Title,
+				Data:  item.Task.Data,
+			},
+			UserID: item.Task.UserID,
+			DueAt:  item.Task.DueAt,
+		}
+	case repo2.TaskOpUpdateStatus:
+		req = UpdateRequest{ID: item.ID, Status: item.Status}
+		op.ID, op.Status = item.ID, item.Status
+	case repo2.TaskOpTag:
+		req = TagRequest{ID: item.ID, Tags: item.Tags}
+		op.ID, op.Tags = item.ID, item.Tags
+	case repo2.TaskOpDelete:
+		req = RequestWithId{ID: item.ID}
+		op.ID = item.ID
+	default:
+		return op, &dto.Error{Code: dto.FieldIncorrect, Desc: dto.Detail(validator.ErrFieldNotOneOf, "BulkOperation.Op")}
+	}
+
+	if vErr := validator.Validate(ctx, req); vErr != nil {
+		return op, &dto.Error{Code: dto.FieldIncorrect, Desc: vErr.Error(), Fields: validator.Fields(vErr)}
+	}
+	switch {
+	case item.Op == repo2.TaskOpCreate && !principal.CanAccess(op.Task.UserID):
+		return op, &dto.Error{Code: dto.NotFound, Desc: dto.MsgUserNotFound}
+	case item.Op != repo2.TaskOpCreate && item.Version <= 0:
+		return op, &dto.Error{Code: dto.PreconditionRequired, Desc: dto.MsgOperationVersionRequired}
+	}
+	return op, nil
+}
+
+// collectOperation добавляет в журнал изменение, сделанное операцией
+func collectOperation(changes *changeSet, op repo2.TaskOperation, res repo2.TaskOperationResult) error {
+	switch op.Op {
+	case repo2.TaskOpCreate:
+		return changes.add(ActionCreate, EntityTask, res.Task.ID, nil, res.Task)
+	case repo2.TaskOpUpdateStatus:
+		return changes.add(ActionStatusChange, EntityTask, res.Task.ID, res.Before, res.Task)
+	case repo2.TaskOpTag:
+		return changes.add(ActionUpdate, EntityTask, res.Task.ID, res.Before, res.Task)
+	case repo2.TaskOpDelete:
+		return changes.add(ActionDelete, EntityTask, res.Task.ID, res.Before, nil)
+	}
+	return nil
+}
+
+func bulkItemError(op repo2.TaskOperation, err error) *dto.Error {
+	if errors.Is(err, dto.ErrVersionConflict) {
+		return &dto.Error{Code: dto.PreconditionFailed, Desc: dto.MsgVersionOutdated}
+	}
+	if !errors.Is(err, dto.ErrNotFound) {
+		return &dto.Error{Code: dto.ServiceUnavailable, Desc: dto.InternalError}
+	}
+	if op.Op == repo2.TaskOpCreate {
+		return &dto.Error{Code: dto.NotFound, Desc: dto.MsgUserNotFound}
+	}
+	return &dto.Error{Code: dto.NotFound, Desc: dto.MsgTaskNotFound}
+}
+
+// bulkAbortedError - отмена атомарного пакета, результаты по каждой операции уходят клиенту в Data
+func bulkAbortedError(results []BulkResult) *Error {
+	return &Error{
+		Code: dto.BulkAborted,
+		Desc: dto.MsgBulkRolledBack,
+		Data: results,
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestService(repository repo2.Repository) Service {
+	return NewService(repository, zap.NewNop().Sugar(), auth.NewSigner("secret", auth.DefaultTokenTTL))
+}
+
+func inTx(repository *mocks.Repository) {
+	repository.On("InTx", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(r repo2.Repository) error) error { return fn(repository) })
+}
+
+func TestBulkTasksPartial(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	inTx(repository)
+	before := &repo2.Task{DataObject: repo2.DataObject{ID: "1", Status: "new", Version: 1}, UserID: "3"}
+	after := &repo2.Task{DataObject: repo2.DataObject{ID: "1", Status: "done", Version: 2}, UserID: "3"}
+	repository.On("ApplyTaskOperations", mock.Anything, []repo2.TaskOperation{
+		{Op: repo2.TaskOpUpdateStatus, ID: "1", Status: "done", Version: 1},
+		{Op: repo2.TaskOpDelete, ID: "2", Version: 1},
+	}).Return([]repo2.TaskOperationResult{{Task: after, Before: before}, {Err: dto.ErrNotFound}}, nil)
+	repository.On("CreateAuditRecords", mock.Anything, mock.MatchedBy(func(records []repo2.AuditRecord) bool {
+		return len(records) == 1 && records[0].Action == ActionStatusChange && records[0].EntityID == "1" &&
+			records[0].Actor == auth.AdminActor && records[0].RequestID == "req-1" &&
+			strings.Contains(string(records[0].Diff), `"new"`) && strings.Contains(string(records[0].Diff), `"done"`)
+	})).Return(nil)
+	repository.On("CreateOutboxEvents", mock.Anything, mock.Anything).Return(nil)
+	repository.On("Notify", mock.Anything, repo2.TaskEventsChannel, mock.Anything).Return(nil)
+
+	ctx := WithRequestID(auth.WithPrincipal(context.Background(), auth.Principal{Admin: true}), "req-1")
+	results, err := newTestService(repository).BulkTasks(ctx, BulkRequest{Mode: BulkModePartial, Operations: []BulkOperation{
+		{Op: repo2.TaskOpUpdateStatus, ID: "1", Status: "done", Version: 1},
+		{Op: repo2.TaskOpDelete, ID: "2", Version: 1},
+		{Op: repo2.TaskOpTag, ID: "3", Tags: []string{"no-hash"}, Version: 1},
+	}})
+
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, BulkItemSuccess, results[0].Status)
+	// клиент получает задачу после изменения
+	data, ok := results[0].Data.(*repo2.Task)
+	require.True(t, ok)
+	assert.Equal(t, "done", data.Status)
+	assert.Equal(t, 2, data.Version)
+	assert.Equal(t, BulkItemError, results[1].Status)
+	assert.Equal(t, dto.NotFound, results[1].Error.Code)
+	assert.Equal(t, BulkItemError, results[2].Status)
+	assert.Equal(t, dto.FieldIncorrect, results[2].Error.Code)
+}
+
+func TestBulkTasksAtomicRollsBack(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	inTx(repository)
+	task := &repo2.Task{DataObject: repo2.DataObject{ID: "1", Status: "new"}}
+	repository.On("ApplyTaskOperations", mock.Anything, mock.Anything).
+		Return([]repo2.TaskOperationResult{{Task: task}, {Err: dto.ErrNotFound}}, nil)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Admin: true})
+	_, err := newTestService(repository).BulkTasks(ctx, BulkRequest{Operations: []BulkOperation{
+		{Op: repo2.TaskOpUpdateStatus, ID: "1", Status: "done", Version: 1},
+		{Op: repo2.TaskOpDelete, ID: "2", Version: 1},
+	}})
+
+	var svcErr *Error
+	require.ErrorAs(t, err, &svcErr)
+	assert.Equal(t, dto.BulkAborted, svcErr.Code)
+	results, ok := svcErr.Data.([]BulkResult)
+	require.True(t, ok)
+	require.Len(t, results, 2)
+	assert.Equal(t, BulkItemSkipped, results[0].Status)
+	assert.Equal(t, BulkItemError, results[1].Status)
+	repository.AssertNotCalled(t, "CreateAuditRecords", mock.Anything, mock.Anything)
+}
+
+func TestBulkTasksOwnershipAndVersion(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	inTx(repository)
+	current := &repo2.Task{DataObject: repo2.DataObject{ID: "1", Status: "new", Version: 3}, UserID: "7"}
+	// чужие задачи и устаревшие версии отсекаются в базе по владельцу и версии операции
+	repository.On("ApplyTaskOperations", mock.Anything, []repo2.TaskOperation{
+		{Op: repo2.TaskOpUpdateStatus, ID: "1", Status: "done", Owner: "7", Version: 2},
+		{Op: repo2.TaskOpDelete, ID: "2", Owner: "7", Version: 1},
+	}).Return([]repo2.TaskOperationResult{{Task: current, Err: dto.ErrVersionConflict}, {Err: dto.ErrNotFound}}, nil)
+	repository.On("Notify", mock.Anything, repo2.TaskEventsChannel).Return(nil)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: "7"})
+	results, err := newTestService(repository).BulkTasks(ctx, BulkRequest{Mode: BulkModePartial, Operations: []BulkOperation{
+		{Op: repo2.TaskOpUpdateStatus, ID: "1", Status: "done", Version: 2},
+		{Op: repo2.TaskOpDelete, ID: "2", Version: 1},
+		{Op: repo2.TaskOpTag, ID: "3", Tags: []string{"#a"}},
+		{Op: repo2.TaskOpCreate, Task: PostRequest{Title: "title", Status: "new", UserID: "8"}},
+	}})
+
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, dto.PreconditionFailed, results[0].Error.Code)
+	assert.Equal(t, current, results[0].Data)
+	assert.Equal(t, dto.NotFound, results[1].Error.Code)
+	assert.Equal(t, dto.PreconditionRequired, results[2].Error.Code)
+	assert.Equal(t, dto.NotFound, results[3].Error.Code)
+	repository.AssertNotCalled(t, "CreateAuditRecords", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/ical"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	CalendarComponentTodo  = "vtodo"
+	CalendarComponentEvent = "vevent"
+
+	// домен в UID не меняется, чтобы календарь узнавал задачу при каждом обновлении ленты
+	calendarUIDDomain = "templatestpgsql"
+)
+
+// статусы задач в терминах VTODO и VEVENT
+var (
+	icalTodoStatuses  = map[string]string{"new": "NEEDS-ACTION", "in_progress": "IN-PROCESS", "done": "COMPLETED"}
+	icalEventStatuses = map[string]string{"new": "TENTATIVE", "in_progress": "CONFIRMED", "done": "CONFIRMED"}
+)
+
+// CalendarFeed отдаёт задачи пользователя со сроком в формате iCalendar.
+// Календари не передают заголовки, поэтому лента защищена токеном из query, а не Authorization.
+func (s *service) CalendarFeed(ctx context.Context, userID string, req CalendarFeedRequest) (*Download, error) {
+	if !s.signer.VerifyFeedToken(userID, req.Token) {
+		return nil, ErrUnauthorized
+	}
+	ctx = auth.WithPrincipal(ctx, auth.Principal{UserID: userID})
+
+	filter, err := s.taskFilter(ctx, req, req.TaskListRequest)
+	if err != nil {
+		return nil, err
+	}
+	filter.WithDue = true
+
+	return &Download{
+		ContentType: "text/calendar; charset=utf-8",
+		Filename:    "tasks.ics",
+		Inline:      true,
+		write: func(ctx context.Context, w io.Writer) error {
+			if err := s.writeCalendar(ctx, w, req.Component, filter); err != nil {
+				s.logger(ctx).Error("Failed to write calendar feed", zap.Error(err))
+				return err
+			}
+			return nil
+		},
+	}, nil
+}
+
+func (s *service) writeCalendar(ctx context.Context, w io.Writer, component string, filter repo2.TaskFilter) error {
+	calendar := ical.NewWriter(w)
+	if err := calendar.Begin("Tasks"); err != nil {
+		return err
+	}
+
+	err := s.repo.ForEachTask(ctx, filter, func(task repo2.Task) error {
+		if component == CalendarComponentEvent {
+			return calendar.WriteEvent(calendarItem(task, icalEventStatuses))
+		}
+		return calendar.WriteTodo(calendarItem(task, icalTodoStatuses))
+	})
+	if err != nil {
+		return err
+	}
+	return calendar.End()
+}
+
+// GetCalendarLink возвращает адрес ленты автора запроса; администратор указывает пользователя в user_id.
+// baseURL - адрес сервиса, под которым его видит клиент.
+func (s *service) GetCalendarLink(ctx context.Context, req CalendarLinkRequest, baseURL string) (*CalendarLink, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	principal := auth.FromContext(ctx)
+	if !principal.Admin || req.UserID == "" {
+		req.UserID = principal.UserID
+	}
+	if req.UserID == "" {
+		return nil, &Error{Code: dto.FieldIncorrect, Desc: dto.MsgCalendarNoUser}
+	}
+
+	token := s.signer.FeedToken(req.UserID)
+	return &CalendarLink{
+		UserID: req.UserID,
+		Token:  token,
+		URL:    fmt.Sprintf("%s/calendar/%s/tasks.ics?token=%s", baseURL, req.UserID, url.QueryEscape(token)),
+	}, nil
+}
+
+func calendarItem(task repo2.Task, statuses map[string]string) ical.Item {
+	item := ical.Item{
+		UID:         fmt.Sprintf("task-%s@%s", task.ID, calendarUIDDomain),
+		Summary:     task.Title,
+		Description: task.Data,
+		Status:      statuses[task.Status],
+		Due:         task.DueAt,
+		Created:     task.CreatedAt,
+	}
+	for _, tag := range task.Tags {
+		item.Categories = append(item.Categories, strings.TrimPrefix(tag, "#"))
+	}
+	return item
+}
+
+// taskStatusFromICal переводит STATUS задачи VTODO в статус задачи; отменённая задача считается закрытой
+func taskStatusFromICal(status string) string {
+	switch status {
+	case "":
+		return ""
+	case "NEEDS-ACTION":
+		return "new"
+	case "IN-PROCESS":
+		return "in_progress"
+	case "COMPLETED", "CANCELLED":
+		return "done"
+	}
+	// неизвестный статус не проходит проверку строки и попадает в отчёт импорта
+	return strings.ToLower(status)
+}
+
+// tagFromCategory приводит категорию календаря к формату тега: "Work Stuff" -> "#work-stuff"
+func tagFromCategory(category string) string {
+	category = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(category), "#"))
+	return "#" + strings.Join(strings.Fields(category), "-")
+}
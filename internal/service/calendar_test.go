@@ -0,0 +1,65 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	due := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	task := repo2.Task{
+		DataObject: repo2.DataObject{ID: "7", Title: "Pay rent", Status: "in_progress"},
+		UserID:     "3",
+		Tags:       []string{"#home"},
+		DueAt:      &due,
+	}
+	repository := mocks.NewRepository(t)
+	repository.On("ForEachTask", mock.Anything, mock.MatchedBy(func(f repo2.TaskFilter) bool {
+		return f.UserID == "3" && f.WithDue && f.Status == "in_progress"
+	}), mock.Anything).
+		Return(func(_ context.Context, _ repo2.TaskFilter, fn func(repo2.Task) error) error { return fn(task) }).Maybe()
+
+	signer := auth.NewSigner("secret", auth.DefaultTokenTTL)
+	s := NewService(repository, zap.NewNop().Sugar(), signer)
+	feed := func(userID, component string) (*Download, string, error) {
+		req := CalendarFeedRequest{
+			TaskListRequest: TaskListRequest{Status: "in_progress"},
+			Component:       component,
+			Token:           signer.FeedToken("3"),
+		}
+		download, err := s.CalendarFeed(context.Background(), userID, req)
+		if err != nil {
+			return nil, "", err
+		}
+		var body bytes.Buffer
+		require.NoError(t, download.Write(context.Background(), &body))
+		return download, body.String(), nil
+	}
+
+	// токен одного пользователя не открывает ленту другого
+	_, _, err := feed("4", "")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	download, body, err := feed("3", "")
+	require.NoError(t, err)
+	assert.True(t, download.Inline)
+	assert.Contains(t, body, "BEGIN:VTODO\r\nUID:task-7@templatestpgsql\r\n")
+	assert.Contains(t, body, "STATUS:IN-PROCESS\r\n")
+	assert.Contains(t, body, "DUE:20250301T090000Z\r\n")
+	assert.Contains(t, body, "CATEGORIES:home\r\n")
+
+	_, body, err = feed("3", CalendarComponentEvent)
+	require.NoError(t, err)
+	assert.Contains(t, body, "DTSTART:20250301T090000Z\r\n")
+	assert.Contains(t, body, "STATUS:CONFIRMED\r\n")
+}
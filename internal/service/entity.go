@@ -1,19 +1,33 @@
 package service
 
+import (
+	"TemplatestPGSQL/internal/dto"
+	"time"
+)
+
 type PostRequest struct {
-	Title  string `json:"title" validate:"required"`
-	Data   string `json:"data"`
-	Status string `json:"status"`
-	UserID string `json:"user_id" validate:"required"`
+	Title  string     `json:"title" validate:"required"`
+	Data   string     `json:"data"`
+	Status string     `json:"status"`
+	UserID string     `json:"user_id" validate:"required"`
+	DueAt  *time.Time `json:"due_at"`
 }
 
 type RequestWithId struct {
-	ID string `validate:"required,intString,min=1"`
+	ID string `params:"id" validate:"required,intString,min=1"`
 }
 
 type UpdateRequest struct {
-	Status string `json:"status" validate:"required"`
-	ID     string `validate:"required,intString,min=1"`
+	Status string `json:"status" validate:"required,oneof=new in_progress done"`
+	ID     string `params:"id" validate:"required,intString,min=1"`
+	// Version - ожидаемая версия задачи (If-Match), 0 - без проверки
+	Version int `json:"-"`
+}
+
+type DeleteTaskRequest struct {
+	ID string `params:"id" validate:"required,intString,min=1"`
+	// Version - ожидаемая версия задачи (If-Match), 0 - без проверки
+	Version int `json:"-"`
 }
 
 type PostUserRequest struct {
@@ -21,6 +35,117 @@ type PostUserRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// UserInfo - пользователь в ответах API, без пароля
+type UserInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginResult struct {
+	Token  string `json:"token"`
+	UserID string `json:"user_id"`
+}
+
 type RequestWithUserName struct {
-	Name string `json:"name" validate:"required"`
+	Name string `json:"name" params:"username" validate:"required"`
+}
+
+type AuditRequest struct {
+	Actor    string `query:"actor"`
+	Action   string `query:"action" validate:"omitempty,oneof=create update delete status_change"`
+	Entity   string `query:"entity" validate:"omitempty,oneof=task user webhook"`
+	EntityID string `query:"entity_id"`
+	From     string `query:"from"`
+	To       string `query:"to"`
+	Limit    int    `query:"limit" validate:"gte=0,lte=1000"`
+	Offset   int    `query:"offset" validate:"gte=0"`
+}
+
+type PostWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=task.created task.updated task.status_changed task.deleted user.created"`
+	Secret     string   `json:"secret" validate:"required,min=16"`
+}
+
+type BulkRequest struct {
+	Mode       string          `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Operations []BulkOperation `json:"operations" validate:"required,min=1,max=1000"`
+}
+
+// BulkOperation - операция пакетного запроса, набор нужных полей зависит от Op
+type BulkOperation struct {
+	Op     string      `json:"op"`
+	ID     string      `json:"id,omitempty"`
+	Status string      `json:"status,omitempty"`
+	Tags   []string    `json:"tags,omitempty"`
+	Task   PostRequest `json:"task"`
+	// Version - ожидаемая версия задачи, обязательна для update_status, tag и delete, как If-Match
+	Version int `json:"version,omitempty"`
+}
+
+type TagRequest struct {
+	ID   string   `params:"id" validate:"required,intString,min=1"`
+	Tags []string `json:"tags" validate:"required,min=1,dive,tag"`
+}
+
+type BulkResult struct {
+	Index  int        `json:"index"`
+	Status string     `json:"status"`
+	Data   any        `json:"data,omitempty"`
+	Error  *dto.Error `json:"error,omitempty"`
+}
+
+// ImportRow - строка импорта задач из CSV, NDJSON или задача VTODO
+type ImportRow struct {
+	Title  string     `json:"title" validate:"required"`
+	Data   string     `json:"data"`
+	Status string     `json:"status" validate:"omitempty,oneof=new in_progress done"`
+	UserID string     `json:"user_id" validate:"required,intString"`
+	Tags   []string   `json:"tags" validate:"omitempty,dive,tag"`
+	DueAt  *time.Time `json:"due_at"`
+}
+
+type ImportRequest struct {
+	Format  string `query:"format" validate:"omitempty,oneof=csv ndjson ics"`
+	DryRun  bool   `query:"dry_run"`
+	Mapping string `query:"mapping"`
+	// UserID - владелец задач из .ics, по умолчанию автор запроса
+	UserID string `query:"user_id" validate:"omitempty,intString"`
+}
+
+// TaskListRequest - фильтры списков и выгрузки задач
+type TaskListRequest struct {
+	Status      string `query:"status" validate:"omitempty,oneof=new in_progress done"`
+	UserID      string `query:"user_id" validate:"omitempty,intString"`
+	Tag         string `query:"tag" validate:"omitempty,tag"`
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	DueFrom     string `query:"due_from"`
+	DueTo       string `query:"due_to"`
+	Limit       int    `query:"limit" validate:"gte=0,lte=1000"`
+	Offset      int    `query:"offset" validate:"gte=0"`
+}
+
+type ExportRequest struct {
+	TaskListRequest
+	Format string `query:"format" validate:"required,oneof=csv ndjson xlsx"`
+}
+
+type CalendarFeedRequest struct {
+	TaskListRequest
+	Component string `query:"component" validate:"omitempty,oneof=vtodo vevent"`
+	// Token проверяется до разбора запроса, поле нужно для описания API
+	Token string `query:"token" validate:"required"`
+}
+
+type CalendarLinkRequest struct {
+	UserID string `query:"user_id" validate:"omitempty,intString"`
+}
+
+// CalendarLink - адрес ленты iCalendar и её токен
+type CalendarLink struct {
+	UserID string `json:"user_id"`
+	Token  string `json:"token"`
+	URL    string `json:"url"`
 }
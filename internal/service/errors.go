@@ -0,0 +1,73 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/pkg/validator"
+	"context"
+	"errors"
+)
+
+// Error - ошибка, которую исправляет клиент: неверный запрос, нет объекта, нет доступа.
+// Транспорт показывает Code и Desc как есть, любая другая ошибка сервиса - внутренняя.
+type Error struct {
+	// Code - код из dto: NOT_FOUND, FIELD_INCORRECT и т.д.
+	Code string
+	Desc string
+	// Data - подробности для клиента, например результаты отменённой пакетной операции
+	Data any
+	// Fields - все нарушенные правила полей для FIELD_INCORRECT
+	Fields []validator.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Desc
+}
+
+// Is сравнивает ошибки по коду, чтобы errors.Is(err, ErrNotFound) находил любую ошибку NOT_FOUND
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrNotFound     = &Error{Code: dto.NotFound, Desc: dto.MsgNotFound}
+	ErrUnauthorized = &Error{Code: dto.Unauthorized, Desc: dto.MsgUnauthorized}
+	ErrForbidden    = &Error{Code: dto.Forbidden, Desc: dto.MsgForbidden}
+	ErrTimeout      = &Error{Code: dto.RequestTimeout, Desc: dto.MsgTimeout}
+	ErrRateLimited  = &Error{Code: dto.RateLimited, Desc: dto.MsgRateLimited}
+	// ErrVersionRequired - транспорт без If-Match (gRPC, GraphQL) не передал ожидаемую версию задачи
+	ErrVersionRequired = &Error{Code: dto.PreconditionRequired, Desc: dto.MsgTaskVersionRequired}
+)
+
+// AsError находит ошибку для клиента в цепочке err. Запрос, прерванный по сроку ctx, - ErrTimeout,
+// даже если база вернула свою ошибку отмены запроса. Иначе false - внутренняя ошибка
+func AsError(ctx context.Context, err error) (*Error, bool) {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr, true
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout, true
+	}
+	return nil, false
+}
+
+func badFormat(desc string) *Error {
+	return &Error{Code: dto.FieldBadFormat, Desc: desc}
+}
+
+func notFound(desc string) *Error {
+	return &Error{Code: dto.NotFound, Desc: desc}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID передаёт идентификатор запроса в записи журнала аудита
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/tracing"
+	"context"
+	"encoding/json"
+)
+
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskUpdated       = "task.updated"
+	EventTaskStatusChanged = "task.status_changed"
+	EventTaskDeleted       = "task.deleted"
+	EventUserCreated       = "user.created"
+
+	maxNotifyPayload = 7900
+)
+
+// события, которые публикуются для действий над сущностями
+var eventTypes = map[string]string{
+	EntityTask + "." + ActionCreate:       EventTaskCreated,
+	EntityTask + "." + ActionUpdate:       EventTaskUpdated,
+	EntityTask + "." + ActionStatusChange: EventTaskStatusChanged,
+	EntityTask + "." + ActionDelete:       EventTaskDeleted,
+	EntityUser + "." + ActionCreate:       EventUserCreated,
+}
+
+// changeSet копит записи аудита, события outbox и уведомления об изменениях,
+// чтобы записать их в транзакции изменения минимальным числом запросов
+type changeSet struct {
+	actor     string
+	requestID string
+
+	audit         []repo2.AuditRecord
+	events        []repo2.OutboxEvent
+	notifications [][]byte
+}
+
+// record фиксирует одно изменение в журнале аудита и в outbox через r, т.е. в транзакции самого изменения
+func (s *service) record(ctx context.Context, r repo2.Repository, action, entity, entityID string, before, after any) error {
+	changes := newChangeSet(ctx)
+	if err := changes.add(action, entity, entityID, before, after); err != nil {
+		return err
+	}
+	return changes.flush(ctx, r)
+}
+
+// newChangeSet берёт автора и идентификатор запроса из контекста
+func newChangeSet(ctx context.Context) *changeSet {
+	return &changeSet{
+		actor:     auth.FromContext(ctx).Actor(),
+		requestID: requestIDFromContext(ctx),
+	}
+}
+
+func (c *changeSet) add(action, entity, entityID string, before, after any) error {
+	record, err := auditRecord(c.actor, c.requestID, action, entity, entityID, before, after)
+	if err != nil {
+		return err
+	}
+	c.audit = append(c.audit, record)
+
+	eventType, ok := eventTypes[entity+"."+action]
+	if !ok {
+		return nil
+	}
+
+	// для удаления публикуется последнее состояние объекта
+	data, err := eventFields(after)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		if data, err = eventFields(before); err != nil {
+			return err
+		}
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	c.events = append(c.events, repo2.OutboxEvent{
+		EventType: eventType,
+		Payload:   payload,
+	})
+
+	if entity != EntityTask {
+		return nil
+	}
+	notification, err := taskNotification(eventType, entityID, data, payload)
+	if err != nil {
+		return err
+	}
+	c.notifications = append(c.notifications, notification)
+	return nil
+}
+
+func (c *changeSet) flush(ctx context.Context, r repo2.Repository) error {
+	if len(c.audit) > 0 {
+		if err := r.CreateAuditRecords(ctx, c.audit); err != nil {
+			return err
+		}
+	}
+	if len(c.events) > 0 {
+		// доставка вебхука продолжит трассировку запроса, вызвавшего событие
+		traceParent := tracing.TraceParent(ctx)
+		for i := range c.events {
+			c.events[i].TraceParent = traceParent
+		}
+		if err := r.CreateOutboxEvents(ctx, c.events); err != nil {
+			return err
+		}
+	}
+	// подписчики потока задач во всех экземплярах сервиса узнают об изменении после COMMIT
+	return r.Notify(ctx, repo2.TaskEventsChannel, c.notifications...)
+}
+
+// eventFields - данные события. toFields оставлен для аудита: он маскирует пароль, но сохраняет ключ,
+// а подписчикам пользователь отдаётся тем же UserInfo, что и в API
+func eventFields(obj any) (map[string]any, error) {
+	if user, ok := obj.(*repo2.User); ok && user != nil {
+		return toFields(userInfo(*user))
+	}
+	return toFields(obj)
+}
+
+func taskNotification(eventType, taskID string, task map[string]any, payload []byte) ([]byte, error) {
+	userID, _ := task["user_id"].(string)
+	event := repo2.TaskEvent{
+		Type:   eventType,
+		TaskID: taskID,
+		UserID: userID,
+		Task:   payload,
+	}
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	// длина NOTIFY ограничена, слишком большие задачи подписчик дочитывает сам
+	if len(raw) > maxNotifyPayload {
+		event.Task = nil
+		return json.Marshal(event)
+	}
+	return raw, nil
+}
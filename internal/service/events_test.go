@@ -0,0 +1,26 @@
+package service
+
+import (
+	repo2 "TemplatestPGSQL/internal/repo"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeSetUserEvent(t *testing.T) {
+	changes := &changeSet{actor: "admin"}
+	user := &repo2.User{ID: "1", Name: "ivan", Password: "secret"}
+
+	require.NoError(t, changes.add(ActionCreate, EntityUser, user.ID, nil, user))
+
+	require.Len(t, changes.events, 1)
+	assert.Equal(t, EventUserCreated, changes.events[0].EventType)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(changes.events[0].Payload, &payload))
+	assert.Equal(t, map[string]any{"id": "1", "name": "ivan", "created_at": "0001-01-01T00:00:00Z"}, payload)
+
+	// аудит по-прежнему видит, что пароль был задан
+	assert.Contains(t, string(changes.audit[0].Diff), redactedValue)
+}
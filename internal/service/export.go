@@ -0,0 +1,212 @@
+package service
+
+import (
+	repo2 "TemplatestPGSQL/internal/repo"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+
+	exportSheet = "Tasks"
+)
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:    "text/csv; charset=utf-8",
+	ExportFormatNDJSON: "application/x-ndjson",
+	ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var exportColumns = []string{"id", "user_id", "title", "data", "status", "tags", "created_at", "due_at"}
+
+// Download - файл, который отдаётся потоком после проверки запроса
+type Download struct {
+	ContentType string
+	Filename    string
+	// Inline - файл показывается, а не сохраняется (ленты календаря)
+	Inline bool
+
+	write func(ctx context.Context, w io.Writer) error
+}
+
+// Write пишет файл в w; ошибку после начала записи клиенту уже не сообщить, он получит обрезанный файл
+func (d *Download) Write(ctx context.Context, w io.Writer) error {
+	return d.write(ctx, w)
+}
+
+func (s *service) ExportTasks(ctx context.Context, req ExportRequest) (*Download, error) {
+	filter, err := s.taskFilter(ctx, req, req.TaskListRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Download{
+		ContentType: exportContentTypes[req.Format],
+		Filename:    fmt.Sprintf("tasks-%s.%s", time.Now().UTC().Format("20060102T150405Z"), req.Format),
+		write: func(ctx context.Context, w io.Writer) error {
+			if err := s.exportTasks(ctx, w, req.Format, filter); err != nil {
+				s.logger(ctx).Error("Failed to export tasks", zap.Error(err))
+				return err
+			}
+			s.logger(ctx).Infof("tasks were exported as %s", req.Format)
+			return nil
+		},
+	}, nil
+}
+
+func (s *service) exportTasks(ctx context.Context, w io.Writer, format string, filter repo2.TaskFilter) error {
+	encoder, err := newTaskEncoder(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.ForEachTask(ctx, filter, encoder.Write); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// taskEncoder пишет задачи в выгрузку по одной
+type taskEncoder interface {
+	Write(task repo2.Task) error
+	Close() error
+}
+
+func newTaskEncoder(format string, w io.Writer) (taskEncoder, error) {
+	switch format {
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		return &csvTaskEncoder{writer: writer}, writer.Write(exportColumns)
+	case ExportFormatNDJSON:
+		return &ndjsonTaskEncoder{encoder: json.NewEncoder(w)}, nil
+	case ExportFormatXLSX:
+		return newXLSXTaskEncoder(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// exportRow - ячейки строки выгрузки
+func exportRow(task repo2.Task) []string {
+	due := ""
+	if task.DueAt != nil {
+		due = task.DueAt.Format(time.RFC3339)
+	}
+	return []string{
+		task.ID,
+		task.UserID,
+		task.Title,
+		task.Data,
+		task.Status,
+		strings.Join(task.Tags, " "),
+		task.CreatedAt.Format(time.RFC3339),
+		due,
+	}
+}
+
+// escapeFormula ставит ' перед значением, которое табличный редактор принял бы за формулу (CSV injection)
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type csvTaskEncoder struct {
+	writer *csv.Writer
+}
+
+// Write экранирует текст пользователя: ячейку CSV табличный редактор разбирает сам и может выполнить как формулу.
+// Статус берётся из фиксированного списка, а теги начинаются с #, поэтому их экранировать не нужно
+func (e *csvTaskEncoder) Write(task repo2.Task) error {
+	row := exportRow(task)
+	row[2], row[3] = escapeFormula(row[2]), escapeFormula(row[3])
+	return e.writer.Write(row)
+}
+
+func (e *csvTaskEncoder) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonTaskEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonTaskEncoder) Write(task repo2.Task) error {
+	return e.encoder.Encode(task)
+}
+
+func (e *ndjsonTaskEncoder) Close() error {
+	return nil
+}
+
+// xlsxTaskEncoder использует потоковую запись excelize: строки сбрасываются во временный файл, а не копятся в памяти.
+// Строки пишутся типизированными текстовыми ячейками, формулой их Excel не считает, экранирование не нужно
+type xlsxTaskEncoder struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXTaskEncoder(out io.Writer) (*xlsxTaskEncoder, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName(file.GetSheetName(0), exportSheet); err != nil {
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(exportSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &xlsxTaskEncoder{out: out, file: file, stream: stream}
+	header := make([]any, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column
+	}
+	return e, e.writeRow(header)
+}
+
+func (e *xlsxTaskEncoder) Write(task repo2.Task) error {
+	values := exportRow(task)
+	row := make([]any, len(values))
+	for i, value := range values {
+		row[i] = value
+	}
+	row[6] = task.CreatedAt
+	if task.DueAt != nil {
+		row[7] = *task.DueAt
+	}
+	return e.writeRow(row)
+}
+
+func (e *xlsxTaskEncoder) writeRow(values []any) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.stream.SetRow(cell, values)
+}
+
+func (e *xlsxTaskEncoder) Close() error {
+	defer e.file.Close()
+
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.out)
+	return err
+}
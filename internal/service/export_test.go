@@ -0,0 +1,143 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+var exportedTask = repo2.Task{
+	DataObject: repo2.DataObject{
+		ID:        "7",
+		Title:     "Report, draft",
+		Data:      "quarterly",
+		Status:    "done",
+		CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	},
+	UserID: "3",
+	Tags:   []string{"q1", "work"},
+}
+
+func exportTasks(t *testing.T, principal auth.Principal, req ExportRequest) (*Download, []byte, *mocks.Repository) {
+	t.Helper()
+	repository := mocks.NewRepository(t)
+	repository.On("ForEachTask", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ repo2.TaskFilter, fn func(repo2.Task) error) error { return fn(exportedTask) }).Maybe()
+
+	ctx := auth.WithPrincipal(context.Background(), principal)
+	download, err := newTestService(repository).ExportTasks(ctx, req)
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	require.NoError(t, download.Write(ctx, &body))
+	return download, body.Bytes(), repository
+}
+
+func TestExportTasksCSV(t *testing.T) {
+	download, body, repository := exportTasks(t, auth.Principal{UserID: "3"}, ExportRequest{
+		TaskListRequest: TaskListRequest{Status: "done", UserID: "5"},
+		Format:          ExportFormatCSV,
+	})
+
+	assert.Equal(t, "text/csv; charset=utf-8", download.ContentType)
+	assert.Regexp(t, `^tasks-\d{8}T\d{6}Z\.csv$`, download.Filename)
+	assert.Equal(t, "id,user_id,title,data,status,tags,created_at,due_at\n"+
+		"7,3,\"Report, draft\",quarterly,done,q1 work,2025-01-02T03:04:05Z,\n", string(body))
+	// пользователь выгружает только свои задачи, даже если передал чужой user_id
+	repository.AssertCalled(t, "ForEachTask", mock.Anything, mock.MatchedBy(func(f repo2.TaskFilter) bool {
+		return f.UserID == "3" && f.Status == "done"
+	}), mock.Anything)
+}
+
+func TestExportTasksNDJSON(t *testing.T) {
+	_, body, _ := exportTasks(t, auth.Principal{Admin: true}, ExportRequest{Format: ExportFormatNDJSON})
+
+	assert.Contains(t, string(body), `"title":"Report, draft"`)
+	assert.Equal(t, 1, bytes.Count(body, []byte("\n")))
+}
+
+func TestExportTasksXLSX(t *testing.T) {
+	_, body, _ := exportTasks(t, auth.Principal{Admin: true}, ExportRequest{Format: ExportFormatXLSX})
+
+	file, err := excelize.OpenReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	defer file.Close()
+	rows, err := file.GetRows(exportSheet)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, exportColumns, rows[0])
+	assert.Equal(t, "Report, draft", rows[1][2])
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	task := exportedTask
+	task.Title = "=HYPERLINK(\"http://evil\",\"x\")"
+	task.Data = "+1"
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"=1+1", "'=1+1"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"a=1", "a=1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeFormula(tt.value), tt.value)
+	}
+
+	// CSV экранируется, в XLSX ячейки текстовые и сохраняют значение как есть
+	for _, tt := range []struct {
+		format string
+		want   []string
+	}{
+		{ExportFormatCSV, []string{"'" + task.Title, "'+1"}},
+		{ExportFormatXLSX, []string{task.Title, "+1"}},
+	} {
+		var body bytes.Buffer
+		encoder, err := newTaskEncoder(tt.format, &body)
+		require.NoError(t, err)
+		require.NoError(t, encoder.Write(task))
+		require.NoError(t, encoder.Close())
+
+		var row []string
+		if tt.format == ExportFormatCSV {
+			records, err := csv.NewReader(&body).ReadAll()
+			require.NoError(t, err)
+			row = records[1]
+		} else {
+			file, err := excelize.OpenReader(&body)
+			require.NoError(t, err)
+			rows, err := file.GetRows(exportSheet)
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+			row = rows[1]
+		}
+		assert.Equal(t, tt.want, []string{row[2], row[3]}, tt.format)
+	}
+}
+
+func TestExportTasksInvalidFormat(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	_, err := newTestService(repository).ExportTasks(context.Background(), ExportRequest{Format: "pdf"})
+
+	var svcErr *Error
+	require.ErrorAs(t, err, &svcErr)
+	assert.Equal(t, dto.FieldIncorrect, svcErr.Code)
+}
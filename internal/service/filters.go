@@ -0,0 +1,60 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var errInvalidTimeBound = errors.New(dto.MsgInvalidTimeBound)
+
+// taskFilter переводит фильтры запроса в фильтр репозитория.
+// Пользователь видит только свои задачи, фильтр user_id доступен администратору.
+func (r TaskListRequest) taskFilter(principal auth.Principal) (repo2.TaskFilter, error) {
+	filter := repo2.TaskFilter{
+		Status: r.Status,
+		UserID: r.UserID,
+		Tag:    r.Tag,
+		Limit:  r.Limit,
+		Offset: r.Offset,
+	}
+	if !principal.Admin {
+		filter.UserID = principal.UserID
+	}
+
+	for _, bound := range []struct {
+		raw string
+		dst **time.Time
+	}{
+		{r.CreatedFrom, &filter.CreatedFrom}, {r.CreatedTo, &filter.CreatedTo},
+		{r.DueFrom, &filter.DueFrom}, {r.DueTo, &filter.DueTo},
+	} {
+		if bound.raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			return filter, errInvalidTimeBound
+		}
+		*bound.dst = &t
+	}
+	return filter, nil
+}
+
+// taskFilter проверяет запрос req и переводит фильтры list из него в фильтр репозитория для автора запроса
+func (s *service) taskFilter(ctx context.Context, req any, list TaskListRequest) (repo2.TaskFilter, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return repo2.TaskFilter{}, err
+	}
+
+	filter, err := list.taskFilter(auth.FromContext(ctx))
+	if err != nil {
+		return filter, badFormat(err.Error())
+	}
+	return filter, nil
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/ical"
+	customLogger "TemplatestPGSQL/internal/logger"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/pkg/i18n"
+	"TemplatestPGSQL/pkg/validator"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+	ImportFormatICS    = "ics"
+
+	importBatchSize   = 1000
+	maxImportErrors   = 1000
+	maxNDJSONLineSize = 1 << 20
+)
+
+// ErrInvalidImport - данные импорта нельзя разобрать целиком (формат, заголовок, сопоставление колонок)
+var ErrInvalidImport = errors.New("invalid import data")
+
+// importError - ErrInvalidImport с текстом для клиента
+type importError struct {
+	desc string
+}
+
+func invalidImport(msg, detail string) error {
+	return &importError{desc: dto.Detail(msg, detail)}
+}
+
+func (e *importError) Error() string {
+	return e.desc
+}
+
+func (e *importError) Is(target error) bool {
+	return target == ErrInvalidImport
+}
+
+// поля задачи, которые можно сопоставить колонкам CSV
+var importFields = []string{"title", "data", "status", "user_id", "tags", "due_at"}
+
+type ImportOptions struct {
+	Format string
+	// Mapping - поле задачи -> название колонки CSV, по умолчанию колонка называется как поле
+	Mapping map[string]string
+	DryRun  bool
+	// UserID - владелец задач для форматов без поля user_id (ics)
+	UserID string
+	// Owner - единственный допустимый владелец строк, пусто - любой (администратор)
+	Owner string
+}
+
+type ImportLineError struct {
+	Line  int       `json:"line"`
+	Error dto.Error `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun          bool              `json:"dry_run"`
+	Total           int               `json:"total"`
+	Valid           int               `json:"valid"`
+	Imported        int               `json:"imported"`
+	Errors          []ImportLineError `json:"errors"`
+	ErrorsTruncated bool              `json:"errors_truncated,omitempty"`
+}
+
+// addError переводит desc на язык запроса сразу: отчёт уходит клиенту в data, а не описанием ошибки
+func (r *ImportReport) addError(ctx context.Context, line int, code, desc string) {
+	if len(r.Errors) >= maxImportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	desc = i18n.Text(i18n.FromContext(ctx), desc)
+	r.Errors = append(r.Errors, ImportLineError{Line: line, Error: dto.Error{Code: code, Desc: desc}})
+}
+
+// Importer загружает задачи потоком: строки проверяются по одной и пишутся пачками через COPY.
+// Используется и HTTP-обработчиком, и командой import.
+type Importer struct {
+	repo repo2.Repository
+	log  *zap.SugaredLogger
+}
+
+func NewImporter(repo repo2.Repository, logger *zap.SugaredLogger) *Importer {
+	return &Importer{
+		repo: repo,
+		log:  logger,
+	}
+}
+
+// Import читает src целиком; без DryRun все пачки пишутся в одной транзакции вместе с журналом аудита.
+// Ошибочные строки попадают в отчёт и не загружаются.
+func (i *Importer) Import(ctx context.Context, actor, requestID string, src io.Reader, opts ImportOptions) (*ImportReport, error) {
+	rows, err := newRowReader(src, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Errors: []ImportLineError{}}
+	if opts.DryRun {
+		return report, i.run(ctx, i.repo, rows, opts.Owner, report, nil)
+	}
+
+	err = i.repo.InTx(ctx, func(r repo2.Repository) error {
+		return i.run(ctx, r, rows, opts.Owner, report, &changeSet{actor: actor, requestID: requestID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+type pendingRow struct {
+	line int
+	task repo2.Task
+}
+
+// run проверяет строки и отдаёт их пачками в flush; строки чужих владельцев при заданном owner
+// отклоняются так же, как строки несуществующих пользователей
+func (i *Importer) run(ctx context.Context, r repo2.Repository, rows rowReader, owner string, report *ImportReport, changes *changeSet) error {
+	batch := make([]pendingRow, 0, importBatchSize)
+	for {
+		line, row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		report.Total++
+
+		var lineErr *importLineError
+		if errors.As(err, &lineErr) {
+			report.addError(ctx, line, dto.FieldBadFormat, lineErr.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if vErr := validator.Validate(ctx, row); vErr != nil {
+			report.addError(ctx, line, dto.FieldIncorrect, vErr.Error())
+			continue
+		}
+		// "01" и "+1" - тот же пользователь 1, что и в базе
+		userID, err := strconv.Atoi(row.UserID)
+		if err != nil {
+			report.addError(ctx, line, dto.FieldIncorrect, dto.Detail(validator.ErrInvalidIntString, "ImportRow.UserID"))
+			continue
+		}
+		row.UserID = strconv.Itoa(userID)
+		if owner != "" && row.UserID != owner {
+			report.addError(ctx, line, dto.NotFound, dto.Detail(dto.MsgUserNotFound, row.UserID))
+			continue
+		}
+
+		batch = append(batch, pendingRow{line: line, task: repo2.Task{
+			DataObject: repo2.DataObject{
+				Title:  row.Title,
+				Data:   row.Data,
+				Status: row.Status,
+			},
+			UserID: row.UserID,
+			Tags:   row.Tags,
+			DueAt:  row.DueAt,
+		}})
+		if len(batch) == importBatchSize {
+			if err = i.flush(ctx, r, batch, report, changes); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	return i.flush(ctx, r, batch, report, changes)
+}
+
+// flush отбрасывает строки с несуществующими пользователями и, если это не dry-run, загружает остальные;
+// changes задаёт автора записей аудита, nil означает dry-run
+func (i *Importer) flush(ctx context.Context, r repo2.Repository, batch []pendingRow, report *ImportReport, changes *changeSet) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	userIDs := make([]string, 0, len(batch))
+	for _, row := range batch {
+		userIDs = append(userIDs, row.task.UserID)
+	}
+	existing, err := r.GetExistingUserIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+
+	tasks := make([]repo2.Task, 0, len(batch))
+	for _, row := range batch {
+		if !known[row.task.UserID] {
+			report.addError(ctx, row.line, dto.NotFound, dto.Detail(dto.MsgUserNotFound, row.task.UserID))
+			continue
+		}
+		tasks = append(tasks, row.task)
+	}
+	report.Valid += len(tasks)
+	if changes == nil || len(tasks) == 0 {
+		return nil
+	}
+
+	created, err := r.CopyTasks(ctx, tasks)
+	if err != nil {
+		return err
+	}
+	batchChanges := &changeSet{actor: changes.actor, requestID: changes.requestID}
+	for idx := range created {
+		if err = batchChanges.add(ActionCreate, EntityTask, created[idx].ID, nil, &created[idx]); err != nil {
+			return err
+		}
+	}
+	if err = batchChanges.flush(ctx, r); err != nil {
+		return err
+	}
+
+	report.Imported += len(created)
+	customLogger.FromContext(ctx, i.log).Infof("%d tasks were imported", len(created))
+	return nil
+}
+
+// ParseImportMapping разбирает сопоставление колонок вида "title=Name,user_id=Owner"
+func ParseImportMapping(raw string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, invalidImport(dto.MsgInvalidMapping, strconv.Quote(pair))
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// importLineError - ошибка разбора одной строки, импорт при ней продолжается; desc - текст для отчёта
+type importLineError struct {
+	desc string
+}
+
+func (e *importLineError) Error() string {
+	return e.desc
+}
+
+type rowReader interface {
+	// Next возвращает номер строки и её содержимое, io.EOF в конце данных
+	Next() (int, ImportRow, error)
+}
+
+func newRowReader(src io.Reader, opts ImportOptions) (rowReader, error) {
+	switch opts.Format {
+	case ImportFormatCSV:
+		return newCSVRowReader(src, opts.Mapping)
+	case ImportFormatNDJSON:
+		scanner := bufio.NewScanner(src)
+		scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineSize)
+		return &ndjsonRowReader{scanner: scanner}, nil
+	case ImportFormatICS:
+		if opts.UserID == "" {
+			return nil, &importError{desc: dto.MsgImportNoUser}
+		}
+		return &icsRowReader{reader: ical.NewReader(src), userID: opts.UserID}, nil
+	default:
+		return nil, invalidImport(dto.MsgUnsupportedImportFormat, strconv.Quote(opts.Format))
+	}
+}
+
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVRowReader(src io.Reader, mapping map[string]string) (*csvRowReader, error) {
+	for field := range mapping {
+		if !isImportField(field) {
+			return nil, invalidImport(dto.MsgUnknownMappingField, strconv.Quote(field))
+		}
+	}
+
+	reader := csv.NewReader(src)
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, invalidImport(dto.MsgInvalidCSVHeader, err.Error())
+	}
+
+	positions := make(map[string]int, len(header))
+	for idx, name := range header {
+		positions[strings.TrimSpace(name)] = idx
+	}
+	columns := make(map[string]int, len(importFields))
+	for _, field := range importFields {
+		column := field
+		if mapped, ok := mapping[field]; ok {
+			column = mapped
+		}
+		if idx, ok := positions[column]; ok {
+			columns[field] = idx
+		} else if _, ok = mapping[field]; ok {
+			return nil, invalidImport(dto.MsgMappedColumnMissing, strconv.Quote(column)+" -> "+field)
+		}
+	}
+	for _, field := range []string{"title", "user_id"} {
+		if _, ok := columns[field]; !ok {
+			return nil, invalidImport(dto.MsgRequiredColumnMissing, field)
+		}
+	}
+
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvRowReader) Next() (int, ImportRow, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, ImportRow{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, ImportRow{}, &importLineError{desc: dto.Detail(dto.MsgInvalidCSVRow, parseErr.Err.Error())}
+	}
+	if err != nil {
+		return 0, ImportRow{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	value := func(field string) string {
+		if idx, ok := r.columns[field]; ok {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+	row := ImportRow{
+		Title:  value("title"),
+		Data:   value("data"),
+		Status: value("status"),
+		UserID: value("user_id"),
+	}
+	// теги в ячейке разделяются пробелами или запятыми
+	if tags := value("tags"); tags != "" {
+		row.Tags = strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == ' ' || r == ';' })
+	}
+	if due := value("due_at"); due != "" {
+		dueAt, err := time.Parse(time.RFC3339, due)
+		if err != nil {
+			return line, ImportRow{}, &importLineError{desc: dto.MsgInvalidDueAt}
+		}
+		row.DueAt = &dueAt
+	}
+	return line, row, nil
+}
+
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonRowReader) Next() (int, ImportRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		raw := bytes.TrimSpace(r.scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var row ImportRow
+		if err := json.Unmarshal(raw, &row); err != nil {
+			return r.line, ImportRow{}, &importLineError{desc: dto.Detail(dto.MsgInvalidJSONRow, err.Error())}
+		}
+		return r.line, row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return r.line + 1, ImportRow{}, invalidImport(dto.MsgInvalidNDJSON, fmt.Sprintf("line %d: %v", r.line+1, err))
+	}
+	return 0, ImportRow{}, io.EOF
+}
+
+// icsRowReader превращает каждую VTODO календаря в строку импорта пользователя userID
+type icsRowReader struct {
+	reader *ical.Reader
+	userID string
+}
+
+func (r *icsRowReader) Next() (int, ImportRow, error) {
+	line, todo, err := r.reader.NextTodo()
+	var parseErr *ical.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Line, ImportRow{}, &importLineError{desc: dto.Detail(dto.MsgInvalidCalendarEntry, parseErr.Err.Error())}
+	}
+	if err != nil {
+		return 0, ImportRow{}, err
+	}
+
+	row := ImportRow{
+		Title:  todo.Summary,
+		Data:   todo.Description,
+		Status: taskStatusFromICal(todo.Status),
+		UserID: r.userID,
+		DueAt:  todo.Due,
+	}
+	for _, category := range todo.Categories {
+		row.Tags = append(row.Tags, tagFromCategory(category))
+	}
+	return line, row, nil
+}
+
+func isImportField(field string) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportTasks загружает задачи из src; формат, не указанный в req, транспорт определяет заранее
+func (s *service) ImportTasks(ctx context.Context, req ImportRequest, src io.Reader) (*ImportReport, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	mapping, err := ParseImportMapping(req.Mapping)
+	if err != nil {
+		return nil, badFormat(err.Error())
+	}
+	if req.Format == "" {
+		req.Format = ImportFormatNDJSON
+	}
+	// пользователь загружает .ics только себе
+	principal := auth.FromContext(ctx)
+	if !principal.Admin || req.UserID == "" {
+		req.UserID = principal.UserID
+	}
+
+	opts := ImportOptions{
+		Format:  req.Format,
+		Mapping: mapping,
+		DryRun:  req.DryRun,
+		UserID:  req.UserID,
+	}
+	if !principal.Admin {
+		opts.Owner = principal.UserID
+	}
+
+	report, err := s.importer.Import(ctx, principal.Actor(), requestIDFromContext(ctx), src, opts)
+	if err != nil {
+		s.logger(ctx).Error("Failed to import tasks", zap.Error(err))
+		if errors.Is(err, ErrInvalidImport) {
+			return nil, badFormat(err.Error())
+		}
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"TemplatestPGSQL/pkg/i18n"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestImporterDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		opts       ImportOptions
+		wantTotal  int
+		wantValid  int
+		wantErrors []ImportLineError
+	}{
+		{
+			name: "CSV with mapping",
+			input: "Name,Owner,State,Labels\n" +
+				"First,1,new,#a #b\n" +
+				",1,new,\n" +
+				"Third,1,archived,\n" +
+				"Fourth,2,done,\n",
+			opts: ImportOptions{
+				Format:  ImportFormatCSV,
+				Mapping: map[string]string{"title": "Name", "user_id": "Owner", "status": "State", "tags": "Labels"},
+			},
+			wantTotal: 4,
+			wantValid: 1,
+			wantErrors: []ImportLineError{
+				{Line: 3, Error: dto.Error{Code: dto.FieldIncorrect, Desc: "Field is required: ImportRow.Title"}},
+				{Line: 4, Error: dto.Error{Code: dto.FieldIncorrect, Desc: "Field has unsupported value: ImportRow.Status"}},
+				{Line: 5, Error: dto.Error{Code: dto.NotFound, Desc: "user not found: 2"}},
+			},
+		},
+		{
+			name: "NDJSON",
+			input: `{"title":"First","user_id":"1","tags":["#a"]}` + "\n\n" +
+				`{"title":"Second","user_id":"1"` + "\n" +
+				`{"title":"Third","user_id":"x"}` + "\n",
+			opts:      ImportOptions{Format: ImportFormatNDJSON},
+			wantTotal: 3,
+			wantValid: 1,
+			wantErrors: []ImportLineError{
+				{Line: 3, Error: dto.Error{Code: dto.FieldBadFormat, Desc: "Invalid JSON line: unexpected end of JSON input"}},
+				{Line: 4, Error: dto.Error{Code: dto.FieldIncorrect, Desc: "Invalid int format: ImportRow.UserID"}},
+			},
+		},
+		{
+			name: "Owner with unnormalised IDs",
+			input: `{"title":"First","user_id":"01"}` + "\n" +
+				`{"title":"Second","user_id":"+1"}` + "\n" +
+				`{"title":"Third","user_id":"2"}` + "\n",
+			opts:      ImportOptions{Format: ImportFormatNDJSON, Owner: "1"},
+			wantTotal: 3,
+			wantValid: 2,
+			wantErrors: []ImportLineError{
+				{Line: 3, Error: dto.Error{Code: dto.NotFound, Desc: "user not found: 2"}},
+			},
+		},
+		{
+			name: "iCalendar",
+			input: "BEGIN:VCALENDAR\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:First\r\nCATEGORIES:Home Office\r\nDUE:20250301T090000Z\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:Second\r\nDUE:tomorrow\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n" +
+				"END:VCALENDAR\r\n",
+			opts:      ImportOptions{Format: ImportFormatICS, UserID: "1"},
+			wantTotal: 3,
+			wantValid: 1,
+			wantErrors: []ImportLineError{
+				{Line: 9, Error: dto.Error{Code: dto.FieldBadFormat, Desc: `Invalid calendar entry: DUE: invalid date "tomorrow"`}},
+				{Line: 11, Error: dto.Error{Code: dto.FieldIncorrect, Desc: "Field is required: ImportRow.Title"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			repository.On("GetExistingUserIDs", mock.Anything, mock.Anything).Return([]string{"1"}, nil)
+
+			tt.opts.DryRun = true
+			report, err := NewImporter(repository, zap.NewNop().Sugar()).
+				Import(context.Background(), "cli", "", strings.NewReader(tt.input), tt.opts)
+			require.NoError(t, err)
+
+			assert.True(t, report.DryRun)
+			assert.Equal(t, tt.wantTotal, report.Total)
+			assert.Equal(t, tt.wantValid, report.Valid)
+			assert.Zero(t, report.Imported)
+			assert.Equal(t, tt.wantErrors, report.Errors)
+			repository.AssertNotCalled(t, "CopyTasks", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestImporterLocalizesReport(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	repository.On("GetExistingUserIDs", mock.Anything, mock.Anything).Return([]string{"1"}, nil)
+
+	ctx := i18n.WithLanguage(context.Background(), "ru")
+	report, err := NewImporter(repository, zap.NewNop().Sugar()).Import(ctx, "cli", "",
+		strings.NewReader("{\"title\":\"First\",\"user_id\":\"2\"}\n{"), ImportOptions{Format: ImportFormatNDJSON, DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ImportLineError{
+		{Line: 2, Error: dto.Error{Code: dto.FieldBadFormat, Desc: "Некорректная строка JSON: unexpected end of JSON input"}},
+		{Line: 1, Error: dto.Error{Code: dto.NotFound, Desc: "пользователь не найден: 2"}},
+	}, report.Errors)
+}
+
+func TestImporterRejectsBadMapping(t *testing.T) {
+	_, err := NewImporter(mocks.NewRepository(t), zap.NewNop().Sugar()).Import(context.Background(), "cli", "",
+		strings.NewReader("title,owner\n"), ImportOptions{Format: ImportFormatCSV})
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
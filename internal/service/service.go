@@ -1,292 +1,387 @@
 package service
 
 import (
+	"TemplatestPGSQL/internal/auth"
 	"TemplatestPGSQL/internal/dto"
+	customLogger "TemplatestPGSQL/internal/logger"
 	repo2 "TemplatestPGSQL/internal/repo"
 	"TemplatestPGSQL/pkg/validator"
-	"encoding/json"
+	"context"
+	"crypto/subtle"
 	"errors"
-	"github.com/gofiber/fiber/v2"
+	"io"
+
 	"go.uber.org/zap"
 )
 
+// Service - доменный слой: принимает запросы, возвращает объекты и ошибки *Error для клиента.
+// Автор запроса берётся из контекста (auth.WithPrincipal), транспорт в сервис не попадает.
 type Service interface {
-	CreateUser(ctx *fiber.Ctx) error
-	CreateTask(ctx *fiber.Ctx) error
-	GetAllTasks(ctx *fiber.Ctx) error
-	GetTaskByID(ctx *fiber.Ctx) error
-	GetLastTaskByUserID(ctx *fiber.Ctx) error
-	GetAllTasksByUserID(ctx *fiber.Ctx) error
-	GetTasksByUserName(ctx *fiber.Ctx) error
-	UpdateStatusByID(ctx *fiber.Ctx) error
-	DeleteTaskByID(ctx *fiber.Ctx) error
+	CreateUser(ctx context.Context, req PostUserRequest) (*UserInfo, error)
+	GetUsers(ctx context.Context) ([]UserInfo, error)
+	DeleteUserByID(ctx context.Context, req RequestWithId) error
+	Login(ctx context.Context, req PostUserRequest) (*LoginResult, error)
+
+	CreateTask(ctx context.Context, req PostRequest) (*repo2.Task, error)
+	GetAllTasks(ctx context.Context, req TaskListRequest) ([]repo2.Task, error)
+	GetTaskByID(ctx context.Context, req RequestWithId) (*repo2.Task, error)
+	GetLastTaskByUserID(ctx context.Context, req RequestWithId) (*repo2.Task, error)
+	GetAllTasksByUserID(ctx context.Context, req RequestWithId) ([]repo2.Task, error)
+	GetTasksByUserName(ctx context.Context, req RequestWithUserName) ([]repo2.Task, error)
+	UpdateStatusByID(ctx context.Context, req UpdateRequest) error
+	DeleteTaskByID(ctx context.Context, req DeleteTaskRequest) error
+	BulkTasks(ctx context.Context, req BulkRequest) ([]BulkResult, error)
+	ImportTasks(ctx context.Context, req ImportRequest, src io.Reader) (*ImportReport, error)
+	ExportTasks(ctx context.Context, req ExportRequest) (*Download, error)
+	CalendarFeed(ctx context.Context, userID string, req CalendarFeedRequest) (*Download, error)
+	GetCalendarLink(ctx context.Context, req CalendarLinkRequest, baseURL string) (*CalendarLink, error)
+
+	GetTaskActivity(ctx context.Context, req RequestWithId) ([]repo2.AuditRecord, error)
+	GetAuditRecords(ctx context.Context, req AuditRequest) ([]repo2.AuditRecord, error)
+
+	CreateWebhook(ctx context.Context, req PostWebhookRequest) (*repo2.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]repo2.Webhook, error)
+	DeleteWebhookByID(ctx context.Context, req RequestWithId) error
+	GetWebhookDeliveries(ctx context.Context, req RequestWithId) ([]repo2.WebhookDelivery, error)
 }
 
 type service struct {
-	repo repo2.Repository
-	log  *zap.SugaredLogger
+	repo     repo2.Repository
+	log      *zap.SugaredLogger
+	signer   *auth.Signer
+	importer *Importer
 }
 
-func NewService(repo repo2.Repository, logger *zap.SugaredLogger) Service {
+func NewService(repo repo2.Repository, logger *zap.SugaredLogger, signer *auth.Signer) Service {
 	return &service{
-		repo: repo,
-		log:  logger,
+		repo:     repo,
+		log:      logger,
+		signer:   signer,
+		importer: NewImporter(repo, logger),
 	}
 }
 
-func (s *service) CreateTask(ctx *fiber.Ctx) error {
-	var obj PostRequest
-
-	// deserialize  JSON-request
-	if err := json.Unmarshal(ctx.Body(), &obj); err != nil {
-		s.log.Error("Invalid request body", zap.Error(err))
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+// validate проверяет запрос по тегам validate, ошибка уходит клиенту как FIELD_INCORRECT
+func (s *service) validate(ctx context.Context, req any) error {
+	if vErr := validator.Validate(ctx, req); vErr != nil {
+		s.logger(ctx).Error("Invalid request", zap.Error(vErr))
+		return &Error{Code: dto.FieldIncorrect, Desc: vErr.Error(), Fields: validator.Fields(vErr)}
 	}
+	return nil
+}
 
-	// validation
-	if vErr := validator.Validate(ctx.Context(), obj); vErr != nil {
-		s.log.Error("Invalid request data", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
-	}
+// logger - логгер запроса с его идентификатором, вне запроса - общий
+func (s *service) logger(ctx context.Context) *zap.SugaredLogger {
+	return customLogger.FromContext(ctx, s.log)
+}
 
-	// adds to memory
-	dataObj := repo2.Task{
-		DataObject: repo2.DataObject{
-			Title: obj.Title,
-			Data:  obj.Data,
-		},
-		UserID: obj.UserID,
+// fail пишет ошибку в лог; «не найдено» из репозитория становится ошибкой для клиента
+func (s *service) fail(ctx context.Context, msg string, err error) error {
+	s.logger(ctx).Error(msg, zap.Error(err))
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr
 	}
-	err := s.repo.CreateTask(ctx.Context(), dataObj)
-	if err != nil {
-		s.log.Error("Failed to insert object", zap.Error(err))
-		return dto.InternalServerError(ctx)
+	if errors.Is(err, dto.ErrNotFound) {
+		return notFound(dto.MsgNotFound)
 	}
-	s.log.Infof("object was appended %s", dataObj.Title)
+	return err
+}
 
-	// forms the answer
-	response := dto.Response{
-		Status: "success",
+// versionConflict - версия из запроса устарела, клиент получает текущее состояние задачи
+func (s *service) versionConflict(ctx context.Context, id string) error {
+	current, err := s.repo.GetTaskByID(ctx, id)
+	if err != nil {
+		return s.fail(ctx, "Failed to get task", err)
 	}
-
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	return &Error{Code: dto.PreconditionFailed, Desc: dto.MsgVersionOutdated, Data: current}
 }
 
-func (s *service) GetTasksByUserName(ctx *fiber.Ctx) error {
-	req := RequestWithUserName{Name: ctx.Params("username")}
+// checkOwner - задача или список пользователя ownerID для автора запроса: чужие объекты для пользователя
+// не существуют, поэтому отказ - NOT_FOUND с текстом desc
+func checkOwner(ctx context.Context, ownerID, desc string) error {
+	if !auth.FromContext(ctx).CanAccess(ownerID) {
+		return notFound(desc)
+	}
+	return nil
+}
 
+func (s *service) CreateTask(ctx context.Context, req PostRequest) (*repo2.Task, error) {
 	// Validation
-	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
-		s.log.Error("Invalid request", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	// пользователь создаёт задачи только себе
+	if err := checkOwner(ctx, req.UserID, dto.MsgUserNotFound); err != nil {
+		return nil, err
 	}
 
-	// Gets from memory
-	objPtr, err := s.repo.GetTasksByUserName(ctx.Context(), req.Name)
+	// Adds to memory
+	dataObj := repo2.Task{
+		DataObject: repo2.DataObject{
+			Title: req.Title,
+			Data:  req.Data,
+		},
+		UserID: req.UserID,
+		DueAt:  req.DueAt,
+	}
+	var created *repo2.Task
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		var err error
+		if created, err = r.CreateTask(ctx, dataObj); err != nil {
+			return err
+		}
+		return s.record(ctx, r, ActionCreate, EntityTask, created.ID, nil, created)
+	})
 	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
+		s.logger(ctx).Error("Failed to insert object", zap.Error(err))
 		if errors.Is(err, dto.ErrNotFound) {
-			return dto.NotFoundError(ctx, dto.NotFound, err.Error())
+			return nil, notFound(dto.MsgUserNotFound)
 		}
-		return dto.InternalServerError(ctx)
+		return nil, err
 	}
+	s.logger(ctx).Infof("object was appended %s", dataObj.Title)
 
-	// Forms answer
-	response := dto.Response{
-		Status: "success",
-		Data:   objPtr,
-	}
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	return created, nil
 }
 
-func (s *service) GetAllTasks(ctx *fiber.Ctx) error {
+func (s *service) GetTasksByUserName(ctx context.Context, req RequestWithUserName) ([]repo2.Task, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
 	// Gets from memory
-	obj, err := s.repo.GetAllTasks(ctx.Context())
+	tasks, err := s.repo.GetTasksByUserName(ctx, req.Name)
 	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
-		return dto.InternalServerError(ctx)
+		return nil, s.fail(ctx, "Failed to get task", err)
+	}
+	// все задачи принадлежат одному пользователю
+	if len(tasks) > 0 {
+		if err = checkOwner(ctx, tasks[0].UserID, dto.MsgUserNotFound); err != nil {
+			return nil, err
+		}
 	}
+	return tasks, nil
+}
 
-	// Forms answer
-	jsonData, err := json.Marshal(obj)
+func (s *service) GetAllTasks(ctx context.Context, req TaskListRequest) ([]repo2.Task, error) {
+	filter, err := s.taskFilter(ctx, req, req)
 	if err != nil {
-		s.log.Error("Failed to marshal response", zap.Error(err))
-		return dto.InternalServerError(ctx)
+		return nil, err
 	}
-	response := dto.Response{
-		Status: "success",
-		Data:   jsonData,
+
+	// Gets from memory
+	tasks, err := s.repo.GetAllTasks(ctx, filter)
+	if err != nil {
+		return nil, s.fail(ctx, "Failed to get task", err)
 	}
-	s.log.Info("all tasks was read and sent")
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	s.logger(ctx).Info("all tasks was read and sent")
+	return tasks, nil
 }
 
-func (s *service) GetTaskByID(ctx *fiber.Ctx) error {
-	req := RequestWithId{ID: ctx.Params("id")}
-
+func (s *service) GetTaskByID(ctx context.Context, req RequestWithId) (*repo2.Task, error) {
 	// Validation
-	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
-		s.log.Error("Invalid request id", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
 	}
 
 	// Gets from memory
-	objPtr, err := s.repo.GetTaskByID(ctx.Context(), req.ID)
+	task, err := s.repo.GetTaskByID(ctx, req.ID)
 	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
-		return dto.InternalServerError(ctx)
+		return nil, s.fail(ctx, "Failed to get task", err)
 	}
-
-	// Forms answer
-	response := dto.Response{
-		Status: "success",
-		Data:   objPtr,
+	if err = checkOwner(ctx, task.UserID, dto.MsgTaskNotFound); err != nil {
+		return nil, err
 	}
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	return task, nil
 }
 
-func (s *service) GetLastTaskByUserID(ctx *fiber.Ctx) error {
-	req := RequestWithId{ID: ctx.Params("id")}
-
+func (s *service) GetLastTaskByUserID(ctx context.Context, req RequestWithId) (*repo2.Task, error) {
 	// Validation
-	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
-		s.log.Error("Invalid request id", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := checkOwner(ctx, req.ID, dto.MsgUserNotFound); err != nil {
+		return nil, err
 	}
 
 	// Gets from memory
-	objPtr, err := s.repo.GetLastTaskByUserID(ctx.Context(), req.ID)
+	task, err := s.repo.GetLastTaskByUserID(ctx, req.ID)
 	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
-		if errors.Is(err, dto.ErrNotFound) {
-			return dto.NotFoundError(ctx, dto.NotFound, err.Error())
-		}
-		return dto.InternalServerError(ctx)
+		return nil, s.fail(ctx, "Failed to get task", err)
 	}
+	return task, nil
+}
 
-	// Forms answer
-	response := dto.Response{
-		Status: "success",
-		Data:   objPtr,
+func (s *service) GetAllTasksByUserID(ctx context.Context, req RequestWithId) ([]repo2.Task, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := checkOwner(ctx, req.ID, dto.MsgUserNotFound); err != nil {
+		return nil, err
 	}
-	return ctx.Status(fiber.StatusOK).JSON(response)
-}
 
-func (s *service) GetAllTasksByUserID(ctx *fiber.Ctx) error {
-	req := RequestWithId{ID: ctx.Params("id")}
+	tasks, err := s.repo.GetAllTasksByUserID(ctx, req.ID)
+	if err != nil {
+		return nil, s.fail(ctx, "Failed to get task", err)
+	}
+	s.logger(ctx).Info("whole memory was read and sent")
+	return tasks, nil
+}
 
+func (s *service) UpdateStatusByID(ctx context.Context, req UpdateRequest) error {
 	// Validation
-	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
-		s.log.Error("Invalid request id", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	if err := s.validate(ctx, req); err != nil {
+		return err
 	}
 
-	obj, err := s.repo.GetAllTasksByUserID(ctx.Context(), req.ID)
-	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
-		if errors.Is(err, dto.ErrNotFound) {
-			return dto.NotFoundError(ctx, dto.NotFound, err.Error())
+	// Updates memory
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		before, err := r.GetTaskByID(ctx, req.ID)
+		if err != nil {
+			return err
 		}
-		return dto.InternalServerError(ctx)
+		if err = checkOwner(ctx, before.UserID, dto.MsgTaskNotFound); err != nil {
+			return err
+		}
+		if req.Version != 0 && before.Version != req.Version {
+			return dto.ErrVersionConflict
+		}
+		if err = r.UpdateStatusByID(ctx, req.ID, req.Status, req.Version); err != nil {
+			return err
+		}
+		after := *before
+		after.Status = req.Status
+		after.Version++
+		return s.record(ctx, r, ActionStatusChange, EntityTask, req.ID, before, &after)
+	})
+	if errors.Is(err, dto.ErrVersionConflict) {
+		return s.versionConflict(ctx, req.ID)
 	}
-
-	jsonData, err := json.Marshal(obj)
 	if err != nil {
-		s.log.Error("Failed to marshal response", zap.Error(err))
-		return dto.InternalServerError(ctx)
-	}
-	response := dto.Response{
-		Status: "success",
-		Data:   jsonData,
+		return s.fail(ctx, "Failed to get task", err)
 	}
-	s.log.Info("whole memory was read and sent")
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	return nil
 }
 
-func (s *service) UpdateStatusByID(ctx *fiber.Ctx) error {
-	req := UpdateRequest{ID: ctx.Params("id"), Status: ctx.Params("status")}
-
+func (s *service) DeleteTaskByID(ctx context.Context, req DeleteTaskRequest) error {
 	// Validation
-	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
-		s.log.Error("Invalid request id", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	if err := s.validate(ctx, req); err != nil {
+		return err
 	}
 
-	// Gets from memory
-	err := s.repo.UpdateStatusByID(ctx.Context(), req.ID, req.Status)
-	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
-		if errors.Is(err, dto.ErrNotFound) {
-			return dto.NotFoundError(ctx, dto.NotFound, err.Error())
+	// Deletes from memory
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		before, err := r.GetTaskByID(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		if err = checkOwner(ctx, before.UserID, dto.MsgTaskNotFound); err != nil {
+			return err
 		}
-		return dto.InternalServerError(ctx)
+		if req.Version != 0 && before.Version != req.Version {
+			return dto.ErrVersionConflict
+		}
+		if err = r.DeleteTaskByID(ctx, req.ID, req.Version); err != nil {
+			return err
+		}
+		return s.record(ctx, r, ActionDelete, EntityTask, req.ID, before, nil)
+	})
+	if errors.Is(err, dto.ErrVersionConflict) {
+		return s.versionConflict(ctx, req.ID)
 	}
-
-	// Forms answer
-	response := dto.Response{
-		Status: "success",
+	if err != nil {
+		return s.fail(ctx, "Failed to get task", err)
 	}
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	return nil
 }
 
-func (s *service) DeleteTaskByID(ctx *fiber.Ctx) error {
-	req := UpdateRequest{ID: ctx.Params("id"), Status: ctx.Params("status")}
-
+func (s *service) CreateUser(ctx context.Context, req PostUserRequest) (*UserInfo, error) {
 	// Validation
-	if vErr := validator.Validate(ctx.Context(), req); vErr != nil {
-		s.log.Error("Invalid request id", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
 	}
 
+	// Adds to memory
+	user := repo2.User{
+		Name:     req.Name,
+		Password: req.Password,
+	}
+	var created *repo2.User
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		var err error
+		if created, err = r.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.record(ctx, r, ActionCreate, EntityUser, created.ID, nil, created)
+	})
+	if err != nil {
+		s.logger(ctx).Error("Failed to insert object", zap.Error(err))
+		return nil, err
+	}
+	s.logger(ctx).Infof("object was appended %s", user.Name)
+
+	return userInfo(*created), nil
+}
+
+func (s *service) GetUsers(ctx context.Context) ([]UserInfo, error) {
 	// Gets from memory
-	err := s.repo.DeleteTaskByID(ctx.Context(), req.ID)
+	users, err := s.repo.GetUsers(ctx)
 	if err != nil {
-		s.log.Error("Failed to get task", zap.Error(err))
-		if errors.Is(err, dto.ErrNotFound) {
-			return dto.NotFoundError(ctx, dto.NotFound, err.Error())
-		}
-		return dto.InternalServerError(ctx)
+		return nil, s.fail(ctx, "Failed to get users", err)
 	}
 
-	// Forms answer
-	response := dto.Response{
-		Status: "success",
+	infos := make([]UserInfo, 0, len(users))
+	for _, user := range users {
+		infos = append(infos, *userInfo(user))
 	}
-	return ctx.Status(fiber.StatusOK).JSON(response)
+	return infos, nil
 }
 
-func (s *service) CreateUser(ctx *fiber.Ctx) error {
-	var obj PostUserRequest
+func (s *service) DeleteUserByID(ctx context.Context, req RequestWithId) error {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return err
+	}
 
-	// deserialize  JSON-request
-	if err := json.Unmarshal(ctx.Body(), &obj); err != nil {
-		s.log.Error("Invalid request body", zap.Error(err))
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	// Deletes from memory, tasks of the user are deleted by cascade
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		before, err := r.GetUserByID(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		if err = r.DeleteUserByID(ctx, req.ID); err != nil {
+			return err
+		}
+		return s.record(ctx, r, ActionDelete, EntityUser, req.ID, before, nil)
+	})
+	if err != nil {
+		return s.fail(ctx, "Failed to delete user", err)
 	}
+	return nil
+}
 
-	// validation
-	if vErr := validator.Validate(ctx.Context(), obj); vErr != nil {
-		s.log.Error("Invalid request data", zap.Error(vErr))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, vErr.Error())
+func (s *service) Login(ctx context.Context, req PostUserRequest) (*LoginResult, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
 	}
 
-	// adds to memory
-	user := repo2.User{
-		Name:     obj.Name,
-		Password: obj.Password,
+	// Checks credentials
+	user, err := s.repo.GetUserByName(ctx, req.Name)
+	if err != nil && !errors.Is(err, dto.ErrNotFound) {
+		s.logger(ctx).Error("Failed to get user", zap.Error(err))
+		return nil, err
 	}
-	err := s.repo.CreateUser(ctx.Context(), user)
-	if err != nil {
-		s.log.Error("Failed to insert object", zap.Error(err))
-		return dto.InternalServerError(ctx)
+	if user == nil || subtle.ConstantTimeCompare([]byte(user.Password), []byte(req.Password)) != 1 {
+		return nil, ErrUnauthorized
 	}
-	s.log.Infof("object was appended %s", user.Name)
 
-	// forms the answer
-	response := dto.Response{
-		Status: "success",
-		Data:   map[string]int{"task_id": 1},
-	}
+	return &LoginResult{Token: s.signer.Issue(user.ID), UserID: user.ID}, nil
+}
 
-	return ctx.Status(fiber.StatusOK).JSON(response)
+func userInfo(user repo2.User) *UserInfo {
+	return &UserInfo{ID: user.ID, Name: user.Name, CreatedAt: user.CreatedAt}
 }
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	repo2 "TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTaskOwnership(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	inTx(repository)
+	foreign := &repo2.Task{DataObject: repo2.DataObject{ID: "1", Status: "new", Version: 1}, UserID: "4"}
+	repository.On("GetTaskByID", mock.Anything, "1").Return(foreign, nil)
+	repository.On("GetTasksByUserName", mock.Anything, "other").Return([]repo2.Task{*foreign}, nil)
+	svc := newTestService(repository)
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: "3"})
+
+	// чужая задача для пользователя не существует
+	_, err := svc.GetTaskByID(ctx, RequestWithId{ID: "1"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.GetAllTasksByUserID(ctx, RequestWithId{ID: "4"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.GetLastTaskByUserID(ctx, RequestWithId{ID: "4"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.GetTasksByUserName(ctx, RequestWithUserName{Name: "other"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	err = svc.UpdateStatusByID(ctx, UpdateRequest{ID: "1", Status: "done", Version: 1})
+	assert.ErrorIs(t, err, ErrNotFound)
+	err = svc.DeleteTaskByID(ctx, DeleteTaskRequest{ID: "1", Version: 1})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.CreateTask(ctx, PostRequest{Title: "t", UserID: "4"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	repository.AssertNotCalled(t, "UpdateStatusByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repository.AssertNotCalled(t, "DeleteTaskByID", mock.Anything, mock.Anything, mock.Anything)
+	repository.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+
+	// администратору доступны задачи всех пользователей
+	task, err := svc.GetTaskByID(auth.WithPrincipal(context.Background(), auth.Principal{Admin: true}), RequestWithId{ID: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", task.UserID)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	repo2 "TemplatestPGSQL/internal/repo"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const defaultDeliveriesLimit = 100
+
+func (s *service) CreateWebhook(ctx context.Context, req PostWebhookRequest) (*repo2.Webhook, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// Adds to memory
+	webhook := repo2.Webhook{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	}
+	var created *repo2.Webhook
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		var err error
+		if created, err = r.CreateWebhook(ctx, webhook); err != nil {
+			return err
+		}
+		return s.record(ctx, r, ActionCreate, EntityWebhook, created.ID, nil, created)
+	})
+	if err != nil {
+		s.logger(ctx).Error("Failed to insert webhook", zap.Error(err))
+		return nil, err
+	}
+	s.logger(ctx).Infof("webhook was added %s", created.URL)
+
+	return created, nil
+}
+
+func (s *service) GetWebhooks(ctx context.Context) ([]repo2.Webhook, error) {
+	// Gets from memory
+	webhooks, err := s.repo.GetWebhooks(ctx)
+	if err != nil {
+		return nil, s.fail(ctx, "Failed to get webhooks", err)
+	}
+	return webhooks, nil
+}
+
+func (s *service) DeleteWebhookByID(ctx context.Context, req RequestWithId) error {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return err
+	}
+
+	// Deletes from memory
+	err := s.repo.InTx(ctx, func(r repo2.Repository) error {
+		if err := r.DeleteWebhookByID(ctx, req.ID); err != nil {
+			return err
+		}
+		return s.record(ctx, r, ActionDelete, EntityWebhook, req.ID, nil, nil)
+	})
+	if err != nil {
+		return s.fail(ctx, "Failed to delete webhook", err)
+	}
+	return nil
+}
+
+func (s *service) GetWebhookDeliveries(ctx context.Context, req RequestWithId) ([]repo2.WebhookDelivery, error) {
+	// Validation
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// Gets from memory
+	deliveries, err := s.repo.GetWebhookDeliveries(ctx, req.ID, defaultDeliveriesLimit)
+	if err != nil {
+		return nil, s.fail(ctx, "Failed to get webhook deliveries", err)
+	}
+	return deliveries, nil
+}
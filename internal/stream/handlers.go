@@ -0,0 +1,126 @@
+package stream
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/repo"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+// SSE отдаёт изменения задач в формате text/event-stream
+func (h *Hub) SSE() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		sub := h.subscribe(auth.FromFiber(ctx))
+
+		ctx.Set(fiber.HeaderContentType, "text/event-stream")
+		ctx.Set(fiber.HeaderCacheControl, "no-cache")
+		ctx.Set(fiber.HeaderConnection, "keep-alive")
+		ctx.Set("X-Accel-Buffering", "no")
+
+		ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer h.unsubscribe(sub)
+
+			heartbeat := time.NewTicker(heartbeatInterval)
+			defer heartbeat.Stop()
+
+			// заголовки уходят клиенту вместе с первыми байтами тела, поэтому поток открывается комментарием,
+			// иначе клиент ждал бы заголовков до первого события или heartbeat
+			_, _ = fmt.Fprint(w, ": connected\n\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			for {
+				select {
+				case <-h.done:
+					return
+				case event := <-sub.events:
+					raw, err := json.Marshal(event)
+					if err != nil {
+						h.log.Error("Failed to marshal task event", zap.Error(err))
+						continue
+					}
+					_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, raw)
+				case <-heartbeat.C:
+					_, _ = fmt.Fprint(w, ": ping\n\n")
+				}
+
+				// ошибка записи означает, что клиент отключился
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+// WebSocket отдаёт изменения задач JSON-сообщениями по WebSocket
+func (h *Hub) WebSocket() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(ctx) {
+			return fiber.ErrUpgradeRequired
+		}
+
+		principal := auth.FromFiber(ctx)
+		return websocket.New(func(conn *websocket.Conn) {
+			h.serveWebSocket(conn, principal)
+		})(ctx)
+	}
+}
+
+func (h *Hub) serveWebSocket(conn *websocket.Conn, principal auth.Principal) {
+	sub := h.subscribe(principal)
+	defer h.unsubscribe(sub)
+
+	// входящие сообщения не ожидаются, чтение нужно только чтобы заметить закрытие
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-h.done:
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown"), time.Now().Add(writeTimeout))
+			return
+		case <-closed:
+			return
+		case event := <-sub.events:
+			err = h.writeEvent(conn, event)
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (h *Hub) writeEvent(conn *websocket.Conn, event repo.TaskEvent) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(event)
+}
@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/repo"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Пакет потоковой раздачи изменений задач (SSE и WebSocket).
+// События приходят через PostgreSQL LISTEN/NOTIFY, поэтому подписчик любого экземпляра
+// получает изменения, сделанные в любом другом.
+
+const (
+	subscriberBuffer = 64
+	reconnectDelay   = time.Second
+)
+
+// Listener - источник уведомлений, реализуется репозиторием
+type Listener interface {
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
+}
+
+type subscriber struct {
+	principal auth.Principal
+	events    chan repo.TaskEvent
+}
+
+type Hub struct {
+	listener Listener
+	log      *zap.SugaredLogger
+
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+
+	// закрывается по завершении Run, чтобы открытые потоки не держали остановку сервера
+	done chan struct{}
+}
+
+func NewHub(listener Listener, logger *zap.SugaredLogger) *Hub {
+	return &Hub{
+		listener:    listener,
+		log:         logger,
+		subscribers: make(map[*subscriber]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Run слушает канал задач и переподключается при разрыве, пока ctx не отменён
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+
+	for {
+		err := h.listener.Listen(ctx, repo.TaskEventsChannel, h.broadcast)
+		if ctx.Err() != nil {
+			return
+		}
+		h.log.Error("Task events listener stopped", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (h *Hub) broadcast(payload string) {
+	var event repo.TaskEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		h.log.Error("Invalid task event", zap.Error(err))
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if !sub.principal.CanAccess(event.UserID) {
+			continue
+		}
+		// медленный подписчик не должен задерживать остальных
+		select {
+		case sub.events <- event:
+		default:
+			h.log.Warnf("task event %s for task %s dropped for slow subscriber", event.Type, event.TaskID)
+		}
+	}
+}
+
+func (h *Hub) subscribe(principal auth.Principal) *subscriber {
+	sub := &subscriber{
+		principal: principal,
+		events:    make(chan repo.TaskEvent, subscriberBuffer),
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *Hub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+}
+
+// Subscribe подписывает на изменения задач, доступных principal, для транспортов вне пакета (gRPC).
+// Возвращённую функцию нужно вызвать, когда поток закрыт.
+func (h *Hub) Subscribe(principal auth.Principal) (<-chan repo.TaskEvent, func()) {
+	sub := h.subscribe(principal)
+	return sub.events, func() { h.unsubscribe(sub) }
+}
+
+// Done закрывается, когда хаб остановлен и открытые потоки нужно завершить
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
@@ -0,0 +1,29 @@
+package stream
+
+import (
+	"TemplatestPGSQL/internal/auth"
+	"TemplatestPGSQL/internal/repo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestHubBroadcastFiltersBySubscriber(t *testing.T) {
+	h := NewHub(nil, zap.NewNop().Sugar())
+	admin := h.subscribe(auth.Principal{Admin: true})
+	owner := h.subscribe(auth.Principal{UserID: "1"})
+	stranger := h.subscribe(auth.Principal{UserID: "2"})
+
+	h.broadcast(`{"type":"task.created","task_id":"10","user_id":"1","task":{"id":"10"}}`)
+
+	want := repo.TaskEvent{Type: "task.created", TaskID: "10", UserID: "1", Task: []byte(`{"id":"10"}`)}
+	assert.Equal(t, want, <-admin.events)
+	assert.Equal(t, want, <-owner.events)
+	assert.Empty(t, stranger.events)
+
+	h.unsubscribe(owner)
+	h.broadcast(`{"type":"task.deleted","task_id":"10","user_id":"1"}`)
+	assert.Len(t, admin.events, 1)
+	assert.Empty(t, owner.events)
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"TemplatestPGSQL/internal/config"
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/tracing"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Пакет рассылки событий из outbox_events по подпискам webhooks
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	maxErrorLen = 512
+)
+
+// Envelope - тело запроса, отправляемого подписчику
+type Envelope struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type Dispatcher struct {
+	repo   repo.Repository
+	client *http.Client
+	cfg    config.Webhook
+	log    *zap.SugaredLogger
+}
+
+func NewDispatcher(repository repo.Repository, cfg config.Webhook, logger *zap.SugaredLogger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repository,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		log:    logger,
+	}
+}
+
+// Run разбирает outbox и отправляет доставки, пока ctx не отменён
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Tick(ctx); err != nil {
+				d.log.Error("Failed to dispatch webhooks", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Tick выполняет один проход: outbox -> доставки -> отправка
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	if _, err := d.repo.FanOutOutboxEvents(ctx, d.cfg.BatchSize); err != nil {
+		return err
+	}
+
+	// аренда покрывает все отправки пачки, иначе доставку может взять другой экземпляр
+	lease := d.cfg.Timeout*time.Duration(d.cfg.BatchSize) + time.Second
+	jobs, err := d.repo.ClaimWebhookDeliveries(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		result := d.deliver(ctx, job)
+		if err = d.repo.UpdateWebhookDelivery(ctx, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job repo.DeliveryJob) repo.DeliveryResult {
+	result := repo.DeliveryResult{ID: job.ID}
+
+	// спан доставки - дочерний к запросу, вызвавшему событие
+	ctx, span := tracing.Tracer().Start(tracing.WithTraceParent(ctx, job.TraceParent), "webhook "+job.EventType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.Int64("webhook.delivery_id", job.ID),
+			attribute.Int("webhook.attempt", job.Attempts),
+			semconv.URLFull(job.URL),
+		),
+	)
+	defer span.End()
+
+	code, err := d.send(ctx, job)
+	result.ResponseCode = code
+	if code != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+	}
+	if err == nil {
+		result.Status = repo.DeliveryDelivered
+		result.NextAttemptAt = time.Now()
+		return result
+	}
+
+	span.SetStatus(codes.Error, err.Error())
+	result.Error = err.Error()
+	if len(result.Error) > maxErrorLen {
+		result.Error = result.Error[:maxErrorLen]
+	}
+	if job.Attempts >= d.cfg.MaxAttempts {
+		result.Status = repo.DeliveryDead
+		result.NextAttemptAt = time.Now()
+		d.log.Warnf("webhook delivery %d is dead after %d attempts: %s", job.ID, job.Attempts, result.Error)
+		return result
+	}
+
+	result.Status = repo.DeliveryPending
+	result.NextAttemptAt = time.Now().Add(Backoff(d.cfg.BackoffBase, d.cfg.BackoffMax, job.Attempts))
+	return result
+}
+
+func (d *Dispatcher) send(ctx context.Context, job repo.DeliveryJob) (int, error) {
+	body, err := json.Marshal(Envelope{
+		ID:         job.EventID,
+		Type:       job.EventType,
+		OccurredAt: job.OccurredAt,
+		Data:       job.Payload,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to marshal event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, job.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(job.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(job.Secret, time.Now(), body))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign возвращает значение заголовка подписи вида t=<unix>,v1=<hex hmac-sha256("<unix>.<body>")>
+func Sign(secret string, ts time.Time, body []byte) string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff - экспоненциальная задержка перед повтором: base * 2^(attempt-1), не больше max
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return min(delay, max)
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"TemplatestPGSQL/internal/config"
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/repo/mocks"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+)
+
+const testSecret = "0123456789abcdef"
+
+var testConfig = config.Webhook{
+	PollInterval: time.Second,
+	BatchSize:    10,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BackoffBase:  time.Second,
+	BackoffMax:   time.Minute,
+}
+
+func TestDispatcherTick(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		attempts   int
+		wantStatus string
+		wantRetry  bool
+	}{
+		{name: "Delivered", status: http.StatusNoContent, attempts: 1, wantStatus: repo.DeliveryDelivered},
+		{name: "Retried on error", status: http.StatusBadGateway, attempts: 1, wantStatus: repo.DeliveryPending, wantRetry: true},
+		{name: "Dead after last attempt", status: http.StatusBadGateway, attempts: 3, wantStatus: repo.DeliveryDead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			job := repo.DeliveryJob{
+				ID:        7,
+				Attempts:  tt.attempts,
+				URL:       receiver.URL,
+				Secret:    testSecret,
+				EventID:   42,
+				EventType: "task.created",
+				Payload:   json.RawMessage(`{"id":"1"}`),
+			}
+
+			repository := mocks.NewRepository(t)
+			repository.On("FanOutOutboxEvents", mock.Anything, testConfig.BatchSize).Return(int64(1), nil)
+			repository.On("ClaimWebhookDeliveries", mock.Anything, testConfig.BatchSize, mock.Anything).
+				Return([]repo.DeliveryJob{job}, nil)
+			var result repo.DeliveryResult
+			repository.On("UpdateWebhookDelivery", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { result = args.Get(1).(repo.DeliveryResult) }).
+				Return(nil)
+
+			d := NewDispatcher(repository, testConfig, zap.NewNop().Sugar())
+			require.NoError(t, d.Tick(context.Background()))
+
+			require.NotNil(t, received)
+			assert.Equal(t, "task.created", received.Header.Get(EventHeader))
+			assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+			assertSignature(t, received.Header.Get(SignatureHeader), body)
+
+			var envelope Envelope
+			require.NoError(t, json.Unmarshal(body, &envelope))
+			assert.Equal(t, int64(42), envelope.ID)
+			assert.JSONEq(t, `{"id":"1"}`, string(envelope.Data))
+
+			assert.Equal(t, int64(7), result.ID)
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.status, result.ResponseCode)
+			if tt.wantRetry {
+				assert.WithinDuration(t, time.Now().Add(testConfig.BackoffBase), result.NextAttemptAt, 500*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestDispatcherPropagatesTrace(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	var traceParent string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	job := repo.DeliveryJob{
+		ID:          7,
+		Attempts:    1,
+		URL:         receiver.URL,
+		Secret:      testSecret,
+		EventType:   "task.created",
+		Payload:     json.RawMessage(`{}`),
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	d := NewDispatcher(mocks.NewRepository(t), testConfig, zap.NewNop().Sugar())
+	result := d.deliver(context.Background(), job)
+
+	assert.Equal(t, repo.DeliveryDelivered, result.Status)
+	// получатель видит трассировку запроса, вызвавшего событие, со спаном доставки
+	require.NotEmpty(t, traceParent)
+	assert.True(t, strings.HasPrefix(traceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	assert.NotEqual(t, job.TraceParent, traceParent)
+}
+
+func assertSignature(t *testing.T, header string, body []byte) {
+	t.Helper()
+	ts, _, ok := strings.Cut(strings.TrimPrefix(header, "t="), ",")
+	require.True(t, ok, "malformed signature %q", header)
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign(testSecret, time.Unix(unix, 0), body), header)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 8*time.Second, Backoff(time.Second, time.Minute, 4))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 20))
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Пакет миграций схемы PostgreSQL в формате golang-migrate: NNNNNN_name.up.sql.
+// InitTables репозитория применяет их к базе при запуске сервиса
+
+//go:embed postgres/*.up.sql
+var files embed.FS
+
+// Migration - одна миграция схемы
+type Migration struct {
+	Version int64
+	// Name - имя файла: 000001_task.up.sql
+	Name string
+	SQL  string
+}
+
+// All - миграции по возрастанию версии
+func All() []Migration {
+	entries, err := fs.ReadDir(files, "postgres")
+	if err != nil {
+		panic(err)
+	}
+
+	all := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			panic("migration " + entry.Name() + " has no version prefix")
+		}
+		sql, err := files.ReadFile(path.Join("postgres", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		all = append(all, Migration{Version: version, Name: entry.Name(), SQL: string(sql)})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all
+}
+
+// Version - номер последней миграции, которого ожидает сервис
+func Version() int64 {
+	var latest int64
+	for _, migration := range All() {
+		latest = max(latest, migration.Version)
+	}
+	return latest
+}
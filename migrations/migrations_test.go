@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVersion проверяет, что миграции пронумерованы подряд и последняя из них - ожидаемая версия
+func TestVersion(t *testing.T) {
+	entries, err := fs.ReadDir(files, "postgres")
+	require.NoError(t, err)
+	assert.EqualValues(t, len(entries), Version())
+
+	for i, migration := range All() {
+		assert.EqualValues(t, i+1, migration.Version, migration.Name)
+		assert.NotEmpty(t, strings.TrimSpace(migration.SQL), migration.Name)
+	}
+}
@@ -0,0 +1,22 @@
+CREATE TABLE audit_log (
+                       id BIGSERIAL PRIMARY KEY,
+                       actor TEXT NOT NULL,
+                       action TEXT NOT NULL,
+                       entity TEXT NOT NULL,
+                       entity_id TEXT NOT NULL,
+                       diff JSONB NOT NULL DEFAULT '{}',
+                       request_id TEXT NOT NULL DEFAULT '',
+                       created_at TIMESTAMP DEFAULT now()
+);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor);
+
+-- журнал только дописывается: любые UPDATE и DELETE отклоняются
+CREATE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_immutable BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
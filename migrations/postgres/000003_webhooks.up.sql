@@ -0,0 +1,31 @@
+CREATE TABLE outbox_events (
+                       id BIGSERIAL PRIMARY KEY,
+                       event_type TEXT NOT NULL,
+                       payload JSONB NOT NULL,
+                       created_at TIMESTAMP DEFAULT now(),
+                       dispatched_at TIMESTAMP
+);
+CREATE INDEX idx_outbox_events_pending ON outbox_events(id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE webhooks (
+                       id SERIAL PRIMARY KEY,
+                       url TEXT NOT NULL,
+                       event_types TEXT[] NOT NULL,
+                       secret TEXT NOT NULL,
+                       created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries (
+                       id BIGSERIAL PRIMARY KEY,
+                       webhook_id INT REFERENCES webhooks(id) ON DELETE CASCADE,
+                       event_id BIGINT REFERENCES outbox_events(id),
+                       status TEXT CHECK (status IN ('pending', 'delivered', 'dead')) DEFAULT 'pending',
+                       attempts INT NOT NULL DEFAULT 0,
+                       response_code INT NOT NULL DEFAULT 0,
+                       last_error TEXT NOT NULL DEFAULT '',
+                       next_attempt_at TIMESTAMP DEFAULT now(),
+                       created_at TIMESTAMP DEFAULT now(),
+                       updated_at TIMESTAMP DEFAULT now()
+);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
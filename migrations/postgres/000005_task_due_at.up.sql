@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ;
+
+CREATE INDEX idx_tasks_due_at ON tasks(user_id, due_at) WHERE due_at IS NOT NULL;
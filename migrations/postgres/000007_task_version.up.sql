@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+UPDATE tasks SET updated_at = created_at WHERE created_at IS NOT NULL;
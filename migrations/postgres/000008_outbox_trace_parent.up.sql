@@ -0,0 +1 @@
+ALTER TABLE outbox_events ADD COLUMN trace_parent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+CREATE TABLE rate_limits (
+    key TEXT NOT NULL PRIMARY KEY,
+    -- жетоны на момент updated_at, пополнение считается при обращении
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+-- тип сохранённого ответа, повтор отдаётся с ним же
+ALTER TABLE idempotency_keys ADD COLUMN content_type TEXT NOT NULL DEFAULT '';
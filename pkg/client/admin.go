@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// GetAuditRecords возвращает одну страницу журнала аудита, для обхода всех страниц есть AuditRecords
+func (c *Client) GetAuditRecords(ctx context.Context, filter AuditRequest) ([]AuditRecord, error) {
+	req := newRequest(http.MethodGet, "/v1/audit")
+	req.query = queryValues(filter)
+	var records []AuditRecord
+	return records, c.call(ctx, req, &records)
+}
+
+// AuditRecords обходит журнал аудита страницами filter.Limit (по умолчанию DefaultPageSize)
+func (c *Client) AuditRecords(ctx context.Context, filter AuditRequest) iter.Seq2[AuditRecord, error] {
+	return paginate(filter.Limit, filter.Offset, func(limit, offset int) ([]AuditRecord, error) {
+		filter.Limit, filter.Offset = limit, offset
+		return c.GetAuditRecords(ctx, filter)
+	})
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, webhook CreateWebhookRequest) (*Webhook, error) {
+	req, err := newRequest(http.MethodPost, "/v1/webhooks").withJSON(webhook)
+	if err != nil {
+		return nil, err
+	}
+	var created Webhook
+	if err = c.call(ctx, req, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	return webhooks, c.call(ctx, newRequest(http.MethodGet, "/v1/webhooks"), &webhooks)
+}
+
+func (c *Client) DeleteWebhookByID(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, "/v1/webhooks/"+url.PathEscape(id)), nil)
+}
+
+func (c *Client) GetWebhookDeliveries(ctx context.Context, id string) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	return deliveries, c.call(ctx, newRequest(http.MethodGet, "/v1/webhooks/"+url.PathEscape(id)+"/deliveries"), &deliveries)
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// GetCalendarLink возвращает адрес ленты iCalendar; userID нужен только администратору
+func (c *Client) GetCalendarLink(ctx context.Context, userID string) (*CalendarLink, error) {
+	req := newRequest(http.MethodGet, "/v1/calendar")
+	if userID != "" {
+		req.query.Set("user_id", userID)
+	}
+
+	var link CalendarLink
+	if err := c.call(ctx, req, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CalendarFeed возвращает ленту iCalendar пользователя по токену ленты из feed.Token
+func (c *Client) CalendarFeed(ctx context.Context, userID string, feed CalendarFeedRequest) (io.ReadCloser, error) {
+	req := newRequest(http.MethodGet, "/calendar/"+url.PathEscape(userID)+"/tasks.ics")
+	req.query = queryValues(feed)
+	req.public = true
+	return c.stream(ctx, req)
+}
@@ -0,0 +1,330 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Пакет типизированного клиента HTTP API сервиса задач
+
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxRetries  = 3
+	DefaultBackoffBase = 200 * time.Millisecond
+	DefaultBackoffMax  = 5 * time.Second
+	DefaultPageSize    = 100
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      func(ctx context.Context) (string, error)
+	timeout    time.Duration
+
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+type Option func(c *Client)
+
+// WithToken добавляет ко всем запросам заголовок Authorization: Bearer <token>
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = func(context.Context) (string, error) { return token, nil }
+	}
+}
+
+// WithTokenSource берёт токен перед каждым запросом, например чтобы обновлять истёкший
+func WithTokenSource(source func(ctx context.Context) (string, error)) Option {
+	return func(c *Client) {
+		c.token = source
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout задаёт срок запроса, если у контекста вызова нет своего; 0 - без срока
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry задаёт число повторов GET, PUT и DELETE при ответах 5xx и 429 и сетевых ошибках
+// и границы экспоненциальной задержки
+func WithRetry(maxRetries int, base, max time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries, c.backoffBase, c.backoffMax = maxRetries, base, max
+	}
+}
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base URL")
+	}
+
+	c := &Client{
+		baseURL:     u,
+		httpClient:  http.DefaultClient,
+		timeout:     DefaultTimeout,
+		maxRetries:  DefaultMaxRetries,
+		backoffBase: DefaultBackoffBase,
+		backoffMax:  DefaultBackoffMax,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request - описание вызова; body повторно используется при повторах, поэтому хранится целиком
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	header      http.Header
+	// stream - тело, которое нельзя прочитать повторно; такой запрос не повторяется
+	stream io.Reader
+	public bool
+}
+
+func newRequest(method, path string) *request {
+	return &request{method: method, path: path, query: url.Values{}}
+}
+
+// idempotent - повтор запроса не меняет результат. POST не повторяется: при обрыве после записи
+// повтор создал бы копию.
+func (r *request) idempotent() bool {
+	if r.stream != nil {
+		return false
+	}
+	switch r.method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (r *request) withJSON(v any) (*request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+	r.body, r.contentType = body, "application/json"
+	return r, nil
+}
+
+// withIfMatch добавляет If-Match с версией задачи; версия 0 - "*", изменение без проверки
+func (r *request) withIfMatch(version int) *request {
+	tag := "*"
+	if version != 0 {
+		tag = `"` + strconv.Itoa(version) + `"`
+	}
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+	r.header.Set("If-Match", tag)
+	return r
+}
+
+// envelope - конверт ответа сервиса
+type envelope struct {
+	Status string          `json:"status"`
+	Error  *apiErrorBody   `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type apiErrorBody struct {
+	Code   string       `json:"code"`
+	Desc   string       `json:"desc"`
+	Fields []FieldError `json:"fields"`
+}
+
+// call выполняет запрос и раскладывает поле data ответа в out; out == nil - data не нужна
+func (c *Client) call(ctx context.Context, req *request, out any) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err = json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return errors.Wrapf(err, "failed to decode %s %s response", req.method, req.path)
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(env.Data, out), "failed to decode %s %s data", req.method, req.path)
+}
+
+// stream выполняет запрос и отдаёт тело успешного ответа как есть, закрыть его должен вызывающий
+func (c *Client) stream(ctx context.Context, req *request) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send повторяет запрос при 5xx и сетевых ошибках; ответ с кодом не 2xx превращается в *APIError
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 && req.stream == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		// тело ответа читается после выхода из send, поэтому отмена привязана к его закрытию
+		resp, err := c.retry(ctx, req)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+	return c.retry(ctx, req)
+}
+
+func (c *Client) retry(ctx context.Context, req *request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(ctx, req)
+		retryable := req.idempotent() && attempt < c.maxRetries && ctx.Err() == nil
+		switch {
+		case err != nil && !retryable:
+			return nil, err
+		case err != nil:
+		// отклонённый ограничением частоты запрос не выполнялся, повтор ждёт Retry-After
+		case (resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests) && retryable:
+			err = readAPIError(resp)
+		case resp.StatusCode >= http.StatusBadRequest:
+			return nil, readAPIError(resp)
+		default:
+			return resp, nil
+		}
+
+		delay := c.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, req *request) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	body := req.stream
+	if body == nil && req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if c.token != nil && !req.public {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get token")
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	// трассировка вызывающего кода продолжается на сервере; без настроенного OpenTelemetry ничего не добавляется
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", req.method, req.path)
+	}
+	return resp, nil
+}
+
+// backoff - экспоненциальная задержка с равномерным разбросом в [d/2, d]
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.backoffBase << attempt
+	if d <= 0 || d > c.backoffMax {
+		d = c.backoffMax
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// queryValues переводит структуру запроса с тегами query в параметры, пустые значения пропускаются
+func queryValues(v any) url.Values {
+	values := url.Values{}
+	addQueryValues(values, reflect.ValueOf(v))
+	return values
+}
+
+func addQueryValues(values url.Values, v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, hasTag := sf.Tag.Lookup("query")
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct {
+			addQueryValues(values, v.Field(i))
+			continue
+		}
+		if !hasTag || name == "" || v.Field(i).IsZero() {
+			continue
+		}
+
+		switch field := v.Field(i); field.Kind() {
+		case reflect.String:
+			values.Set(name, field.String())
+		case reflect.Bool:
+			values.Set(name, strconv.FormatBool(field.Bool()))
+		case reflect.Int, reflect.Int64, reflect.Int32:
+			values.Set(name, strconv.FormatInt(field.Int(), 10))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Ошибки по кодам dto.Error, проверяются через errors.Is
+var (
+	ErrNotFound     = errors.New(dto.NotFound)
+	ErrBadFormat    = errors.New(dto.FieldBadFormat)
+	ErrIncorrect    = errors.New(dto.FieldIncorrect)
+	ErrUnavailable  = errors.New(dto.ServiceUnavailable)
+	ErrUnauthorized = errors.New(dto.Unauthorized)
+	ErrForbidden    = errors.New(dto.Forbidden)
+	ErrBulkAborted  = errors.New(dto.BulkAborted)
+	// ErrPreconditionFailed - задача изменена после чтения, в APIError.Data её текущее состояние
+	ErrPreconditionFailed   = errors.New(dto.PreconditionFailed)
+	ErrPreconditionRequired = errors.New(dto.PreconditionRequired)
+	ErrMethodNotAllowed     = errors.New(dto.MethodNotAllowed)
+	ErrRequestTooLarge      = errors.New(dto.RequestTooLarge)
+	// ErrTimeout - сервис не уложился в REQUEST_TIMEOUT, запрос можно повторить
+	ErrTimeout = errors.New(dto.RequestTimeout)
+	// ErrRateLimited - превышено ограничение частоты запросов, повтор возможен через APIError.RetryAfter
+	ErrRateLimited = errors.New(dto.RateLimited)
+)
+
+// GetErrorCatalog - все коды ошибок API с их HTTP-статусами
+func (c *Client) GetErrorCatalog(ctx context.Context) ([]ErrorCode, error) {
+	req := newRequest(http.MethodGet, dto.ProblemTypeBase)
+	req.public = true
+	var codes []ErrorCode
+	return codes, c.call(ctx, req, &codes)
+}
+
+var codeErrors = map[string]error{
+	dto.NotFound:             ErrNotFound,
+	dto.FieldBadFormat:       ErrBadFormat,
+	dto.FieldIncorrect:       ErrIncorrect,
+	dto.ServiceUnavailable:   ErrUnavailable,
+	dto.Unauthorized:         ErrUnauthorized,
+	dto.Forbidden:            ErrForbidden,
+	dto.BulkAborted:          ErrBulkAborted,
+	dto.PreconditionFailed:   ErrPreconditionFailed,
+	dto.PreconditionRequired: ErrPreconditionRequired,
+	dto.MethodNotAllowed:     ErrMethodNotAllowed,
+	dto.RequestTooLarge:      ErrRequestTooLarge,
+	dto.RequestTimeout:       ErrTimeout,
+	dto.RateLimited:          ErrRateLimited,
+}
+
+// APIError - ответ сервиса с кодом не 2xx
+type APIError struct {
+	StatusCode int
+	Code       string
+	Desc       string
+	// Fields - нарушенные правила полей для FIELD_INCORRECT
+	Fields []FieldError
+	// Data - поле data ответа с ошибкой, например результаты отменённой пакетной операции
+	Data json.RawMessage
+	// RetryAfter - значение заголовка Retry-After, 0 если его нет
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api error: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("api error: status %d: %s: %s", e.StatusCode, e.Code, e.Desc)
+}
+
+func (e *APIError) Is(target error) bool {
+	return codeErrors[e.Code] == target
+}
+
+// readAPIError разбирает конверт ответа с ошибкой и закрывает тело
+func readAPIError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var env envelope
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil || json.Unmarshal(raw, &env) != nil {
+		return apiErr
+	}
+	if env.Error != nil {
+		apiErr.Code, apiErr.Desc, apiErr.Fields = env.Error.Code, env.Error.Desc, env.Error.Fields
+	}
+	apiErr.Data = env.Data
+	return apiErr
+}
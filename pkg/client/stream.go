@@ -0,0 +1,48 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SSE подписывается на изменения задач. Поток идёт, пока не отменён ctx или сервер не закрыл соединение;
+// срок из WithTimeout к нему не применяется.
+func (c *Client) SSE(ctx context.Context) iter.Seq2[TaskEvent, error] {
+	return func(yield func(TaskEvent, error) bool) {
+		req := newRequest(http.MethodGet, "/v1/tasks/stream")
+		// поток нельзя повторить с места обрыва, поэтому запрос не повторяется и не ограничивается по времени
+		req.stream = http.NoBody
+		body, err := c.stream(ctx, req)
+		if err != nil {
+			yield(TaskEvent{}, err)
+			return
+		}
+		defer body.Close()
+
+		scanner := bufio.NewScanner(body)
+		var data strings.Builder
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "data:"):
+				data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+			case line == "" && data.Len() > 0:
+				var event TaskEvent
+				err = json.Unmarshal([]byte(data.String()), &event)
+				data.Reset()
+				if !yield(event, errors.Wrap(err, "failed to decode task event")) || err != nil {
+					return
+				}
+			}
+		}
+		if err = scanner.Err(); err != nil && ctx.Err() == nil {
+			yield(TaskEvent{}, errors.Wrap(err, "task stream interrupted"))
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+var importContentTypes = map[string]string{
+	"csv":    "text/csv",
+	"ndjson": "application/x-ndjson",
+	"ics":    "text/calendar",
+}
+
+// CreateTask создаёт задачу и возвращает её id
+func (c *Client) CreateTask(ctx context.Context, task CreateTaskRequest) (string, error) {
+	req, err := newRequest(http.MethodPost, "/v1/tasks").withJSON(task)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		TaskID string `json:"task_id"`
+	}
+	return created.TaskID, c.call(ctx, req, &created)
+}
+
+func (c *Client) GetTaskByID(ctx context.Context, id string) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, newRequest(http.MethodGet, "/v1/tasks/"+url.PathEscape(id)), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetAllTasks возвращает одну страницу задач, для обхода всех страниц есть Tasks
+func (c *Client) GetAllTasks(ctx context.Context, filter TaskListRequest) ([]Task, error) {
+	req := newRequest(http.MethodGet, "/v1/tasks/all")
+	req.query = queryValues(filter)
+	var tasks []Task
+	return tasks, c.call(ctx, req, &tasks)
+}
+
+// Tasks обходит все задачи по фильтру страницами filter.Limit (по умолчанию DefaultPageSize).
+// Ошибка завершает обход.
+func (c *Client) Tasks(ctx context.Context, filter TaskListRequest) iter.Seq2[Task, error] {
+	return paginate(filter.Limit, filter.Offset, func(limit, offset int) ([]Task, error) {
+		filter.Limit, filter.Offset = limit, offset
+		return c.GetAllTasks(ctx, filter)
+	})
+}
+
+func (c *Client) GetAllTasksByUserID(ctx context.Context, userID string) ([]Task, error) {
+	var tasks []Task
+	return tasks, c.call(ctx, newRequest(http.MethodGet, "/v1/tasks/users/"+url.PathEscape(userID)), &tasks)
+}
+
+func (c *Client) GetLastTaskByUserID(ctx context.Context, userID string) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, newRequest(http.MethodGet, "/v1/tasks/users/"+url.PathEscape(userID)+"/last"), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) GetTasksByUserName(ctx context.Context, name string) ([]Task, error) {
+	var tasks []Task
+	return tasks, c.call(ctx, newRequest(http.MethodGet, "/v1/tasks/users/name/"+url.PathEscape(name)), &tasks)
+}
+
+// UpdateStatusByID меняет статус задачи, если её версия равна version; 0 - без проверки версии.
+// Устаревшая версия - ErrPreconditionFailed.
+func (c *Client) UpdateStatusByID(ctx context.Context, id, status string, version int) error {
+	req, err := newRequest(http.MethodPut, "/v1/tasks/"+url.PathEscape(id)).withJSON(map[string]string{"status": status})
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, req.withIfMatch(version), nil)
+}
+
+// DeleteTaskByID удаляет задачу, если её версия равна version; 0 - без проверки версии
+func (c *Client) DeleteTaskByID(ctx context.Context, id string, version int) error {
+	return c.call(ctx, newRequest(http.MethodDelete, "/v1/tasks/"+url.PathEscape(id)).withIfMatch(version), nil)
+}
+
+// BulkTasks выполняет пакет операций. Если атомарный пакет отменён, вместе с ErrBulkAborted
+// возвращаются результаты по каждой операции.
+func (c *Client) BulkTasks(ctx context.Context, bulk BulkRequest) ([]BulkResult, error) {
+	req, err := newRequest(http.MethodPost, "/v1/tasks/bulk").withJSON(bulk)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BulkResult
+	err = c.call(ctx, req, &results)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && errors.Is(err, ErrBulkAborted) && len(apiErr.Data) > 0 {
+		_ = json.Unmarshal(apiErr.Data, &results)
+	}
+	return results, err
+}
+
+// ImportTasks загружает задачи из src; тело читается один раз, поэтому запрос не повторяется
+func (c *Client) ImportTasks(ctx context.Context, src io.Reader, opts ImportOptions) (*ImportReport, error) {
+	req := newRequest(http.MethodPost, "/v1/tasks/import")
+	req.stream, req.contentType = src, importContentTypes[opts.Format]
+	req.query = queryValues(struct {
+		Format  string `query:"format"`
+		DryRun  bool   `query:"dry_run"`
+		Mapping string `query:"mapping"`
+		UserID  string `query:"user_id"`
+	}(opts))
+
+	var report ImportReport
+	if err := c.call(ctx, req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportTasks возвращает файл выгрузки, закрыть его должен вызывающий
+func (c *Client) ExportTasks(ctx context.Context, export ExportRequest) (io.ReadCloser, error) {
+	req := newRequest(http.MethodGet, "/v1/tasks/export")
+	req.query = queryValues(export)
+	return c.stream(ctx, req)
+}
+
+func (c *Client) GetTaskActivity(ctx context.Context, id string) ([]AuditRecord, error) {
+	var records []AuditRecord
+	return records, c.call(ctx, newRequest(http.MethodGet, "/v1/tasks/"+url.PathEscape(id)+"/activity"), &records)
+}
+
+// paginate обходит страницы limit/offset, пока сервер не вернёт неполную страницу
+func paginate[T any](limit, offset int, page func(limit, offset int) ([]T, error)) iter.Seq2[T, error] {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	return func(yield func(T, error) bool) {
+		for {
+			items, err := page(limit, offset)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) < limit {
+				return
+			}
+			offset += len(items)
+		}
+	}
+}
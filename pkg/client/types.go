@@ -0,0 +1,47 @@
+package client
+
+import (
+	"TemplatestPGSQL/internal/dto"
+	"TemplatestPGSQL/internal/repo"
+	"TemplatestPGSQL/internal/service"
+	"TemplatestPGSQL/pkg/validator"
+)
+
+// Типы ответов и запросов совпадают с типами сервиса, псевдонимы позволяют называть их вне модуля
+
+type (
+	Task            = repo.Task
+	User            = repo.User
+	AuditRecord     = repo.AuditRecord
+	Webhook         = repo.Webhook
+	WebhookDelivery = repo.WebhookDelivery
+	TaskEvent       = repo.TaskEvent
+
+	CreateTaskRequest    = service.PostRequest
+	UserRequest          = service.PostUserRequest
+	UserInfo             = service.UserInfo
+	TaskListRequest      = service.TaskListRequest
+	ExportRequest        = service.ExportRequest
+	CalendarFeedRequest  = service.CalendarFeedRequest
+	AuditRequest         = service.AuditRequest
+	CreateWebhookRequest = service.PostWebhookRequest
+	BulkRequest          = service.BulkRequest
+	BulkOperation        = service.BulkOperation
+	BulkResult           = service.BulkResult
+	ImportReport         = service.ImportReport
+	LoginResult          = service.LoginResult
+	CalendarLink         = service.CalendarLink
+
+	ErrorCode  = dto.CodeInfo
+	FieldError = validator.FieldError
+)
+
+// ImportOptions - параметры импорта, как у POST /v1/tasks/import
+type ImportOptions struct {
+	// Format - csv, ndjson или ics
+	Format  string
+	DryRun  bool
+	Mapping string
+	// UserID - владелец задач из .ics
+	UserID string
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Login обменивает имя и пароль на токен пользователя
+func (c *Client) Login(ctx context.Context, name, password string) (*LoginResult, error) {
+	req, err := newRequest(http.MethodPost, "/v1/login").withJSON(UserRequest{Name: name, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	req.public = true
+
+	var result LoginResult
+	if err = c.call(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateUser создаёт пользователя и возвращает его id
+func (c *Client) CreateUser(ctx context.Context, user UserRequest) (string, error) {
+	req, err := newRequest(http.MethodPost, "/v1/users").withJSON(user)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		UserID string `json:"user_id"`
+	}
+	return created.UserID, c.call(ctx, req, &created)
+}
+
+// GetUsers возвращает всех пользователей, нужен сервисный токен
+func (c *Client) GetUsers(ctx context.Context) ([]UserInfo, error) {
+	var users []UserInfo
+	return users, c.call(ctx, newRequest(http.MethodGet, "/v1/users"), &users)
+}
+
+// DeleteUserByID удаляет пользователя вместе с его задачами, нужен сервисный токен
+func (c *Client) DeleteUserByID(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, "/v1/users/"+url.PathEscape(id)), nil)
+}
@@ -0,0 +1,151 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Пакет каталогов сообщений. Исходный язык текстов в коде - английский: каталог en описывает только
+// коды ошибок, остальные каталоги переводят и коды, и английские тексты сообщений.
+
+// Source - язык текстов в коде
+const Source = "en"
+
+// Catalog - сообщения одного языка
+type Catalog struct {
+	// Codes - описание каждого кода ошибки dto
+	Codes map[string]string `json:"codes"`
+	// Messages - переводы английских текстов: правил валидации и постоянных описаний ошибок
+	Messages map[string]string `json:"messages"`
+}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	catalogs    = make(map[string]Catalog)
+	defaultLang = Source
+)
+
+func init() {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		raw, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog Catalog
+		if err = json.Unmarshal(raw, &catalog); err != nil {
+			panic(errors.Wrapf(err, "invalid catalog %s", file.Name()))
+		}
+		catalogs[strings.TrimSuffix(file.Name(), ".json")] = catalog
+	}
+}
+
+// Languages - языки, для которых есть каталог
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Lookup - каталог языка
+func Lookup(lang string) (Catalog, bool) {
+	catalog, ok := catalogs[lang]
+	return catalog, ok
+}
+
+// SetDefault задаёт язык для запросов без подходящего Accept-Language
+func SetDefault(lang string) error {
+	if _, ok := catalogs[lang]; !ok {
+		return errors.Errorf("no message catalog for language %q", lang)
+	}
+	defaultLang = lang
+	return nil
+}
+
+func Default() string {
+	return defaultLang
+}
+
+// Match выбирает язык по заголовку Accept-Language с учётом q; язык без каталога пропускается
+func Match(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if primary == "*" {
+			primary = defaultLang
+		}
+		if _, ok := catalogs[primary]; ok && q > 0 {
+			candidates = append(candidates, candidate{lang: primary, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return defaultLang
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+type langKey struct{}
+
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// FromContext - язык запроса, без него - язык по умолчанию
+func FromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(langKey{}).(string); ok {
+		return lang
+	}
+	return defaultLang
+}
+
+// Text переводит английский текст; без перевода текст возвращается как есть. У текста вида
+// "сообщение: подробности" переводится сообщение, подробности (id, имя поля) остаются как есть
+func Text(lang, text string) string {
+	messages := catalogs[lang].Messages
+	if translated, ok := messages[text]; ok {
+		return translated
+	}
+	if message, detail, ok := strings.Cut(text, ": "); ok {
+		if translated, ok := messages[message]; ok {
+			return translated + ": " + detail
+		}
+	}
+	return text
+}
+
+// Code - описание кода ошибки на языке lang, fallback - если в каталоге его нет
+func Code(lang, code, fallback string) string {
+	if title, ok := catalogs[lang].Codes[code]; ok {
+		return title
+	}
+	return fallback
+}
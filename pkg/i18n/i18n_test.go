@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"Empty", "", Source},
+		{"Region", "ru-RU", "ru"},
+		{"Quality", "en;q=0.5, ru-RU;q=0.9", "ru"},
+		{"Unsupported skipped", "de-DE, ru;q=0.3", "ru"},
+		{"Only unsupported", "de, fr", Source},
+		{"Wildcard", "*", Source},
+		{"Zero quality", "ru;q=0", Source},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Match(tt.header))
+		})
+	}
+}
+
+func TestDefaultLanguage(t *testing.T) {
+	require.NoError(t, SetDefault("ru"))
+	t.Cleanup(func() { _ = SetDefault(Source) })
+
+	assert.Equal(t, "ru", Match("de"))
+	assert.Equal(t, "ru", FromContext(context.Background()))
+	assert.Equal(t, "en", FromContext(WithLanguage(context.Background(), "en")))
+	assert.Error(t, SetDefault("de"))
+}
+
+func TestText(t *testing.T) {
+	assert.Equal(t, "Обязательное поле", Text("ru", "Field is required"))
+	assert.Equal(t, "Field is required", Text("en", "Field is required"))
+	assert.Equal(t, "no translation", Text("ru", "no translation"))
+	assert.Equal(t, "пользователь не найден: 5", Text("ru", "user not found: 5"))
+	assert.Equal(t, "no translation: 5", Text("ru", "no translation: 5"))
+}
@@ -0,0 +1,486 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	contentJSON = "application/json"
+	// поле конверта ответа, в которое подставляется схема Route.Response
+	envelopeData = "data"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Route - описание обработчика маршрута
+type Route struct {
+	// OperationID по умолчанию - имя метода-обработчика
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	// Public - маршрут доступен без авторизации
+	Public bool
+	// Admin - маршрут доступен только администратору
+	Admin bool
+
+	// Path, Query, Header и Body - структуры запроса с тегами params, query, header и json
+	Path   any
+	Query  any
+	Header any
+	Body   any
+	// Consumes - типы тела, которое читается как есть, без разбора в Body
+	Consumes []string
+
+	// Response - значение поля data успешного ответа
+	Response any
+	// Produces - типы успешного ответа, если он не в конверте JSON
+	Produces []string
+	// Status - код успешного ответа, по умолчанию 200
+	Status int
+	// Errors - коды ошибок маршрута помимо общих 400, 401, 403 и 500
+	Errors []int
+}
+
+// Spec - всё, чего нет в таблице маршрутов fiber
+type Spec struct {
+	Info Info
+	// Routes - описания маршрутов по ключу RouteKey
+	Routes map[string]Route
+	// Envelope - конверт ответов; в успешном ответе поле data заменяется схемой Route.Response
+	Envelope any
+	// Security - схемы авторизации, любая из них подходит для непубличных маршрутов
+	Security map[string]SecurityScheme
+	// Rules - схемы пользовательских правил validate
+	Rules map[string]Schema
+}
+
+// RouteKey - ключ описания маршрута: метод и путь в записи fiber, например "GET /v1/tasks/:id"
+func RouteKey(method, path string) string {
+	return method + " " + normalizePath(path)
+}
+
+// Build описывает все маршруты приложения; маршруты без описания помечаются Undocumented
+func (s Spec) Build(routes []fiber.Route) *Document {
+	g := &generator{
+		rules:   s.Rules,
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.Info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         g.schemas,
+			SecuritySchemes: s.Security,
+		},
+	}
+
+	var envelope *Schema
+	if s.Envelope != nil {
+		envelope = g.schema(reflect.TypeOf(s.Envelope))
+	}
+
+	for _, route := range routes {
+		// fiber добавляет HEAD к каждому GET
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		desc, ok := s.Routes[RouteKey(route.Method, route.Path)]
+		op := g.operation(route, desc, envelope, s.Security)
+		op.Undocumented = !ok
+
+		path := PathTemplate(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+type generator struct {
+	rules   map[string]Schema
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func (g *generator) operation(route fiber.Route, desc Route, envelope *Schema, security map[string]SecurityScheme) *Operation {
+	op := &Operation{
+		OperationID: desc.OperationID,
+		Summary:     desc.Summary,
+		Description: desc.Description,
+		Tags:        desc.Tags,
+		Responses:   make(map[string]Response),
+	}
+	if op.OperationID == "" {
+		op.OperationID = handlerName(route.Handlers)
+	}
+
+	pathFields := make(map[string]field)
+	for _, f := range g.fields(desc.Path, "params") {
+		pathFields[f.name] = f
+	}
+	for _, name := range route.Params {
+		schema := &Schema{Type: "string"}
+		if f, ok := pathFields[name]; ok {
+			schema = f.schema
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	for _, f := range g.fields(desc.Query, "query") {
+		op.Parameters = append(op.Parameters, Parameter{Name: f.name, In: "query", Required: f.required, Schema: f.schema})
+	}
+	for _, f := range g.fields(desc.Header, "header") {
+		op.Parameters = append(op.Parameters, Parameter{Name: f.name, In: "header", Required: f.required, Schema: f.schema})
+	}
+
+	if desc.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{contentJSON: {Schema: g.schema(reflect.TypeOf(desc.Body))}},
+		}
+	} else if len(desc.Consumes) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: binaryContent(desc.Consumes)}
+	}
+
+	status := desc.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case len(desc.Produces) > 0:
+		success.Content = binaryContent(desc.Produces)
+	case envelope != nil && status != fiber.StatusSwitchingProtocols:
+		success.Content = map[string]MediaType{contentJSON: {Schema: g.envelope(envelope, desc.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	failure := func(status int) {
+		response := Response{Description: http.StatusText(status)}
+		if envelope != nil {
+			response.Content = map[string]MediaType{contentJSON: {Schema: envelope}}
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	failure(fiber.StatusBadRequest)
+	if !desc.Public {
+		failure(fiber.StatusUnauthorized)
+		names := make([]string, 0, len(security))
+		for name := range security {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			op.Security = append(op.Security, map[string][]string{name: {}})
+		}
+	}
+	if desc.Admin {
+		failure(fiber.StatusForbidden)
+	}
+	for _, status := range desc.Errors {
+		failure(status)
+	}
+	failure(fiber.StatusInternalServerError)
+	return op
+}
+
+// envelope подставляет схему data в конверт ответа
+func (g *generator) envelope(envelope *Schema, data any) *Schema {
+	if data == nil {
+		return envelope
+	}
+	base := g.resolve(envelope)
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(base.Properties))}
+	for name, prop := range base.Properties {
+		schema.Properties[name] = prop
+	}
+	schema.Properties[envelopeData] = g.schema(reflect.TypeOf(data))
+	return schema
+}
+
+func (g *generator) resolve(schema *Schema) *Schema {
+	if schema.Ref == "" {
+		return schema
+	}
+	return g.schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+
+	var schema *Schema
+	switch {
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		// произвольный JSON
+		schema = &Schema{}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			schema = &Schema{Type: "string"}
+		case reflect.Bool:
+			schema = &Schema{Type: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			schema = &Schema{Type: "integer"}
+		case reflect.Int64, reflect.Uint64:
+			schema = &Schema{Type: "integer", Format: "int64"}
+		case reflect.Float32, reflect.Float64:
+			schema = &Schema{Type: "number"}
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				schema = &Schema{Type: "string", Format: "byte"}
+			} else {
+				schema = &Schema{Type: "array", Items: g.schema(t.Elem())}
+			}
+		case reflect.Map:
+			schema = &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+		case reflect.Struct:
+			if t.Name() == "" {
+				schema = g.object(t)
+			} else {
+				// у $ref в OpenAPI 3.0 не может быть соседних ключей
+				return g.ref(t)
+			}
+		default:
+			schema = &Schema{}
+		}
+	}
+	schema.Nullable = nullable
+	return schema
+}
+
+// ref добавляет именованную структуру в components и возвращает ссылку на неё
+func (g *generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		// неэкспортируемые типы ответов тоже попадают в документ с заглавной буквы
+		name = strings.ToUpper(name[:1]) + name[1:]
+		if _, taken := g.schemas[name]; taken {
+			pkg := t.PkgPath()
+			pkg = pkg[strings.LastIndexByte(pkg, '/')+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		g.names[t] = name
+		// заглушка на случай рекурсивных типов
+		g.schemas[name] = &Schema{}
+		g.schemas[name] = g.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range g.structFields(t, "json") {
+		schema.Properties[f.name] = f.schema
+		if f.required {
+			schema.Required = append(schema.Required, f.name)
+		}
+	}
+	return schema
+}
+
+type field struct {
+	name     string
+	schema   *Schema
+	required bool
+}
+
+// fields возвращает поля структуры v с тегом tagKey; nil - структуры нет
+func (g *generator) fields(v any, tagKey string) []field {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return g.structFields(t, tagKey)
+}
+
+// structFields обходит поля как encoding/json: встроенные структуры без тега раскрываются.
+// Для тела (json) поле без тега называется как в Go, а поля из пути (params) пропускаются;
+// для query, params и header учитываются только поля с тегом.
+func (g *generator) structFields(t reflect.Type, tagKey string) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup(tagKey)
+		name, _, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, g.structFields(sf.Type, tagKey)...)
+			continue
+		}
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if tagKey == "json" {
+			if _, isPath := sf.Tag.Lookup("params"); isPath && !hasTag {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+		} else if name == "" {
+			continue
+		}
+
+		schema := g.schema(sf.Type)
+		required := g.constrain(schema, sf.Tag.Get("validate"))
+		fields = append(fields, field{name: name, schema: schema, required: required})
+	}
+	return fields
+}
+
+// constrain переносит правила validate в схему и сообщает, обязательно ли поле
+func (g *generator) constrain(schema *Schema, rules string) bool {
+	required := false
+	target := schema
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if target == nil || target.Ref != "" {
+			if name == "required" && target == schema {
+				required = true
+			}
+			continue
+		}
+
+		switch name {
+		case "required":
+			required = required || target == schema
+		case "dive":
+			target = target.Items
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "min", "gte":
+			bound(target, param, true, false)
+		case "max", "lte":
+			bound(target, param, false, false)
+		case "gt":
+			bound(target, param, true, true)
+		case "lt":
+			bound(target, param, false, true)
+		case "len":
+			bound(target, param, true, false)
+			bound(target, param, false, false)
+		case "url", "uri":
+			target.Format = "uri"
+		case "email":
+			target.Format = "email"
+		default:
+			if custom, ok := g.rules[name]; ok {
+				if custom.Pattern != "" {
+					target.Pattern = custom.Pattern
+				}
+				if custom.Format != "" {
+					target.Format = custom.Format
+				}
+			}
+		}
+	}
+	return required
+}
+
+// bound задаёт границу по смыслу правила: длину строки, число элементов или значение
+func bound(schema *Schema, param string, lower, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string", "array":
+		n := int(value)
+		if exclusive && lower {
+			n++
+		} else if exclusive {
+			n--
+		}
+		switch {
+		case schema.Type == "string" && lower:
+			schema.MinLength = &n
+		case schema.Type == "string":
+			schema.MaxLength = &n
+		case lower:
+			schema.MinItems = &n
+		default:
+			schema.MaxItems = &n
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum, schema.ExclusiveMinimum = &value, exclusive
+		} else {
+			schema.Maximum, schema.ExclusiveMaximum = &value, exclusive
+		}
+	}
+}
+
+func binaryContent(types []string) map[string]MediaType {
+	content := make(map[string]MediaType, len(types))
+	for _, contentType := range types {
+		content[contentType] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	return content
+}
+
+// handlerName возвращает имя метода последнего обработчика: service.Service.GetAllTasks-fm -> GetAllTasks,
+// для замыканий - имя функции, которая их создала: stream.(*Hub).SSE.func1 -> SSE
+func handlerName(handlers []fiber.Handler) string {
+	if len(handlers) == 0 {
+		return ""
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(handlers[len(handlers)-1]).Pointer()).Name()
+	parts := strings.Split(strings.TrimSuffix(name, "-fm"), ".")
+	for len(parts) > 1 && isClosureName(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
+
+func isClosureName(name string) bool {
+	digits := strings.TrimPrefix(name, "func")
+	if digits == name || digits == "" {
+		return false
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
+
+func normalizePath(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// templatePath переводит параметры fiber в шаблон OpenAPI: /tasks/:id -> /tasks/{id}
+func PathTemplate(path string) string {
+	segments := strings.Split(normalizePath(path), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(segment[1:], "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type base struct {
+	ID string `json:"id" params:"id" validate:"required,intString"`
+}
+
+type item struct {
+	base
+	Name   string     `json:"name" validate:"required,min=2,max=10"`
+	Tags   []string   `json:"tags" validate:"omitempty,max=3,dive,oneof=a b"`
+	Count  int        `json:"count" validate:"gt=0,lte=5"`
+	Due    *time.Time `json:"due"`
+	Secret string     `json:"-"`
+	PathID string     `params:"item_id"`
+}
+
+func (item) handler(*fiber.Ctx) error { return nil }
+
+func TestBuild(t *testing.T) {
+	app := fiber.New()
+	app.Put("/items/:item_id", item{}.handler)
+	app.Get("/other/", func(*fiber.Ctx) error { return nil })
+
+	doc := Spec{
+		Routes: map[string]Route{"PUT /items/:item_id": {Path: item{}, Body: item{}}},
+		Rules:  map[string]Schema{"intString": {Pattern: "^[0-9]+$"}},
+	}.Build(app.GetRoutes(true))
+
+	op := doc.Paths["/items/{item_id}"]["put"]
+	require.NotNil(t, op)
+	assert.Equal(t, "handler", op.OperationID)
+	assert.False(t, op.Undocumented)
+	require.Len(t, op.Parameters, 1)
+	assert.Equal(t, Parameter{Name: "item_id", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[0])
+
+	schema := doc.Components.Schemas["Item"]
+	require.NotNil(t, schema)
+	assert.Equal(t, []string{"id", "name"}, schema.Required)
+	assert.Equal(t, "^[0-9]+$", schema.Properties["id"].Pattern)
+	assert.Equal(t, 2, *schema.Properties["name"].MinLength)
+	assert.Equal(t, 10, *schema.Properties["name"].MaxLength)
+	assert.Equal(t, 3, *schema.Properties["tags"].MaxItems)
+	assert.Equal(t, []string{"a", "b"}, schema.Properties["tags"].Items.Enum)
+	assert.True(t, schema.Properties["count"].ExclusiveMinimum)
+	assert.Equal(t, 5.0, *schema.Properties["count"].Maximum)
+	assert.True(t, schema.Properties["due"].Nullable)
+	assert.NotContains(t, schema.Properties, "Secret")
+	assert.NotContains(t, schema.Properties, "PathID")
+
+	other := doc.Paths["/other"]["get"]
+	require.NotNil(t, other)
+	assert.True(t, other.Undocumented)
+}
+
+func TestBuildHeadersAndErrors(t *testing.T) {
+	type header struct {
+		Key string `header:"Idempotency-Key" validate:"omitempty,max=255"`
+	}
+	app := fiber.New()
+	app.Post("/items", item{}.handler)
+
+	doc := Spec{
+		Routes: map[string]Route{"POST /items": {Header: header{}, Errors: []int{fiber.StatusConflict}}},
+	}.Build(app.GetRoutes(true))
+
+	op := doc.Paths["/items"]["post"]
+	require.NotNil(t, op)
+	require.Len(t, op.Parameters, 1)
+	assert.Equal(t, "header", op.Parameters[0].In)
+	assert.Equal(t, "Idempotency-Key", op.Parameters[0].Name)
+	assert.Equal(t, 255, *op.Parameters[0].Schema.MaxLength)
+	assert.Contains(t, op.Responses, "409")
+}
@@ -0,0 +1,26 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed redoc.html
+var redocPage string
+
+var redocTemplate = template.Must(template.New("redoc").Parse(redocPage))
+
+// UI отдаёт страницу Redoc, которая загружает документ по адресу specURL
+func UI(title, specURL string) fiber.Handler {
+	var page bytes.Buffer
+	// шаблон проверен при старте, ошибка возможна только при записи в буфер
+	_ = redocTemplate.Execute(&page, struct{ Title, SpecURL string }{title, specURL})
+
+	return func(ctx *fiber.Ctx) error {
+		ctx.Type("html", "utf-8")
+		return ctx.Send(page.Bytes())
+	}
+}
@@ -1,11 +1,14 @@
 package validator
 
 import (
+	"TemplatestPGSQL/pkg/i18n"
 	"context"
 	"errors"
 	"github.com/go-playground/validator"
+	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // Пакет валидации для входных данных с http
@@ -21,8 +24,23 @@ const (
 	ErrFieldBelowMinVal   = "Field is below minimum value"
 	ErrUnknownValidation  = "Unknown validation error"
 	ErrInvalidIntString   = "Invalid int format"
+	ErrFieldNotOneOf      = "Field has unsupported value"
 )
 
+// Messages - все тексты ошибок валидации, каждый каталог pkg/i18n должен их переводить
+var Messages = []string{
+	ErrInvalidFormat, ErrFieldRequired, ErrFieldExceedsMaxLen, ErrFieldBelowMinLen, ErrFieldExceedsMaxVal,
+	ErrFieldBelowMinVal, ErrUnknownValidation, ErrInvalidIntString, ErrFieldNotOneOf,
+}
+
+// шаблоны пользовательских правил, используются и в описании API
+const (
+	TagPattern       = `^#[a-z0-9_\-]+$`
+	IntStringPattern = `^[+-]?[0-9]+$`
+)
+
+var tagRegexp = regexp.MustCompile(TagPattern)
+
 func init() {
 	SetValidator(New())
 }
@@ -43,8 +61,7 @@ func Validator() *validator.Validate {
 }
 
 func validateTag(fl validator.FieldLevel) bool {
-	re, _ := regexp.Compile(`^#[a-z0-9_\-]+$`)
-	return re.MatchString(fl.Field().String())
+	return tagRegexp.MatchString(fl.Field().String())
 }
 
 func validateIntString(fl validator.FieldLevel) bool {
@@ -58,11 +75,44 @@ func validateIntString(fl validator.FieldLevel) bool {
 	return true
 }
 
+// FieldError - нарушенное правило одного поля
+type FieldError struct {
+	// Field - имя поля, как его передаёт клиент: тег json, query, params или header
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	// Param - параметр правила: max=5 - "5", oneof=new done - "new done"
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+	// Namespace - путь к полю в структуре, например PostRequest.Title
+	Namespace string `json:"-"`
+}
+
+// Errors - все нарушенные правила запроса
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Message+": "+fieldErr.Namespace)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields - нарушенные правила из ошибки Validate, nil для других ошибок
+func Fields(err error) []FieldError {
+	var vErrors Errors
+	if errors.As(err, &vErrors) {
+		return vErrors
+	}
+	return nil
+}
+
 func Validate(ctx context.Context, structure any) error {
-	return parseValidationErrors(Validator().StructCtx(ctx, structure))
+	return parseValidationErrors(i18n.FromContext(ctx), reflect.TypeOf(structure), Validator().StructCtx(ctx, structure))
 }
 
-func parseValidationErrors(err error) error {
+// parseValidationErrors собирает все нарушенные правила, сообщения - на языке lang
+func parseValidationErrors(lang string, root reflect.Type, err error) error {
 	if err == nil {
 		return nil
 	}
@@ -72,26 +122,76 @@ func parseValidationErrors(err error) error {
 		return nil
 	}
 
-	validationError := vErrors[0]
-	var validationErrorDescription string
-	switch validationError.Tag() {
-	case "tag":
-		validationErrorDescription = ErrInvalidFormat
+	result := make(Errors, 0, len(vErrors))
+	for _, validationError := range vErrors {
+		result = append(result, FieldError{
+			Field:     clientName(root, validationError.StructNamespace()),
+			Rule:      validationError.Tag(),
+			Param:     validationError.Param(),
+			Message:   i18n.Text(lang, message(validationError.Tag())),
+			Namespace: validationError.Namespace(),
+		})
+	}
+	return result
+}
+
+func message(tag string) string {
+	switch tag {
+	case "tag", "url":
+		return ErrInvalidFormat
 	case "required":
-		validationErrorDescription = ErrFieldRequired
+		return ErrFieldRequired
 	case "max":
-		validationErrorDescription = ErrFieldExceedsMaxLen
+		return ErrFieldExceedsMaxLen
 	case "min":
-		validationErrorDescription = ErrFieldBelowMinLen
+		return ErrFieldBelowMinLen
 	case "lt", "lte":
-		validationErrorDescription = ErrFieldExceedsMaxVal
+		return ErrFieldExceedsMaxVal
 	case "gt", "gte":
-		validationErrorDescription = ErrFieldBelowMinVal
+		return ErrFieldBelowMinVal
 	case "intString":
-		validationErrorDescription = ErrInvalidIntString
+		return ErrInvalidIntString
+	case "oneof":
+		return ErrFieldNotOneOf
 	default:
-		validationErrorDescription = ErrUnknownValidation
+		return ErrUnknownValidation
 	}
+}
 
-	return errors.New(validationErrorDescription + ": " + validationError.Namespace())
+// clientName переводит путь PostRequest.Operations[2].ID в имена из тегов: operations[2].id
+func clientName(root reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")[1:]
+	names := make([]string, 0, len(parts))
+	t := root
+	for _, part := range parts {
+		name, index, _ := strings.Cut(part, "[")
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			t = t.Elem()
+		}
+		var sf reflect.StructField
+		found := false
+		if t != nil && t.Kind() == reflect.Struct {
+			sf, found = t.FieldByName(name)
+		}
+		if !found {
+			names = append(names, part)
+			t = nil
+			continue
+		}
+		if index != "" {
+			index = "[" + index
+		}
+		names = append(names, tagName(sf)+index)
+		t = sf.Type
+	}
+	return strings.Join(names, ".")
+}
+
+func tagName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "query", "params", "header"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
 }
@@ -15,6 +15,7 @@ type TestStruct struct {
 	LtField        int    `validate:"lt=10"`
 	GteField       int    `validate:"gte=5"`
 	IntStringField string `validate:"intString"`
+	OneOfField     string `validate:"omitempty,oneof=new done"`
 }
 
 func TestValidate(t *testing.T) {
@@ -32,37 +33,37 @@ func TestValidate(t *testing.T) {
 		},
 		{
 			name:       "Missing required field",
-			input:      TestStruct{TagField: "#tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 5},
+			input:      TestStruct{TagField: "#tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 5, IntStringField: "1"},
 			wantErr:    true,
 			wantErrMsg: ErrFieldRequired + ": TestStruct.RequiredField",
 		},
 		{
 			name:       "Invalid tag field",
-			input:      TestStruct{RequiredField: "value", TagField: "tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 5},
+			input:      TestStruct{RequiredField: "value", TagField: "tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 5, IntStringField: "1"},
 			wantErr:    true,
 			wantErrMsg: ErrInvalidFormat + ": TestStruct.TagField",
 		},
 		{
 			name:       "Field exceeds max length",
-			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "toolong", MinField: "val", LtField: 5, GteField: 5},
+			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "toolong", MinField: "val", LtField: 5, GteField: 5, IntStringField: "1"},
 			wantErr:    true,
 			wantErrMsg: ErrFieldExceedsMaxLen + ": TestStruct.MaxField",
 		},
 		{
 			name:       "Field below min length",
-			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "va", LtField: 5, GteField: 5},
+			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "va", LtField: 5, GteField: 5, IntStringField: "1"},
 			wantErr:    true,
 			wantErrMsg: ErrFieldBelowMinLen + ": TestStruct.MinField",
 		},
 		{
 			name:       "Field exceeds max value",
-			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "val", LtField: 15, GteField: 5},
+			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "val", LtField: 15, GteField: 5, IntStringField: "1"},
 			wantErr:    true,
 			wantErrMsg: ErrFieldExceedsMaxVal + ": TestStruct.LtField",
 		},
 		{
 			name:       "Field below min value",
-			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 3},
+			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 3, IntStringField: "1"},
 			wantErr:    true,
 			wantErrMsg: ErrFieldBelowMinVal + ": TestStruct.GteField",
 		},
@@ -72,6 +73,12 @@ func TestValidate(t *testing.T) {
 			wantErr:    true,
 			wantErrMsg: ErrInvalidIntString + ": TestStruct.IntStringField",
 		},
+		{
+			name:       "Field isnt one of allowed values",
+			input:      TestStruct{RequiredField: "value", TagField: "#tag", MaxField: "value", MinField: "val", LtField: 5, GteField: 5, IntStringField: "1", OneOfField: "archived"},
+			wantErr:    true,
+			wantErrMsg: ErrFieldNotOneOf + ": TestStruct.OneOfField",
+		},
 	}
 
 	for _, tt := range tests {
@@ -86,3 +93,28 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReportsEveryField(t *testing.T) {
+	type request struct {
+		Title  string `json:"title" validate:"required"`
+		Status string `query:"status" validate:"oneof=new done"`
+		Items  []struct {
+			Name string `json:"name" validate:"max=3"`
+		} `json:"items" validate:"dive"`
+	}
+	req := request{Status: "lost"}
+	req.Items = append(req.Items, struct {
+		Name string `json:"name" validate:"max=3"`
+	}{Name: "long"})
+
+	err := Validate(context.Background(), req)
+
+	assert.Equal(t, []FieldError{
+		{Field: "title", Rule: "required", Message: ErrFieldRequired, Namespace: "request.Title"},
+		{Field: "status", Rule: "oneof", Param: "new done", Message: ErrFieldNotOneOf, Namespace: "request.Status"},
+		{Field: "items[0].name", Rule: "max", Param: "3", Message: ErrFieldExceedsMaxLen, Namespace: "request.Items[0].Name"},
+	}, Fields(err))
+	assert.EqualError(t, err, "Field is required: request.Title; Field has unsupported value: request.Status; "+
+		"Field exceeds maximum length: request.Items[0].Name")
+	assert.Nil(t, Fields(assert.AnError))
+}